@@ -0,0 +1,401 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+const bsonMaxNestingDepth = 1000
+
+// MarshalBSON encodes a JSON object v in the MongoDB Extended JSON v2 representation into a BSON document.
+// Type wrappers in either the canonical or the relaxed format are encoded into the BSON types they represent,
+// and the other JSON numbers are encoded as int32 or int64 if they are written as integers in the ranges, as decimal128 if they are written as integers out of the ranges,
+// and as doubles otherwise.
+// Members are sorted by their keys, and malformed type wrappers are rejected with errors.
+func MarshalBSON(v Value) ([]byte, error) {
+	if v.Type() != TypeObject || isExtJSONWrapper(v) {
+		return nil, fmt.Errorf(`fail to marshal Value into BSON: JSON value must be a document`)
+	}
+	var b bytes.Buffer
+	if err := encodeBSONDocument(&b, v, 0); err != nil {
+		return nil, fmt.Errorf(`fail to marshal Value into BSON: %w`, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// encodeBSONDocument encodes a JSON object or array into a BSON document.
+func encodeBSONDocument(b *bytes.Buffer, v Value, depth int) error {
+	if depth > bsonMaxNestingDepth {
+		return fmt.Errorf(`JSON values are nested too deeply`)
+	}
+	type element struct {
+		key string
+		val Value
+	}
+	elements := []element{}
+	switch v.Type() {
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		for _, key := range keys {
+			elements = append(elements, element{key: key, val: v.ObjectGetElm(key)})
+		}
+	case TypeArray:
+		for i, elm := range v.ArrayAll() {
+			elements = append(elements, element{key: strconv.Itoa(i), val: elm})
+		}
+	}
+
+	doc := []byte{0, 0, 0, 0}
+	for _, e := range elements {
+		kind, payload, err := encodeBSONElement(e.val, depth+1)
+		if err != nil {
+			return fmt.Errorf(`at %q: %w`, e.key, err)
+		}
+		doc = append(doc, kind)
+		if doc, err = appendBSONCString(doc, e.key); err != nil {
+			return err
+		}
+		doc = append(doc, payload...)
+	}
+	doc = append(doc, 0)
+	binary.LittleEndian.PutUint32(doc, uint32(len(doc)))
+	b.Write(doc)
+
+	return nil
+}
+
+// encodeBSONElement encodes a JSON value into the type and the payload of a BSON element.
+func encodeBSONElement(v Value, depth int) (byte, []byte, error) {
+	switch v.Type() {
+	case TypeNull:
+		return bsonNull, nil, nil
+	case TypeBoolean:
+		if v.BooleanGet() {
+			return bsonBoolean, []byte{1}, nil
+		}
+		return bsonBoolean, []byte{0}, nil
+	case TypeNumber:
+		return encodeBSONNumber(v.NumberGet())
+	case TypeString:
+		return bsonString, appendBSONString(nil, v.StringGet()), nil
+	case TypeArray:
+		var b bytes.Buffer
+		if err := encodeBSONDocument(&b, v, depth); err != nil {
+			return 0, nil, err
+		}
+		return bsonArray, b.Bytes(), nil
+	default:
+		if isExtJSONWrapper(v) {
+			return encodeExtJSONWrapper(v, depth)
+		}
+		var b bytes.Buffer
+		if err := encodeBSONDocument(&b, v, depth); err != nil {
+			return 0, nil, err
+		}
+		return bsonDocument, b.Bytes(), nil
+	}
+}
+
+// UnmarshalBSON decodes a BSON document into a JSON object in the MongoDB Extended JSON v2 representation of the format mode.
+func UnmarshalBSON(b []byte, mode ExtJSONMode) (Value, error) {
+	d := &bsonDecoder{src: b, mode: mode}
+	v, err := d.decodeDocument(0, false)
+	if err == nil && d.pos < len(d.src) {
+		err = fmt.Errorf(`unexpected data after BSON document at offset %d`, d.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`fail to unmarshal BSON into Value: %w`, err)
+	}
+
+	return v, nil
+}
+
+type bsonDecoder struct {
+	src  []byte
+	pos  int
+	mode ExtJSONMode
+}
+
+func (d *bsonDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.src)-d.pos < n {
+		return nil, fmt.Errorf(`unexpected end of BSON data at offset %d`, d.pos)
+	}
+	b := d.src[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *bsonDecoder) readUint32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (d *bsonDecoder) readUint64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (d *bsonDecoder) readCString() (string, error) {
+	n := bytes.IndexByte(d.src[d.pos:], 0)
+	if n < 0 {
+		return "", fmt.Errorf(`unterminated cstring at offset %d`, d.pos)
+	}
+	b, _ := d.read(n + 1)
+	if !utf8.Valid(b[:n]) {
+		return "", fmt.Errorf(`cstring at offset %d is not valid UTF-8`, d.pos-n-1)
+	}
+
+	return string(b[:n]), nil
+}
+
+func (d *bsonDecoder) readString() (string, error) {
+	start := d.pos
+	n, err := d.readUint32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 || n > math.MaxInt32 {
+		return "", fmt.Errorf(`invalid string length %d at offset %d`, n, start)
+	}
+	b, err := d.read(int(n))
+	if err != nil {
+		return "", err
+	}
+	if b[n-1] != 0 {
+		return "", fmt.Errorf(`string at offset %d is not terminated with NUL`, start)
+	}
+	if !utf8.Valid(b[:n-1]) {
+		return "", fmt.Errorf(`string at offset %d is not valid UTF-8`, start)
+	}
+
+	return string(b[:n-1]), nil
+}
+
+// decodeDocument decodes a BSON document into a JSON object, or a JSON array if isArray is true.
+func (d *bsonDecoder) decodeDocument(depth int, isArray bool) (Value, error) {
+	if depth > bsonMaxNestingDepth {
+		return nil, fmt.Errorf(`BSON documents are nested too deeply`)
+	}
+	start := d.pos
+	size, err := d.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if size < 5 || uint64(size) > uint64(len(d.src)-start) {
+		return nil, fmt.Errorf(`invalid document size %d at offset %d`, size, start)
+	}
+	end := start + int(size)
+
+	obj, arr := Object(), Array()
+	for d.pos < end-1 {
+		kind, _ := d.read(1)
+		key, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		val, err := d.decodeElement(kind[0], depth+1)
+		if err != nil {
+			return nil, fmt.Errorf(`at %q: %w`, key, err)
+		}
+		if isArray {
+			arr.ArrayAddElm(val)
+			continue
+		}
+		if obj.ObjectHasElm(key) {
+			return nil, fmt.Errorf(`duplicate key %q in document at offset %d`, key, start)
+		}
+		obj.ObjectSetElm(key, val)
+	}
+	if d.pos != end-1 || d.src[d.pos] != 0 {
+		return nil, fmt.Errorf(`document at offset %d does not end at its size`, start)
+	}
+	d.pos++
+
+	if isArray {
+		return arr, nil
+	}
+
+	return obj, nil
+}
+
+// decodeElement decodes the payload of a BSON element of the type kind into a JSON value.
+func (d *bsonDecoder) decodeElement(kind byte, depth int) (Value, error) {
+	start := d.pos
+	switch kind {
+	case bsonDouble:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return formatExtJSONDouble(math.Float64frombits(u), d.mode), nil
+	case bsonString:
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return String(s), nil
+	case bsonDocument:
+		doc, err := d.decodeDocument(depth, false)
+		if err != nil {
+			return nil, err
+		}
+		if isExtJSONWrapper(doc) {
+			return nil, fmt.Errorf(`document at offset %d has keys conflicting with Extended JSON`, start)
+		}
+		return doc, nil
+	case bsonArray:
+		return d.decodeDocument(depth, true)
+	case bsonBinary:
+		n, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		subType, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.read(int(min(n, math.MaxInt32)))
+		if err != nil {
+			return nil, err
+		}
+		if subType[0] == 2 {
+			if len(data) < 4 || binary.LittleEndian.Uint32(data) != uint32(len(data)-4) {
+				return nil, fmt.Errorf(`invalid binary of the old subtype at offset %d`, start)
+			}
+			data = data[4:]
+		}
+		return Object(Props{"$binary": Object(Props{
+			"base64":  String(base64.StdEncoding.EncodeToString(data)),
+			"subType": String(fmt.Sprintf("%02x", subType[0])),
+		})}), nil
+	case bsonUndefined:
+		return Object(Props{"$undefined": Boolean(true)}), nil
+	case bsonObjectID:
+		oid, err := d.read(12)
+		if err != nil {
+			return nil, err
+		}
+		return Object(Props{"$oid": String(hex.EncodeToString(oid))}), nil
+	case bsonBoolean:
+		b, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] > 1 {
+			return nil, fmt.Errorf(`invalid boolean %d at offset %d`, b[0], start)
+		}
+		return Boolean(b[0] == 1), nil
+	case bsonDateTime:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return formatExtJSONDate(int64(u), d.mode), nil
+	case bsonNull:
+		return Null(), nil
+	case bsonRegex:
+		pattern, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		options, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		return Object(Props{"$regularExpression": Object(Props{"pattern": String(pattern), "options": String(options)})}), nil
+	case bsonDBPointer:
+		ref, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		oid, err := d.read(12)
+		if err != nil {
+			return nil, err
+		}
+		return Object(Props{"$dbPointer": Object(Props{
+			"$ref": String(ref),
+			"$id":  Object(Props{"$oid": String(hex.EncodeToString(oid))}),
+		})}), nil
+	case bsonJavaScript, bsonSymbol:
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		if kind == bsonSymbol {
+			return Object(Props{"$symbol": String(s)}), nil
+		}
+		return Object(Props{"$code": String(s)}), nil
+	case bsonCodeWScope:
+		size, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		code, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		scope, err := d.decodeDocument(depth, false)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(d.pos-start) != uint64(size) {
+			return nil, fmt.Errorf(`invalid code with scope size %d at offset %d`, size, start)
+		}
+		return Object(Props{"$code": String(code), "$scope": scope}), nil
+	case bsonInt32:
+		u, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if d.mode == ExtJSONRelaxed {
+			return Number(int32(u)), nil
+		}
+		return Object(Props{"$numberInt": String(strconv.Itoa(int(int32(u))))}), nil
+	case bsonTimestamp:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return Object(Props{"$timestamp": Object(Props{"t": Number(u >> 32), "i": Number(u & math.MaxUint32)})}), nil
+	case bsonInt64:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		if d.mode == ExtJSONRelaxed {
+			return Number(int64(u)), nil
+		}
+		return Object(Props{"$numberLong": String(strconv.FormatInt(int64(u), 10))}), nil
+	case bsonDecimal128:
+		b, err := d.read(16)
+		if err != nil {
+			return nil, err
+		}
+		return Object(Props{"$numberDecimal": String(formatDecimal128(b))}), nil
+	case bsonMinKey:
+		return Object(Props{"$minKey": Number(1)}), nil
+	case bsonMaxKey:
+		return Object(Props{"$maxKey": Number(1)}), nil
+	default:
+		return nil, fmt.Errorf(`unknown element type 0x%02x at offset %d`, kind, start-1)
+	}
+}
@@ -0,0 +1,156 @@
+package jsonvalue_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestMarshalBSON(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: `{}`, want: "0500000000"},
+		{name: "int32", in: `{"i":{"$numberInt":"-2147483648"}}`, want: "0c0000001069000000008000"},
+		{name: "int32 from number", in: `{"i":1}`, want: "0c0000001069000100000000"},
+		{name: "int64 from number", in: `{"a":3000000000}`, want: "10000000126100005ed0b20000000000"},
+		{name: "decimal128 from number", in: `{"d":18446744073709551615}`, want: "18000000136400" + "ffffffffffffffff0000000000004030" + "00"},
+		{name: "double from number", in: `{"d":1.0}`, want: "10000000016400000000000000f03f00"},
+		{name: "double", in: `{"d":{"$numberDouble":"-Infinity"}}`, want: "10000000016400000000000000f0ff00"},
+		{name: "string", in: `{"a":"b"}`, want: "0e00000002610002000000620000"},
+		{name: "sorted keys", in: `{"b":true,"a":null}`, want: "0c0000000a61000862000100"},
+		{name: "array", in: `{"a":[1,"x"]}`, want: "1d0000000461001500000010300001000000023100020000007800" + "0000"},
+		{name: "object id", in: `{"a":{"$oid":"56e1fc72e0c917e9c4714161"}}`, want: "1400000007610056e1fc72e0c917e9c471416100"},
+		{name: "binary", in: `{"x":{"$binary":{"base64":"//8=","subType":"80"}}}`, want: "0f0000000578000200000080ffff00"},
+		{name: "old binary", in: `{"x":{"$binary":{"base64":"//8=","subType":"02"}}}`, want: "13000000057800060000000202000000ffff00"},
+		{name: "uuid", in: `{"x":{"$uuid":"73ffd264-44b3-4c69-90e8-e7d1dfc035d4"}}`, want: "1d000000057800100000000473ffd26444b34c6990e8e7d1dfc035d400"},
+		{name: "date canonical", in: `{"a":{"$date":{"$numberLong":"1356351330501"}}}`, want: "10000000096100c5d8d6cc3b01000000"},
+		{name: "date relaxed", in: `{"a":{"$date":"2012-12-24T12:15:30.501Z"}}`, want: "10000000096100c5d8d6cc3b01000000"},
+		{name: "regular expression", in: `{"a":{"$regularExpression":{"pattern":"abc","options":"mi"}}}`, want: "0f0000000b610061626300696d0000"},
+		{name: "timestamp", in: `{"a":{"$timestamp":{"t":123456789,"i":42}}}`, want: "100000001161002a00000015cd5b0700"},
+		{name: "int64", in: `{"a":{"$numberLong":"1"}}`, want: "10000000126100010000000000000000"},
+		{name: "decimal128", in: `{"d":{"$numberDecimal":"1"}}`, want: "180000001364000100000000000000000000000000403000"},
+		{name: "decimal128 exponent", in: `{"d":{"$numberDecimal":"-1.0E-3"}}`, want: "18000000136400" + "0a0000000000000000000000000038b0" + "00"},
+		{name: "decimal128 nan", in: `{"d":{"$numberDecimal":"NaN"}}`, want: "180000001364000000000000000000000000000000007c00"},
+		{name: "code", in: `{"a":{"$code":"b"}}`, want: "0e0000000d61000200000062000" + "0"},
+		{name: "code with scope", in: `{"a":{"$code":"b","$scope":{"x":1}}}`, want: "1e0000000f6100160000000200000062000c000000107800010000000000"},
+		{name: "symbol", in: `{"a":{"$symbol":"b"}}`, want: "0e0000000e610002000000620000"},
+		{name: "db pointer", in: `{"a":{"$dbPointer":{"$ref":"b","$id":{"$oid":"56e1fc72e0c917e9c4714161"}}}}`, want: "1a0000000c610002000000620056e1fc72e0c917e9c471416100"},
+		{name: "special keys", in: `{"a":{"$minKey":1},"b":{"$maxKey":1},"c":{"$undefined":true}}`, want: "0e000000ff61007f620006630000"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.MarshalBSON(mustUnmarshal(t, testCase.in))
+			equal(t, err, nil)
+			equal(t, hex.EncodeToString(got), testCase.want)
+		})
+	}
+}
+
+func TestMarshalBSON_Error(t *testing.T) {
+	testCases := map[string]string{
+		"not document":          `[1]`,
+		"wrapper at root":       `{"$oid":"56e1fc72e0c917e9c4714161"}`,
+		"invalid object id":     `{"a":{"$oid":"xyz"}}`,
+		"int32 out of range":    `{"a":{"$numberInt":"2147483648"}}`,
+		"int32 not string":      `{"a":{"$numberInt":1}}`,
+		"extra wrapper key":     `{"a":{"$numberLong":"1","b":2}}`,
+		"invalid double":        `{"a":{"$numberDouble":"inf"}}`,
+		"inexact decimal":       `{"a":{"$numberDecimal":"12345678901234567890123456789012345"}}`,
+		"invalid base64":        `{"a":{"$binary":{"base64":"!","subType":"00"}}}`,
+		"invalid subtype":       `{"a":{"$binary":{"base64":"","subType":"100"}}}`,
+		"invalid uuid":          `{"a":{"$uuid":"73ffd264"}}`,
+		"invalid date":          `{"a":{"$date":"yesterday"}}`,
+		"invalid timestamp":     `{"a":{"$timestamp":{"t":-1,"i":0}}}`,
+		"invalid min key":       `{"a":{"$minKey":0}}`,
+		"invalid undefined":     `{"a":{"$undefined":false}}`,
+		"scope without code":    `{"a":{"$scope":{}}}`,
+		"NUL in key":            "{\"a\\u0000\":1}",
+		"too large number":      `{"a":1e400}`,
+		"too long integer":      `{"a":12345678901234567890123456789012345}`,
+		"invalid db pointer id": `{"a":{"$dbPointer":{"$ref":"b","$id":"x"}}}`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.MarshalBSON(mustUnmarshal(t, in))
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestUnmarshalBSON(t *testing.T) {
+	type testCase struct {
+		name      string
+		in        string
+		canonical string
+		relaxed   string
+	}
+	testCases := []testCase{
+		{name: "int32", in: "0c0000001069000100000000", canonical: `{"i":{"$numberInt":"1"}}`, relaxed: `{"i":1}`},
+		{name: "int64", in: "10000000126100005ed0b20000000000", canonical: `{"a":{"$numberLong":"3000000000"}}`, relaxed: `{"a":3000000000}`},
+		{name: "decimal128", in: "18000000136400" + "ffffffffffffffff0000000000004030" + "00", canonical: `{"d":{"$numberDecimal":"18446744073709551615"}}`, relaxed: `{"d":{"$numberDecimal":"18446744073709551615"}}`},
+		{name: "double", in: "10000000016400000000000000f03f00", canonical: `{"d":{"$numberDouble":"1.0"}}`, relaxed: `{"d":1.0}`},
+		{name: "negative zero", in: "10000000016400000000000000008000", canonical: `{"d":{"$numberDouble":"-0.0"}}`, relaxed: `{"d":-0.0}`},
+		{name: "large double", in: "100000000164001b695743b8179e4700", canonical: `{"d":{"$numberDouble":"1.0E+37"}}`, relaxed: `{"d":1.0E+37}`},
+		{name: "infinity", in: "10000000016400000000000000f07f00", canonical: `{"d":{"$numberDouble":"Infinity"}}`, relaxed: `{"d":{"$numberDouble":"Infinity"}}`},
+		{name: "date", in: "10000000096100c5d8d6cc3b01000000", canonical: `{"a":{"$date":{"$numberLong":"1356351330501"}}}`, relaxed: `{"a":{"$date":"2012-12-24T12:15:30.501Z"}}`},
+		{name: "date before epoch", in: "10000000096100ffffffffffffffff00", canonical: `{"a":{"$date":{"$numberLong":"-1"}}}`, relaxed: `{"a":{"$date":{"$numberLong":"-1"}}}`},
+		{name: "array", in: "1d0000000461001500000010300001000000023100020000007800" + "0000", canonical: `{"a":[{"$numberInt":"1"},"x"]}`, relaxed: `{"a":[1,"x"]}`},
+		{name: "nested", in: "14000000037800" + "0c0000001061000100000000" + "00", canonical: `{"x":{"a":{"$numberInt":"1"}}}`, relaxed: `{"x":{"a":1}}`},
+		{name: "old binary", in: "13000000057800060000000202000000ffff00", canonical: `{"x":{"$binary":{"base64":"//8=","subType":"02"}}}`, relaxed: `{"x":{"$binary":{"base64":"//8=","subType":"02"}}}`},
+		{name: "decimal128", in: "18000000136400" + "0a0000000000000000000000000038b0" + "00", canonical: `{"d":{"$numberDecimal":"-0.0010"}}`, relaxed: `{"d":{"$numberDecimal":"-0.0010"}}`},
+		{name: "decimal128 large", in: "18000000136400" + "01000000000000000000000000005230" + "00", canonical: `{"d":{"$numberDecimal":"1E+9"}}`, relaxed: `{"d":{"$numberDecimal":"1E+9"}}`},
+		{name: "timestamp", in: "100000001161002a00000015cd5b0700", canonical: `{"a":{"$timestamp":{"t":123456789,"i":42}}}`, relaxed: `{"a":{"$timestamp":{"t":123456789,"i":42}}}`},
+		{name: "code with scope", in: "1e0000000f6100160000000200000062000c000000107800010000000000", canonical: `{"a":{"$code":"b","$scope":{"x":{"$numberInt":"1"}}}}`, relaxed: `{"a":{"$code":"b","$scope":{"x":1}}}`},
+		{name: "special keys", in: "0e000000ff61007f620006630000", canonical: `{"a":{"$minKey":1},"b":{"$maxKey":1},"c":{"$undefined":true}}`, relaxed: `{"a":{"$minKey":1},"b":{"$maxKey":1},"c":{"$undefined":true}}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(testCase.in)
+			canonical, err := jsonvalue.UnmarshalBSON(b, jsonvalue.ExtJSONCanonical)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, canonical)), string(mustMarshal(t, mustUnmarshal(t, testCase.canonical))))
+
+			relaxed, err := jsonvalue.UnmarshalBSON(b, jsonvalue.ExtJSONRelaxed)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, relaxed)), string(mustMarshal(t, mustUnmarshal(t, testCase.relaxed))))
+
+			for _, v := range []jsonvalue.Value{canonical, relaxed} {
+				got, err := jsonvalue.MarshalBSON(v)
+				equal(t, err, nil)
+				equal(t, hex.EncodeToString(got), testCase.in)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBSON_Error(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                 "",
+		"too short":             "0400000000",
+		"size too large":        "0600000000",
+		"missing terminator":    "0500000001",
+		"trailing data":         "050000000000",
+		"unknown type":          "080000002061000000",
+		"truncated int32":       "0a000000106100010000",
+		"unterminated key":      "060000001061",
+		"invalid string length": "0e00000002610000000000620000",
+		"string without NUL":    "0e00000002610002000000626100",
+		"invalid utf8 string":   "0e00000002610002000000ff0000",
+		"invalid boolean":       "09000000086100020" + "0",
+		"duplicate key":         "0b0000000a61000a610000",
+		"wrapper keys":          "1a000000036100120000000224636f646500020000006200" + "0000",
+		"element beyond size":   "0b0000001069000100000000",
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, _ := hex.DecodeString(in)
+			_, err := jsonvalue.UnmarshalBSON(b, jsonvalue.ExtJSONCanonical)
+			IsNotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/exp/slices"
+)
+
+// MarshalCanonical encodes a JSON value v into the canonical form defined by JSON Canonicalization Scheme (RFC 8785).
+// Object members are sorted by their keys in UTF-16 code units, numbers are formatted as IEEE 754 double precision numbers in the shortest form, and no whitespace is inserted.
+func MarshalCanonical(v Value) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalCanonical(&b, v); err != nil {
+		return nil, fmt.Errorf(`fail to marshal Value canonically: %w`, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func marshalCanonical(b *bytes.Buffer, v Value) error {
+	switch v.Type() {
+	case TypeNull:
+		b.WriteString("null")
+	case TypeBoolean:
+		b.WriteString(strconv.FormatBool(v.BooleanGet()))
+	case TypeNumber:
+		f, err := strconv.ParseFloat(v.NumberGet().String(), 64)
+		if err != nil {
+			return fmt.Errorf(`number %s is not representable in IEEE 754 double precision: %w`, v.NumberGet(), err)
+		}
+		b.WriteString(formatCanonicalNumber(f))
+	case TypeString:
+		writeCanonicalString(b, v.StringGet())
+	case TypeArray:
+		b.WriteByte('[')
+		for i, elm := range v.ArrayAll() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := marshalCanonical(b, elm); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.SortFunc(keys, func(a, b string) bool {
+			return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b))) < 0
+		})
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, key)
+			b.WriteByte(':')
+			if err := marshalCanonical(b, v.ObjectGetElm(key)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	}
+
+	return nil
+}
+
+// formatCanonicalNumber formats f in the same way as Number.prototype.toString of ECMAScript.
+func formatCanonicalNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if abs := math.Abs(f); 1e-6 <= abs && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+
+	return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
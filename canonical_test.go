@@ -0,0 +1,36 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "literals", in: ` [ null , true , false ] `, want: `[null,true,false]`},
+		{name: "sorted keys", in: `{"b":1,"a":{"d":2,"c":3}}`, want: `{"a":{"c":3,"d":2},"b":1}`},
+		{name: "utf16 order", in: `{"😀":1,"דּ":2}`, want: "{\"\U0001f600\":1,\"דּ\":2}"},
+		{name: "integers", in: `[0,-0,1.0,100,-5e2]`, want: `[0,0,1,100,-500]`},
+		{name: "fractions", in: `[0.5,1e-6,1.5e-7,333333333.33333329]`, want: `[0.5,0.000001,1.5e-7,333333333.3333333]`},
+		{name: "large", in: `[1e21,1e20,4.5e300]`, want: `[1e+21,100000000000000000000,4.5e+300]`},
+		{name: "strings", in: `"\u0001\n\"\\/<é"`, want: `"\u0001\n\"\\/<é"`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.MarshalCanonical(mustUnmarshal(t, testCase.in))
+			equal(t, err, nil)
+			equal(t, string(got), testCase.want)
+		})
+	}
+}
+
+func TestMarshalCanonical_Error(t *testing.T) {
+	_, err := jsonvalue.MarshalCanonical(mustUnmarshal(t, `[1e400]`))
+	IsNotNil(t, err)
+}
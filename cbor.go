@@ -0,0 +1,417 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	cborMajorUint       = 0
+	cborMajorNegative   = 1
+	cborMajorBytes      = 2
+	cborMajorText       = 3
+	cborMajorArray      = 4
+	cborMajorMap        = 5
+	cborMajorTag        = 6
+	cborMajorSimple     = 7
+	cborTagPositiveBig  = 2
+	cborTagNegativeBig  = 3
+	cborIndefinite      = 31
+	cborBreak           = 0xff
+	cborMaxNestingDepth = 1000
+)
+
+// MarshalCBOR encodes a JSON value v into CBOR (RFC 8949) following the core deterministic encoding requirements.
+// Integers are encoded with the shortest arguments, map keys are sorted by the bytewise lexicographic order of their encodings,
+// and the other numbers are encoded as the shortest floating-point numbers preserving their values.
+// Numbers written as integers beyond the range of the major types 0 and 1 are encoded as bignums with the tags 2 and 3.
+func MarshalCBOR(v Value) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalCBOR(&b, v); err != nil {
+		return nil, fmt.Errorf(`fail to marshal Value into CBOR: %w`, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeCBORHead(b *bytes.Buffer, major byte, arg uint64) {
+	switch {
+	case arg < 24:
+		b.WriteByte(major<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		b.WriteByte(major<<5 | 24)
+		b.WriteByte(byte(arg))
+	case arg <= math.MaxUint16:
+		b.WriteByte(major<<5 | 25)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(arg)))
+	case arg <= math.MaxUint32:
+		b.WriteByte(major<<5 | 26)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(arg)))
+	default:
+		b.WriteByte(major<<5 | 27)
+		b.Write(binary.BigEndian.AppendUint64(nil, arg))
+	}
+}
+
+func marshalCBOR(b *bytes.Buffer, v Value) error {
+	switch v.Type() {
+	case TypeNull:
+		b.WriteByte(cborMajorSimple<<5 | 22)
+	case TypeBoolean:
+		if v.BooleanGet() {
+			b.WriteByte(cborMajorSimple<<5 | 21)
+		} else {
+			b.WriteByte(cborMajorSimple<<5 | 20)
+		}
+	case TypeNumber:
+		return marshalCBORNumber(b, v.NumberGet())
+	case TypeString:
+		writeCBORHead(b, cborMajorText, uint64(len(v.StringGet())))
+		b.WriteString(v.StringGet())
+	case TypeArray:
+		writeCBORHead(b, cborMajorArray, uint64(v.ArrayLen()))
+		for _, elm := range v.ArrayAll() {
+			if err := marshalCBOR(b, elm); err != nil {
+				return err
+			}
+		}
+	case TypeObject:
+		type member struct {
+			key []byte
+			val Value
+		}
+		members := []member{}
+		for key, val := range v.ObjectAll() {
+			var k bytes.Buffer
+			writeCBORHead(&k, cborMajorText, uint64(len(key)))
+			k.WriteString(key)
+			members = append(members, member{key: k.Bytes(), val: val})
+		}
+		slices.SortFunc(members, func(a, b member) bool { return bytes.Compare(a.key, b.key) < 0 })
+		writeCBORHead(b, cborMajorMap, uint64(len(members)))
+		for _, m := range members {
+			b.Write(m.key)
+			if err := marshalCBOR(b, m.val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func marshalCBORNumber(b *bytes.Buffer, n json.Number) error {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		i, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return fmt.Errorf(`invalid number %q`, s)
+		}
+		major, tag := byte(cborMajorUint), uint64(cborTagPositiveBig)
+		if i.Sign() < 0 {
+			// The negative integer -1-n is encoded with the argument n.
+			i.Not(i)
+			major, tag = cborMajorNegative, cborTagNegativeBig
+		}
+		if i.IsUint64() {
+			writeCBORHead(b, major, i.Uint64())
+		} else {
+			writeCBORHead(b, cborMajorTag, tag)
+			writeCBORHead(b, cborMajorBytes, uint64(len(i.Bytes())))
+			b.Write(i.Bytes())
+		}
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf(`number %s is not representable in IEEE 754 double precision: %w`, s, err)
+	}
+	if h, ok := float16Bits(f); ok {
+		b.WriteByte(cborMajorSimple<<5 | 25)
+		b.Write(binary.BigEndian.AppendUint16(nil, h))
+	} else if float64(float32(f)) == f {
+		b.WriteByte(cborMajorSimple<<5 | 26)
+		b.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(f))))
+	} else {
+		b.WriteByte(cborMajorSimple<<5 | 27)
+		b.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	}
+
+	return nil
+}
+
+// float16Bits returns the IEEE 754 half precision representation of a finite number f if f is exactly representable.
+func float16Bits(f float64) (uint16, bool) {
+	var sign uint16
+	if math.Signbit(f) {
+		sign = 0x8000
+	}
+	a := math.Abs(f)
+	if a == 0 {
+		return sign, true
+	}
+
+	exp := math.Ilogb(a)
+	switch {
+	case -14 <= exp && exp <= 15:
+		m := (math.Ldexp(a, -exp) - 1) * 1024
+		if m != math.Trunc(m) {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(m), true
+	case -24 <= exp && exp < -14:
+		m := math.Ldexp(a, 24)
+		if m != math.Trunc(m) {
+			return 0, false
+		}
+		return sign | uint16(m), true
+	default:
+		return 0, false
+	}
+}
+
+func float16Value(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+
+	return f
+}
+
+// UnmarshalCBOR decodes a CBOR data item (RFC 8949) into a JSON value.
+// Both definite-length and indefinite-length items are accepted.
+// Bignums with the tags 2 and 3 are decoded into numbers, and the other tags are ignored.
+// Byte strings are decoded into strings in base64url encoding without padding, and undefined is decoded into null.
+// Map keys must be text strings, and duplicate keys, NaN, infinities, unknown simple values and trailing data are rejected with errors.
+func UnmarshalCBOR(b []byte) (Value, error) {
+	d := &cborDecoder{src: b}
+	v, err := d.decode(0)
+	if err == nil && d.pos < len(d.src) {
+		err = fmt.Errorf(`unexpected data after CBOR data item at offset %d`, d.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`fail to unmarshal CBOR into Value: %w`, err)
+	}
+
+	return v, nil
+}
+
+type cborDecoder struct {
+	src []byte
+	pos int
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if uint64(len(d.src)-d.pos) < n {
+		return nil, fmt.Errorf(`unexpected end of CBOR data`)
+	}
+	b := d.src[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return b, nil
+}
+
+// readHead reads the initial byte and the argument of a data item.
+// The additional information is cborIndefinite for indefinite-length items.
+func (d *cborDecoder) readHead() (major byte, info byte, arg uint64, err error) {
+	head, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = head[0]>>5, head[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		b, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, c := range b {
+			arg = arg<<8 | uint64(c)
+		}
+		return major, info, arg, nil
+	case info == cborIndefinite && (major == cborMajorBytes || major == cborMajorText || major == cborMajorArray || major == cborMajorMap):
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf(`invalid additional information %d for major type %d at offset %d`, info, major, d.pos-1)
+	}
+}
+
+func (d *cborDecoder) atBreak() bool {
+	return d.pos < len(d.src) && d.src[d.pos] == cborBreak
+}
+
+func (d *cborDecoder) decode(depth int) (Value, error) {
+	if depth > cborMaxNestingDepth {
+		return nil, fmt.Errorf(`CBOR data items are nested too deeply`)
+	}
+	start := d.pos
+	major, info, arg, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborMajorUint:
+		return Number(json.Number(strconv.FormatUint(arg, 10))), nil
+	case cborMajorNegative:
+		n := new(big.Int).SetUint64(arg)
+		return Number(json.Number(n.Not(n).String())), nil
+	case cborMajorBytes:
+		b, err := d.decodeString(major, info, arg)
+		if err != nil {
+			return nil, err
+		}
+		return String(base64.RawURLEncoding.EncodeToString(b)), nil
+	case cborMajorText:
+		b, err := d.decodeString(major, info, arg)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf(`text string at offset %d is not valid UTF-8`, start)
+		}
+		return String(string(b)), nil
+	case cborMajorArray:
+		arr := Array()
+		for i := uint64(0); info == cborIndefinite || i < arg; i++ {
+			if info == cborIndefinite && d.atBreak() {
+				d.pos++
+				break
+			}
+			elm, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr.ArrayAddElm(elm)
+		}
+		return arr, nil
+	case cborMajorMap:
+		obj := Object()
+		for i := uint64(0); info == cborIndefinite || i < arg; i++ {
+			if info == cborIndefinite && d.atBreak() {
+				d.pos++
+				break
+			}
+			keyPos := d.pos
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if key.Type() != TypeString || d.src[keyPos]>>5 != cborMajorText {
+				return nil, fmt.Errorf(`map key at offset %d must be a text string`, keyPos)
+			}
+			if obj.ObjectHasElm(key.StringGet()) {
+				return nil, fmt.Errorf(`duplicate map key %q at offset %d`, key.StringGet(), keyPos)
+			}
+			val, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			obj.ObjectSetElm(key.StringGet(), val)
+		}
+		return obj, nil
+	case cborMajorTag:
+		if arg != cborTagPositiveBig && arg != cborTagNegativeBig {
+			return d.decode(depth + 1)
+		}
+		contentPos := d.pos
+		m, contentInfo, contentArg, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if m != cborMajorBytes {
+			return nil, fmt.Errorf(`bignum at offset %d must contain a byte string`, contentPos)
+		}
+		b, err := d.decodeString(m, contentInfo, contentArg)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).SetBytes(b)
+		if arg == cborTagNegativeBig {
+			// The bignum with the tag 3 represents -1-n.
+			n.Not(n)
+		}
+		return Number(json.Number(n.String())), nil
+	default:
+		return d.decodeSimple(start, info, arg)
+	}
+}
+
+// decodeString reads the content of a byte string or a text string, concatenating the chunks of an indefinite-length string.
+func (d *cborDecoder) decodeString(major byte, info byte, arg uint64) ([]byte, error) {
+	if info != cborIndefinite {
+		return d.read(arg)
+	}
+
+	var b []byte
+	for !d.atBreak() {
+		chunkPos := d.pos
+		m, info, arg, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || info == cborIndefinite {
+			return nil, fmt.Errorf(`invalid chunk of indefinite-length string at offset %d`, chunkPos)
+		}
+		chunk, err := d.read(arg)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+	d.pos++
+
+	return b, nil
+}
+
+func (d *cborDecoder) decodeSimple(start int, info byte, arg uint64) (Value, error) {
+	var f float64
+	switch info {
+	case 20:
+		return Boolean(false), nil
+	case 21:
+		return Boolean(true), nil
+	case 22, 23:
+		return Null(), nil
+	case 25:
+		f = float16Value(uint16(arg))
+	case 26:
+		f = float64(math.Float32frombits(uint32(arg)))
+	case 27:
+		f = math.Float64frombits(arg)
+	default:
+		return nil, fmt.Errorf(`unsupported simple value %d at offset %d`, arg, start)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf(`%v at offset %d cannot be represented in JSON`, f, start)
+	}
+
+	return Number(json.Number(formatCanonicalNumber(f))), nil
+}
@@ -0,0 +1,137 @@
+package jsonvalue_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestMarshalCBOR(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "zero", in: `0`, want: "00"},
+		{name: "small integer", in: `23`, want: "17"},
+		{name: "uint8", in: `24`, want: "1818"},
+		{name: "uint16", in: `1000`, want: "1903e8"},
+		{name: "uint32", in: `1000000`, want: "1a000f4240"},
+		{name: "uint64", in: `1000000000000`, want: "1b000000e8d4a51000"},
+		{name: "max uint64", in: `18446744073709551615`, want: "1bffffffffffffffff"},
+		{name: "positive bignum", in: `18446744073709551616`, want: "c249010000000000000000"},
+		{name: "negative integer", in: `-1000`, want: "3903e7"},
+		{name: "min negative", in: `-18446744073709551616`, want: "3bffffffffffffffff"},
+		{name: "negative bignum", in: `-18446744073709551617`, want: "c349010000000000000000"},
+		{name: "half float", in: `1.5`, want: "f93e00"},
+		{name: "half float integer", in: `1.0`, want: "f93c00"},
+		{name: "negative zero", in: `-0.0`, want: "f98000"},
+		{name: "half float max", in: `65504.0`, want: "f97bff"},
+		{name: "half float subnormal", in: `5.960464477539063e-8`, want: "f90001"},
+		{name: "single float", in: `100000.0`, want: "fa47c35000"},
+		{name: "double float", in: `1.1`, want: "fb3ff199999999999a"},
+		{name: "literals", in: `[false,true,null]`, want: "83f4f5f6"},
+		{name: "string", in: `"ü"`, want: "62c3bc"},
+		{name: "nested array", in: `[1,[2,3],[4,5]]`, want: "8301820203820405"},
+		{name: "sorted map", in: `{"aa":1,"b":2,"a":{"c":[]}}`, want: "a36161a1616380616202626161" + "01"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.MarshalCBOR(mustUnmarshal(t, testCase.in))
+			equal(t, err, nil)
+			equal(t, hex.EncodeToString(got), testCase.want)
+		})
+	}
+}
+
+func TestMarshalCBOR_Error(t *testing.T) {
+	_, err := jsonvalue.MarshalCBOR(mustUnmarshal(t, `[1e400]`))
+	IsNotNil(t, err)
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "integers", in: "86001718183903e71bffffffffffffffff3bffffffffffffffff", want: `[0,23,24,-1000,18446744073709551615,-18446744073709551616]`},
+		{name: "bignums", in: "82c249010000000000000000c349010000000000000000", want: `[18446744073709551616,-18446744073709551617]`},
+		{name: "floats", in: "85f93e00f97bfffa47c35000fb3ff199999999999af98000", want: `[1.5,65504,100000,1.1,0]`},
+		{name: "single precision", in: "fa3dcccccd", want: `0.10000000149011612`},
+		{name: "half precision", in: "f92e66", want: `0.0999755859375`},
+		{name: "simple values", in: "84f4f5f6f7", want: `[false,true,null,null]`},
+		{name: "strings", in: "8262c3bc4401020304", want: `["ü","AQIDBA"]`},
+		{name: "map", in: "a26161016162820203", want: `{"a":1,"b":[2,3]}`},
+		{name: "other tags", in: "c074323031332d30332d32315432303a30343a30305a", want: `"2013-03-21T20:04:00Z"`},
+		{name: "indefinite byte string", in: "5f42010243030405ff", want: `"AQIDBAU"`},
+		{name: "indefinite text string", in: "7f657374726561646d696e67ff", want: `"streaming"`},
+		{name: "indefinite arrays", in: "9f018202039f0405ffff", want: `[1,[2,3],[4,5]]`},
+		{name: "indefinite map", in: "bf61610161629f0203ffff", want: `{"a":1,"b":[2,3]}`},
+		{name: "empty indefinite", in: "829fffbfff", want: `[[],{}]`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(testCase.in)
+			got, err := jsonvalue.UnmarshalCBOR(b)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestUnmarshalCBOR_Error(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                   "",
+		"truncated argument":      "19 03",
+		"truncated string":        "63 6161",
+		"trailing data":           "01 02",
+		"invalid utf8":            "61 ff",
+		"non-string key":          "a1 01 02",
+		"byte string key":         "a1 4161 01",
+		"duplicate key":           "a2 6161 01 6161 02",
+		"nan":                     "f9 7e00",
+		"infinity":                "fa 7f800000",
+		"unknown simple":          "f8 20",
+		"unexpected break":        "ff",
+		"missing break":           "9f 01",
+		"invalid chunk":           "5f 6161 ff",
+		"nested indefinite chunk": "7f 7f ff ff",
+		"indefinite integer":      "1f",
+		"reserved info":           "1c",
+		"bignum without bytes":    "c2 01",
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, _ := hex.DecodeString(strings.ReplaceAll(in, " ", ""))
+			_, err := jsonvalue.UnmarshalCBOR(b)
+			IsNotNil(t, err)
+		})
+	}
+	t.Run("deeply nested", func(t *testing.T) {
+		_, err := jsonvalue.UnmarshalCBOR(bytes.Repeat([]byte{0x81}, 2000))
+		IsNotNil(t, err)
+	})
+}
+
+func TestCBOR_RoundTrip(t *testing.T) {
+	in := mustUnmarshal(t, `{"name":"x","values":[0,-1,1.5,0.1,1e300,123456789012345678901234567890],"nested":{"ok":true,"none":null}}`)
+	b, err := jsonvalue.MarshalCBOR(in)
+	equal(t, err, nil)
+	got, err := jsonvalue.UnmarshalCBOR(b)
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+
+	// The numbers encoded in half and single precision without their shortest forms.
+	in = mustUnmarshal(t, `[1.100000023841858,3.4028234663852886e38,0.0999755859375,-1.401298464324817e-45]`)
+	b, err = jsonvalue.MarshalCBOR(in)
+	equal(t, err, nil)
+	got, err = jsonvalue.UnmarshalCBOR(b)
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+}
@@ -0,0 +1,410 @@
+// Command jsonvalue manipulates JSON documents from the shell with the same semantics as the jsonvalue package.
+//
+// Usage:
+//
+//	jsonvalue get <pointer> [file]
+//	jsonvalue set <pointer> <json> [file]
+//	jsonvalue del <pointer> [file]
+//	jsonvalue walk [file]
+//	jsonvalue diff <from-file> [to-file]
+//	jsonvalue patch <patch-file> [file]
+//	jsonvalue merge <merge-patch-file> [file]
+//	jsonvalue fmt [--canonical] [file]
+//	jsonvalue validate --schema <schema-file> [file]
+//
+// If file is omitted, the JSON document is read from the standard input.
+// The exit status is 0 on success, 1 on failure, including schema violations, and 2 on invalid usage.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+	"golang.org/x/exp/slices"
+)
+
+const usage = `Usage:
+  jsonvalue get <pointer> [file]
+  jsonvalue set <pointer> <json> [file]
+  jsonvalue del <pointer> [file]
+  jsonvalue walk [file]
+  jsonvalue diff <from-file> [to-file]
+  jsonvalue patch <patch-file> [file]
+  jsonvalue merge <merge-patch-file> [file]
+  jsonvalue fmt [--canonical] [file]
+  jsonvalue validate --schema <schema-file> [file]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// usageError represents an invalid command line.
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// violationError represents that a JSON document violates a JSON Schema.
+type violationError struct {
+	count int
+}
+
+func (e violationError) Error() string {
+	return fmt.Sprintf(`%d schema violation(s) found`, e.count)
+}
+
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// run runs the command with the arguments and returns the exit status.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c := command{stdin: stdin, stdout: stdout}
+	err := c.run(args)
+	var ue usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &ue):
+		fmt.Fprintf(stderr, "jsonvalue: %v\n%s", err, usage)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "jsonvalue: %v\n", err)
+		return 1
+	}
+}
+
+func (c command) run(args []string) error {
+	if len(args) == 0 {
+		return usageError{message: "command is required"}
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "get":
+		return c.get(args)
+	case "set":
+		return c.set(args)
+	case "del":
+		return c.del(args)
+	case "walk":
+		return c.walk(args)
+	case "diff":
+		return c.diff(args)
+	case "patch":
+		return c.patch(args)
+	case "merge":
+		return c.merge(args)
+	case "fmt":
+		return c.format(args)
+	case "validate":
+		return c.validate(args)
+	default:
+		return usageError{message: fmt.Sprintf("unknown command %q", name)}
+	}
+}
+
+// positional checks that the number of the positional arguments is between min and max.
+func positional(name string, args []string, min int, max int) error {
+	if len(args) < min || len(args) > max {
+		return usageError{message: fmt.Sprintf("%s: wrong number of arguments", name)}
+	}
+
+	return nil
+}
+
+// parseFlags parses the flags of a subcommand and returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, usageError{message: fmt.Sprintf("%s: %v", fs.Name(), err)}
+	}
+
+	return fs.Args(), nil
+}
+
+// read reads a JSON document from the file at args[i] or from the standard input if args has no such element.
+func (c command) read(args []string, i int) (jsonvalue.Value, error) {
+	if i < len(args) {
+		return readFile(args[i])
+	}
+
+	b, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to read standard input: %w`, err)
+	}
+
+	return decode("standard input", b)
+}
+
+func readFile(name string) (jsonvalue.Value, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to read file: %w`, err)
+	}
+
+	return decode(name, b)
+}
+
+func decode(name string, b []byte) (jsonvalue.Value, error) {
+	v := jsonvalue.Null()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, fmt.Errorf(`fail to parse JSON in %s: %w`, name, err)
+	}
+
+	return v, nil
+}
+
+// write writes a JSON value in the indented form followed by a newline.
+func (c command) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf(`fail to marshal JSON: %w`, err)
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		return fmt.Errorf(`fail to indent JSON: %w`, err)
+	}
+	out.WriteByte('\n')
+	if _, err := c.stdout.Write(out.Bytes()); err != nil {
+		return fmt.Errorf(`fail to write output: %w`, err)
+	}
+
+	return nil
+}
+
+func (c command) get(args []string) error {
+	if err := positional("get", args, 1, 2); err != nil {
+		return err
+	}
+	v, err := c.read(args, 1)
+	if err != nil {
+		return err
+	}
+
+	path, err := jsonvalue.ParsePointer(v, args[0])
+	if err != nil {
+		return err
+	}
+	found, ok := jsonvalue.Find(v, path)
+	if !ok {
+		return fmt.Errorf(`JSON value at %q is not found`, args[0])
+	}
+
+	return c.write(found)
+}
+
+func (c command) set(args []string) error {
+	if err := positional("set", args, 2, 3); err != nil {
+		return err
+	}
+	val, err := decode("argument", []byte(args[1]))
+	if err != nil {
+		return err
+	}
+	v, err := c.read(args, 2)
+	if err != nil {
+		return err
+	}
+
+	op := jsonvalue.PatchOpAdd
+	if path, err := jsonvalue.ParsePointer(v, args[0]); err == nil {
+		if _, ok := jsonvalue.Find(v, path); ok {
+			op = jsonvalue.PatchOpReplace
+		}
+	}
+	patch := jsonvalue.Patch{{Op: op, Path: args[0], Value: val}}
+	if err := patch.Apply(v); err != nil {
+		return err
+	}
+
+	return c.write(v)
+}
+
+func (c command) del(args []string) error {
+	if err := positional("del", args, 1, 2); err != nil {
+		return err
+	}
+	v, err := c.read(args, 1)
+	if err != nil {
+		return err
+	}
+
+	patch := jsonvalue.Patch{{Op: jsonvalue.PatchOpRemove, Path: args[0]}}
+	if err := patch.Apply(v); err != nil {
+		return err
+	}
+
+	return c.write(v)
+}
+
+func (c command) walk(args []string) error {
+	if err := positional("walk", args, 0, 1); err != nil {
+		return err
+	}
+	v, err := c.read(args, 0)
+	if err != nil {
+		return err
+	}
+
+	paths := []jsonvalue.Path{}
+	for path, val := range jsonvalue.All(v) {
+		if val.Type() != jsonvalue.TypeArray && val.Type() != jsonvalue.TypeObject {
+			paths = append(paths, path)
+		}
+	}
+	slices.SortFunc(paths, func(a, b jsonvalue.Path) bool { return a.Compare(b) < 0 })
+
+	var out strings.Builder
+	for _, path := range paths {
+		val, _ := jsonvalue.Find(v, path)
+		b, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf(`fail to marshal JSON: %w`, err)
+		}
+		fmt.Fprintf(&out, "%s\t%s\n", path.Pointer(), b)
+	}
+	if _, err := io.WriteString(c.stdout, out.String()); err != nil {
+		return fmt.Errorf(`fail to write output: %w`, err)
+	}
+
+	return nil
+}
+
+func (c command) diff(args []string) error {
+	if err := positional("diff", args, 1, 2); err != nil {
+		return err
+	}
+	from, err := readFile(args[0])
+	if err != nil {
+		return err
+	}
+	to, err := c.read(args, 1)
+	if err != nil {
+		return err
+	}
+
+	return c.write(jsonvalue.Diff(from, to))
+}
+
+func (c command) patch(args []string) error {
+	if err := positional("patch", args, 1, 2); err != nil {
+		return err
+	}
+	b, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf(`fail to read file: %w`, err)
+	}
+	var patch jsonvalue.Patch
+	if err := json.Unmarshal(b, &patch); err != nil {
+		return fmt.Errorf(`fail to parse JSON Patch in %s: %w`, args[0], err)
+	}
+	v, err := c.read(args, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := patch.Apply(v); err != nil {
+		return err
+	}
+
+	return c.write(v)
+}
+
+func (c command) merge(args []string) error {
+	if err := positional("merge", args, 1, 2); err != nil {
+		return err
+	}
+	patch, err := readFile(args[0])
+	if err != nil {
+		return err
+	}
+	v, err := c.read(args, 1)
+	if err != nil {
+		return err
+	}
+
+	return c.write(jsonvalue.MergePatch(v, patch))
+}
+
+func (c command) format(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	canonical := fs.Bool("canonical", false, "output in the JSON Canonicalization Scheme (RFC 8785)")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional("fmt", args, 0, 1); err != nil {
+		return err
+	}
+	v, err := c.read(args, 0)
+	if err != nil {
+		return err
+	}
+
+	if !*canonical {
+		return c.write(v)
+	}
+	b, err := jsonvalue.MarshalCanonical(v)
+	if err != nil {
+		return err
+	}
+	if _, err := c.stdout.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf(`fail to write output: %w`, err)
+	}
+
+	return nil
+}
+
+func (c command) validate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schemaFile := fs.String("schema", "", "file of the JSON Schema")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *schemaFile == "" {
+		return usageError{message: "validate: --schema is required"}
+	}
+	if err := positional("validate", args, 0, 1); err != nil {
+		return err
+	}
+	schema, err := readFile(*schemaFile)
+	if err != nil {
+		return err
+	}
+	v, err := c.read(args, 0)
+	if err != nil {
+		return err
+	}
+
+	violations, err := jsonvalue.ValidateSchema(v, schema)
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	for _, violation := range violations {
+		fmt.Fprintln(&out, violation.Error())
+	}
+	if _, err := io.WriteString(c.stdout, out.String()); err != nil {
+		return fmt.Errorf(`fail to write output: %w`, err)
+	}
+	if len(violations) > 0 {
+		return violationError{count: len(violations)}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestRun(t *testing.T) {
+	type testCase struct {
+		name  string
+		args  []string
+		stdin string
+	}
+	testCases := []testCase{
+		{name: "get", args: []string{"get", "/size", "testdata/doc.json"}},
+		{name: "get_element", args: []string{"get", "/tags/1", "testdata/doc.json"}},
+		{name: "get_root_stdin", args: []string{"get", ""}, stdin: "testdata/doc2.json"},
+		{name: "get_missing", args: []string{"get", "/tags/2", "testdata/doc.json"}},
+		{name: "get_invalid_pointer", args: []string{"get", "size", "testdata/doc.json"}},
+		{name: "set_replace", args: []string{"set", "/size/w", `{"cm":3}`, "testdata/doc.json"}},
+		{name: "set_add", args: []string{"set", "/tags/-", `"z"`, "testdata/doc.json"}},
+		{name: "set_invalid_value", args: []string{"set", "/a", `{`, "testdata/doc.json"}},
+		{name: "del", args: []string{"del", "/tags/0"}, stdin: "testdata/doc.json"},
+		{name: "del_missing", args: []string{"del", "/missing", "testdata/doc.json"}},
+		{name: "walk", args: []string{"walk", "testdata/doc.json"}},
+		{name: "diff", args: []string{"diff", "testdata/doc.json", "testdata/doc2.json"}},
+		{name: "patch", args: []string{"patch", "testdata/patch.json", "testdata/doc.json"}},
+		{name: "patch_failed", args: []string{"patch", "testdata/patch.json", "testdata/doc2.json"}},
+		{name: "merge", args: []string{"merge", "testdata/merge.json"}, stdin: "testdata/doc.json"},
+		{name: "fmt", args: []string{"fmt", "testdata/canonical.json"}},
+		{name: "fmt_canonical", args: []string{"fmt", "--canonical", "testdata/canonical.json"}},
+		{name: "fmt_broken", args: []string{"fmt", "testdata/broken.json"}},
+		{name: "validate_valid", args: []string{"validate", "--schema", "testdata/schema.json", "testdata/doc2.json"}},
+		{name: "validate_invalid", args: []string{"validate", "--schema", "testdata/schema.json", "testdata/doc.json"}},
+		{name: "validate_no_schema", args: []string{"validate", "testdata/doc.json"}},
+		{name: "no_command", args: []string{}},
+		{name: "unknown_command", args: []string{"unknown"}},
+		{name: "wrong_arguments", args: []string{"walk", "testdata/doc.json", "testdata/doc2.json"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var stdin []byte
+			if testCase.stdin != "" {
+				b, err := os.ReadFile(testCase.stdin)
+				if err != nil {
+					t.Fatalf("fail to read %s: %v", testCase.stdin, err)
+				}
+				stdin = b
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := run(testCase.args, bytes.NewReader(stdin), &stdout, &stderr)
+			got := fmt.Sprintf("exit: %d\n--- stdout\n%s--- stderr\n%s", code, stdout.String(), stderr.String())
+
+			golden := filepath.Join("testdata", "golden", testCase.name+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatalf("fail to create directory: %v", err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatalf("fail to write %s: %v", golden, err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("fail to read %s: %v", golden, err)
+			}
+			if got != string(want) {
+				t.Errorf("output mismatch with %s\n got:\n%s\nwant:\n%s", golden, got, strings.TrimSuffix(string(want), "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// Filter returns a new JSON array containing the deep copies of the elements of a JSON array v for which pred returns true.
+func Filter(v Value, pred func(index int, elm Value) bool) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	filtered := Array()
+	for i, elm := range v.ArrayAll() {
+		if pred(i, elm) {
+			filtered.ArrayAddElm(elm.Clone())
+		}
+	}
+
+	return filtered
+}
+
+// MapArray returns a new JSON array containing the results of fn called for each element of a JSON array v.
+func MapArray(v Value, fn func(index int, elm Value) Value) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	mapped := Array()
+	for i, elm := range v.ArrayAll() {
+		m := fn(i, elm)
+		assert.State(m != nil, "map function must not return nil Value")
+		mapped.ArrayAddElm(m)
+	}
+
+	return mapped
+}
+
+// Reduce calls fn for each element of a JSON array v in order, passing the result of the previous call as acc, and returns the result of the last call.
+// init is passed as acc to the first call and returned if v is empty.
+func Reduce(v Value, init Value, fn func(acc Value, index int, elm Value) Value) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	acc := init
+	for i, elm := range v.ArrayAll() {
+		acc = fn(acc, i, elm)
+	}
+
+	return acc
+}
+
+// GroupBy returns a new JSON object whose members are JSON arrays containing the deep copies of the elements of a JSON array v grouped by the JSON values at keyPath.
+// The key of a group is the JSON text of the JSON value at keyPath, e.g. "a" with the quotes for a JSON string,
+// where object members are sorted and numbers are written exactly without exponents so that equal JSON values by Equal share the key.
+// JSON values of different types are grouped separately, and the elements without keyPath are grouped under the empty key.
+// The elements in each group keep their order in v.
+func GroupBy(v Value, keyPath Path) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	grouped := Object()
+	for _, elm := range v.ArrayAll() {
+		key := groupKey(elm, keyPath)
+		if !grouped.ObjectHasElm(key) {
+			grouped.ObjectSetElm(key, Array())
+		}
+		grouped.ObjectGetElm(key).ArrayAddElm(elm.Clone())
+	}
+
+	return grouped
+}
+
+// IndexBy returns a new JSON object whose members are the deep copies of the elements of a JSON array v keyed by the JSON values at keyPath in the same way as GroupBy.
+// If multiple elements have the same key, the last one is used.
+func IndexBy(v Value, keyPath Path) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	indexed := Object()
+	for _, elm := range v.ArrayAll() {
+		indexed.ObjectSetElm(groupKey(elm, keyPath), elm.Clone())
+	}
+
+	return indexed
+}
+
+func groupKey(elm Value, keyPath Path) string {
+	key, ok := Find(elm, keyPath)
+	if !ok {
+		return ""
+	}
+
+	var b bytes.Buffer
+	writeGroupKey(&b, key)
+
+	return b.String()
+}
+
+// writeGroupKey writes a JSON value v as a JSON text in which JSON values equal by Equal are written identically.
+func writeGroupKey(b *bytes.Buffer, v Value) {
+	switch v.Type() {
+	case TypeNull:
+		b.WriteString("null")
+	case TypeBoolean:
+		b.WriteString(strconv.FormatBool(v.BooleanGet()))
+	case TypeNumber:
+		b.WriteString(normalizeNumber(v.NumberGet()))
+	case TypeString:
+		writeCanonicalString(b, v.StringGet())
+	case TypeArray:
+		b.WriteByte('[')
+		for i, elm := range v.ArrayAll() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeGroupKey(b, elm)
+		}
+		b.WriteByte(']')
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, key)
+			b.WriteByte(':')
+			writeGroupKey(b, v.ObjectGetElm(key))
+		}
+		b.WriteByte('}')
+	}
+}
+
+// maxPlainExponent bounds the exponents of the numbers formatted by normalizeNumber without exponents.
+const maxPlainExponent = 100
+
+// normalizeNumber formats a number exactly so that equal numbers are formatted identically.
+// The number is formatted in the decimal notation without exponents unless its exponent is too large or too small.
+func normalizeNumber(n json.Number) string {
+	d, ok := parseDecimal(n.String())
+	if !ok {
+		return n.String()
+	}
+	if d.sign == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if d.sign < 0 {
+		sign = "-"
+	}
+	if !d.exp.IsInt64() || d.exp.Int64() < -maxPlainExponent || d.exp.Int64() > maxPlainExponent {
+		mantissa := d.digits[:1]
+		if len(d.digits) > 1 {
+			mantissa += "." + d.digits[1:]
+		}
+		return sign + mantissa + "e" + new(big.Int).Sub(d.exp, big.NewInt(1)).String()
+	}
+
+	switch e := int(d.exp.Int64()); {
+	case e <= 0:
+		return sign + "0." + strings.Repeat("0", -e) + d.digits
+	case e >= len(d.digits):
+		return sign + d.digits + strings.Repeat("0", e-len(d.digits))
+	default:
+		return sign + d.digits[:e] + "." + d.digits[e:]
+	}
+}
+
+// Distinct returns a new JSON array containing the deep copies of the elements of a JSON array v without duplicates determined by Equal.
+// The first occurrence of each element is kept in the original order.
+func Distinct(v Value) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	indices := make([]int, v.ArrayLen())
+	for i := range indices {
+		indices[i] = i
+	}
+	slices.SortStableFunc(indices, func(a, b int) bool {
+		return Compare(v.ArrayGetElm(a), v.ArrayGetElm(b)) < 0
+	})
+
+	duplicated := make([]bool, v.ArrayLen())
+	for i := 1; i < len(indices); i++ {
+		if Compare(v.ArrayGetElm(indices[i-1]), v.ArrayGetElm(indices[i])) == 0 {
+			duplicated[indices[i]] = true
+		}
+	}
+
+	return Filter(v, func(index int, _ Value) bool { return !duplicated[index] })
+}
+
+// FlattenArray returns a new JSON array in which the JSON arrays nested in a JSON array v are expanded up to depth levels.
+// If depth is negative, all the nested JSON arrays are expanded.
+// The JSON values in the result are deep copies.
+func FlattenArray(v Value, depth int) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	flattened := Array()
+	for _, elm := range v.ArrayAll() {
+		if elm.Type() == TypeArray && depth != 0 {
+			flattened.ArrayAddElm(arrayElms(FlattenArray(elm, depth-1))...)
+		} else {
+			flattened.ArrayAddElm(elm.Clone())
+		}
+	}
+
+	return flattened
+}
+
+func arrayElms(v Value) []Value {
+	elms := make([]Value, v.ArrayLen())
+	for i, elm := range v.ArrayAll() {
+		elms[i] = elm
+	}
+
+	return elms
+}
+
+// Zip returns a new JSON array whose i-th element is a JSON array containing the deep copies of the i-th elements of JSON arrays vs.
+// The length of the result is the minimum length of vs.
+func Zip(vs ...Value) Value {
+	n := -1
+	for _, v := range vs {
+		assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+		if n < 0 || v.ArrayLen() < n {
+			n = v.ArrayLen()
+		}
+	}
+
+	zipped := Array()
+	for i := 0; i < n; i++ {
+		tuple := Array()
+		for _, v := range vs {
+			tuple.ArrayAddElm(v.ArrayGetElm(i).Clone())
+		}
+		zipped.ArrayAddElm(tuple)
+	}
+
+	return zipped
+}
@@ -0,0 +1,137 @@
+package jsonvalue_test
+
+import (
+	"strconv"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+const collectionExample = `[
+	{"id":1,"team":"a","tags":["x"]},
+	{"id":2,"team":"b","tags":["y","z"]},
+	{"id":3,"team":"a","tags":[]},
+	{"id":4,"tags":["x"]}
+]`
+
+func TestFilter(t *testing.T) {
+	v := mustUnmarshal(t, collectionExample)
+	a := jsonvalue.Filter(v, func(index int, elm jsonvalue.Value) bool {
+		team, ok := jsonvalue.Find(elm, jsonvalue.Path{jsonvalue.KeyName("team")})
+		return ok && team.StringGet() == "a"
+	})
+	equal(t, mustMarshal(t, a), `[{"id":1,"tags":["x"],"team":"a"},{"id":3,"tags":[],"team":"a"}]`)
+
+	a.ArrayGetElm(0).ObjectSetElm("id", jsonvalue.Null())
+	equal(t, v.ArrayGetElm(0).ObjectGetElm("id").NumberGet().String(), "1")
+}
+
+func TestMapArray(t *testing.T) {
+	v := mustUnmarshal(t, collectionExample)
+	a := jsonvalue.MapArray(v, func(index int, elm jsonvalue.Value) jsonvalue.Value {
+		return jsonvalue.Array(jsonvalue.Number(index), elm.ObjectGetElm("id"))
+	})
+	equal(t, mustMarshal(t, a), `[[0,1],[1,2],[2,3],[3,4]]`)
+}
+
+func TestReduce(t *testing.T) {
+	v := mustUnmarshal(t, collectionExample)
+	t.Run("sum", func(t *testing.T) {
+		a := jsonvalue.Reduce(v, jsonvalue.Number(0), func(acc jsonvalue.Value, index int, elm jsonvalue.Value) jsonvalue.Value {
+			x, _ := strconv.Atoi(acc.NumberGet().String())
+			y, _ := strconv.Atoi(elm.ObjectGetElm("id").NumberGet().String())
+			return jsonvalue.Number(x + y)
+		})
+		equal(t, a.NumberGet().String(), "10")
+	})
+	t.Run("empty", func(t *testing.T) {
+		a := jsonvalue.Reduce(jsonvalue.Array(), jsonvalue.Null(), func(acc jsonvalue.Value, index int, elm jsonvalue.Value) jsonvalue.Value {
+			return elm
+		})
+		equal(t, a.Type(), jsonvalue.TypeNull)
+	})
+}
+
+func TestGroupBy(t *testing.T) {
+	v := mustUnmarshal(t, collectionExample)
+	t.Run("string", func(t *testing.T) {
+		a := jsonvalue.GroupBy(v, jsonvalue.Path{jsonvalue.KeyName("team")})
+		equal(t, a.ObjectLen(), 3)
+		equal(t, mustMarshal(t, a.ObjectGetElm(`"a"`)), `[{"id":1,"tags":["x"],"team":"a"},{"id":3,"tags":[],"team":"a"}]`)
+		equal(t, a.ObjectGetElm(`"b"`).ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("").ArrayLen(), 1)
+	})
+	t.Run("non string", func(t *testing.T) {
+		a := jsonvalue.GroupBy(v, jsonvalue.Path{jsonvalue.KeyName("tags"), jsonvalue.KeyIndex(0)})
+		equal(t, a.ObjectLen(), 3)
+		equal(t, a.ObjectGetElm(`"x"`).ArrayLen(), 2)
+		equal(t, a.ObjectGetElm(`"y"`).ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("").ArrayLen(), 1)
+	})
+	t.Run("distinct types", func(t *testing.T) {
+		v := mustUnmarshal(t, `[{"k":null},{"k":"null"},{},{"k":1},{"k":"1"},{"k":1.0},{"k":1e0},{"k":12345678901234567890},{"k":12345678901234567891},{"k":0.5e-1},{"k":{"b":[1],"a":true}},{"k":{"a":true,"b":[10e-1]}}]`)
+		a := jsonvalue.GroupBy(v, jsonvalue.Path{jsonvalue.KeyName("k")})
+		equal(t, a.ObjectLen(), 9)
+		equal(t, a.ObjectGetElm("null").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm(`"null"`).ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("1").ArrayLen(), 3)
+		equal(t, a.ObjectGetElm(`"1"`).ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("12345678901234567890").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("12345678901234567891").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("0.05").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm(`{"a":true,"b":[1]}`).ArrayLen(), 2)
+	})
+	t.Run("large exponents", func(t *testing.T) {
+		v := mustUnmarshal(t, `[{"k":1e10000000},{"k":10e9999999},{"k":-1.5e-200},{"k":-15e-201},{"k":-120e-3},{"k":1e2}]`)
+		a := jsonvalue.GroupBy(v, jsonvalue.Path{jsonvalue.KeyName("k")})
+		equal(t, a.ObjectLen(), 4)
+		equal(t, a.ObjectGetElm("1e10000000").ArrayLen(), 2)
+		equal(t, a.ObjectGetElm("-1.5e-200").ArrayLen(), 2)
+		equal(t, a.ObjectGetElm("-0.12").ArrayLen(), 1)
+		equal(t, a.ObjectGetElm("100").ArrayLen(), 1)
+	})
+}
+
+func TestIndexBy(t *testing.T) {
+	v := mustUnmarshal(t, collectionExample)
+	a := jsonvalue.IndexBy(v, jsonvalue.Path{jsonvalue.KeyName("id")})
+	equal(t, a.ObjectLen(), 4)
+	equal(t, a.ObjectGetElm("3").ObjectGetElm("team").StringGet(), "a")
+
+	b := jsonvalue.IndexBy(v, jsonvalue.Path{jsonvalue.KeyName("team")})
+	equal(t, b.ObjectLen(), 3)
+	equal(t, b.ObjectGetElm(`"a"`).ObjectGetElm("id").NumberGet().String(), "3")
+
+	c := jsonvalue.IndexBy(mustUnmarshal(t, `[{"k":"1"},{"k":1}]`), jsonvalue.Path{jsonvalue.KeyName("k")})
+	equal(t, c.ObjectLen(), 2)
+}
+
+func TestDistinct(t *testing.T) {
+	v := mustUnmarshal(t, `[3,1,"a",{"x":[1]},1.0,3,null,{"x":[1]},"a",null]`)
+	a := jsonvalue.Distinct(v)
+	equal(t, mustMarshal(t, a), `[3,1,"a",{"x":[1]},null]`)
+}
+
+func TestFlattenArray(t *testing.T) {
+	v := mustUnmarshal(t, `[1,[2,[3,[4]]],[],{"a":[5]}]`)
+	t.Run("all", func(t *testing.T) {
+		equal(t, mustMarshal(t, jsonvalue.FlattenArray(v, -1)), `[1,2,3,4,{"a":[5]}]`)
+	})
+	t.Run("depth 0", func(t *testing.T) {
+		equal(t, mustMarshal(t, jsonvalue.FlattenArray(v, 0)), `[1,[2,[3,[4]]],[],{"a":[5]}]`)
+	})
+	t.Run("depth 1", func(t *testing.T) {
+		equal(t, mustMarshal(t, jsonvalue.FlattenArray(v, 1)), `[1,2,[3,[4]],{"a":[5]}]`)
+	})
+}
+
+func TestZip(t *testing.T) {
+	t.Run("zip", func(t *testing.T) {
+		a := jsonvalue.Zip(mustUnmarshal(t, `[1,2,3]`), mustUnmarshal(t, `["a","b"]`), mustUnmarshal(t, `[null,true,false]`))
+		equal(t, mustMarshal(t, a), `[[1,"a",null],[2,"b",true]]`)
+	})
+	t.Run("empty", func(t *testing.T) {
+		equal(t, mustMarshal(t, jsonvalue.Zip()), `[]`)
+	})
+}
@@ -0,0 +1,208 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// CSVOptions configures ToCSV and FromCSV.
+type CSVOptions struct {
+	// Comma is the field delimiter such as '\t' for TSV. If Comma is 0, ',' is used.
+	Comma rune
+	// Separator joins the keys of the Path to a leaf into a column name. If Separator is empty, "." is used.
+	Separator string
+	// Columns is the column names written by ToCSV in order.
+	// If Columns is nil, the columns for all the leaves are written in the order of their Paths.
+	Columns []string
+}
+
+func (o CSVOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+
+	return o.Comma
+}
+
+func (o CSVOptions) separator() string {
+	if o.Separator == "" {
+		return "."
+	}
+
+	return o.Separator
+}
+
+// ToCSV encodes a JSON array of JSON objects into CSV with a header row, writing each JSON object as a row.
+// Each leaf, which is a scalar or an empty JSON object or array, is written in the column named by joining the keys of its Path with the separator.
+// Strings are written as they are, null is written as null, empty JSON objects and arrays are written as {} and [],
+// and the cells for missing leaves are left empty.
+// A row of an empty cell in a single column is written as "" so that it is not skipped as a blank line.
+// Member names containing the separator or consisting of digits are rejected with errors, since they cannot be read back by FromCSV.
+func ToCSV(v Value, opts CSVOptions) ([]byte, error) {
+	if v.Type() != TypeArray {
+		return nil, fmt.Errorf(`fail to encode CSV: JSON value must be an array but got %v`, v.Type())
+	}
+
+	sep := opts.separator()
+	rows := []map[string]string{}
+	columnPaths := map[string]Path{}
+	for i, row := range v.ArrayAll() {
+		if row.Type() != TypeObject {
+			return nil, fmt.Errorf(`fail to encode CSV: element at %d must be an object but got %v`, i, row.Type())
+		}
+		cells := map[string]string{}
+		for path, val := range All(row) {
+			if len(path) == 0 || val.Type() == TypeObject && val.ObjectLen() > 0 || val.Type() == TypeArray && val.ArrayLen() > 0 {
+				continue
+			}
+			names := []string{}
+			for _, key := range path {
+				if !key.IsIndex() && strings.Contains(key.String(), sep) {
+					return nil, fmt.Errorf(`fail to encode CSV: member name %q in row %d contains separator %q`, key.String(), i, sep)
+				}
+				if !key.IsIndex() && isDigits(key.String()) {
+					return nil, fmt.Errorf(`fail to encode CSV: member name %q in row %d consists of digits, which is read as an index`, key.String(), i)
+				}
+				names = append(names, key.String())
+			}
+			column := strings.Join(names, sep)
+			cells[column] = formatCSVCell(val)
+			columnPaths[column] = path
+		}
+		rows = append(rows, cells)
+	}
+
+	columns := opts.Columns
+	if columns == nil {
+		columns = []string{}
+		for column := range columnPaths {
+			columns = append(columns, column)
+		}
+		slices.SortFunc(columns, func(a, b string) bool { return columnPaths[a].Compare(columnPaths[b]) < 0 })
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Comma = opts.comma()
+	records := [][]string{columns}
+	for _, cells := range rows {
+		record := []string{}
+		for _, column := range columns {
+			record = append(record, cells[column])
+		}
+		records = append(records, record)
+	}
+	for _, record := range records {
+		if len(record) == 1 && record[0] == "" {
+			// A record of an empty field is quoted since a blank line is skipped on reading.
+			w.Flush()
+			b.WriteString("\"\"\n")
+			continue
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf(`fail to encode CSV: %w`, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf(`fail to encode CSV: %w`, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func formatCSVCell(v Value) string {
+	switch v.Type() {
+	case TypeNull:
+		return "null"
+	case TypeBoolean:
+		return strconv.FormatBool(v.BooleanGet())
+	case TypeNumber:
+		return v.NumberGet().String()
+	case TypeString:
+		return v.StringGet()
+	case TypeArray:
+		return "[]"
+	default:
+		return "{}"
+	}
+}
+
+// FromCSV decodes CSV with a header row into a JSON array of JSON objects, reading each row as a JSON object.
+// Column names are split by the separator into the keys of Paths, where keys consisting of digits are read as array indices.
+// Cells are inferred as numbers, true, false, null, {} and [] if they are written so, and as strings otherwise.
+// Empty cells are skipped, and the skipped elements of JSON arrays are filled with null.
+func FromCSV(b []byte, opts CSVOptions) (Value, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.Comma = opts.comma()
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf(`fail to decode CSV: %w`, err)
+	}
+	if len(records) == 0 {
+		return Array(), nil
+	}
+
+	paths := []Path{}
+	for _, column := range records[0] {
+		path := Path{}
+		for _, name := range strings.Split(column, opts.separator()) {
+			if i, err := strconv.Atoi(name); err == nil && i >= 0 && strings.TrimLeft(name, "0123456789") == "" {
+				path = path.Append(KeyIndex(i))
+			} else {
+				path = path.Append(KeyName(name))
+			}
+		}
+		if path[0].IsIndex() {
+			return nil, fmt.Errorf(`fail to decode CSV: column %q must start with a member name`, column)
+		}
+		paths = append(paths, path)
+	}
+
+	rows := Array()
+	for i, record := range records[1:] {
+		order := []int{}
+		for j := range record {
+			if record[j] != "" {
+				order = append(order, j)
+			}
+		}
+		slices.SortStableFunc(order, func(a, b int) bool { return paths[a].Compare(paths[b]) < 0 })
+
+		row := Object()
+		for _, j := range order {
+			if err := insertAt(row, paths[j], inferCSVCell(record[j]), false); err != nil {
+				return nil, fmt.Errorf(`fail to decode CSV: row %d: column %q: %w`, i+1, records[0][j], err)
+			}
+		}
+		rows.ArrayAddElm(row)
+	}
+
+	return rows, nil
+}
+
+func inferCSVCell(s string) Value {
+	switch s {
+	case "null":
+		return Null()
+	case "true":
+		return Boolean(true)
+	case "false":
+		return Boolean(false)
+	case "{}":
+		return Object()
+	case "[]":
+		return Array()
+	}
+	if s != "" && strings.TrimSpace(s) == s && (s[0] == '-' || '0' <= s[0] && s[0] <= '9') && json.Valid([]byte(s)) {
+		return Number(json.Number(s))
+	}
+
+	return String(s)
+}
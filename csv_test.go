@@ -0,0 +1,117 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestToCSV(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		opts jsonvalue.CSVOptions
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: `[]`, want: "\n"},
+		{name: "flat", in: `[{"b":1,"a":"x"},{"a":"y, z","c":true}]`, want: "a,b,c\nx,1,\n\"y, z\",,true\n"},
+		{name: "nested", in: `[{"user":{"name":"a","tags":["p","q"]},"id":1}]`, want: "id,user.name,user.tags.0,user.tags.1\n1,a,p,q\n"},
+		{name: "index order", in: `[{"a":[0,1,2,3,4,5,6,7,8,9,10]}]`, want: "a.0,a.1,a.2,a.3,a.4,a.5,a.6,a.7,a.8,a.9,a.10\n0,1,2,3,4,5,6,7,8,9,10\n"},
+		{name: "literals", in: `[{"n":null,"o":{},"a":[],"s":"","f":false}]`, want: "a,f,n,o,s\n[],false,null,{},\n"},
+		{name: "tsv", in: `[{"a":{"b":1},"c":"x\ty"}]`, opts: jsonvalue.CSVOptions{Comma: '\t', Separator: "/"}, want: "a/b\tc\n1\t\"x\ty\"\n"},
+		{name: "columns", in: `[{"a":1,"b":{"c":2},"d":3}]`, opts: jsonvalue.CSVOptions{Columns: []string{"d", "b.c", "x"}}, want: "d,b.c,x\n3,2,\n"},
+		{name: "single column", in: `[{"a":1},{},{"a":""}]`, want: "a\n1\n\"\"\n\"\"\n"},
+		{name: "empty column name", in: `[{"":1}]`, want: "\"\"\n1\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.ToCSV(mustUnmarshal(t, testCase.in), testCase.opts)
+			equal(t, err, nil)
+			equal(t, string(got), testCase.want)
+		})
+	}
+}
+
+func TestToCSV_Error(t *testing.T) {
+	testCases := map[string]string{
+		"not array":            `{"a":1}`,
+		"not object row":       `[{"a":1},[1]]`,
+		"separator in a key":   `[{"a.b":1}]`,
+		"separator in nesting": `[{"a":{"b.c":1}}]`,
+		"digit key":            `[{"a":{"0":"x"}}]`,
+		"leading zero key":     `[{"01":1}]`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.ToCSV(mustUnmarshal(t, in), jsonvalue.CSVOptions{})
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestFromCSV(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		opts jsonvalue.CSVOptions
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: "", want: `[]`},
+		{name: "header only", in: "a,b\n", want: `[]`},
+		{name: "type inference", in: "a,b,c,d,e,f,g\n1,-2.5e3,true,null,x,{},[]\n01,1 ,TRUE,,\"\"\"1\"\"\",false,-\n", want: `[{"a":1,"b":-2.5e3,"c":true,"d":null,"e":"x","f":{},"g":[]},{"a":"01","b":"1 ","c":"TRUE","e":"\"1\"","f":false,"g":"-"}]`},
+		{name: "nested", in: "user.tags.1,id,user.name,user.tags.0\nq,1,a,p\n", want: `[{"id":1,"user":{"name":"a","tags":["p","q"]}}]`},
+		{name: "sparse array", in: "a.2,a.0.x\n3,\n,4\n", want: `[{"a":[null,null,3]},{"a":[{"x":4}]}]`},
+		{name: "tsv", in: "a/b\tc\n1\t\"x\ty\"\n", opts: jsonvalue.CSVOptions{Comma: '\t', Separator: "/"}, want: `[{"a":{"b":1},"c":"x\ty"}]`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.FromCSV([]byte(testCase.in), testCase.opts)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, got)), string(mustMarshal(t, mustUnmarshal(t, testCase.want))))
+		})
+	}
+}
+
+func TestFromCSV_Error(t *testing.T) {
+	testCases := map[string]string{
+		"leading index":      "0\n1\n",
+		"leaf and container": "a,a.b\n1,2\n",
+		"object and array":   "a.b,a.0\n1,2\n",
+		"duplicate column":   "a,a\n1,2\n",
+		"wrong field count":  "a,b\n1\n",
+		"unterminated quote": "a\n\"x\n",
+		"too large index":    "a.99999999999\n1\n",
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.FromCSV([]byte(in), jsonvalue.CSVOptions{})
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestCSV_RoundTrip(t *testing.T) {
+	in := mustUnmarshal(t, `[{"id":1,"user":{"name":"a","tags":["p","q"]},"ok":true},{"id":2,"user":{"name":"b, c","tags":[]},"none":null}]`)
+	b, err := jsonvalue.ToCSV(in, jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	got, err := jsonvalue.FromCSV(b, jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+
+	// Empty cells in a single column are not skipped as blank lines.
+	in = mustUnmarshal(t, `[{"a":1},{},{"a":2},{}]`)
+	b, err = jsonvalue.ToCSV(in, jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	got, err = jsonvalue.FromCSV(b, jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+
+	// Empty strings are read as missing leaves.
+	b, err = jsonvalue.ToCSV(mustUnmarshal(t, `[{"a":""},{"a":"x"}]`), jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	got, err = jsonvalue.FromCSV(b, jsonvalue.CSVOptions{})
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, mustUnmarshal(t, `[{},{"a":"x"}]`)), true)
+}
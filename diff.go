@@ -0,0 +1,80 @@
+package jsonvalue
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// Diff returns a JSON Patch that transforms a JSON value from into a JSON value to.
+// Object members are compared by key and array elements are compared by index, and the resulting operations are add, remove and replace.
+// The JSON values in the patch are deep copies.
+func Diff(from Value, to Value) Patch {
+	return diffImpl(Path{}, from, to, Patch{})
+}
+
+func diffImpl(path Path, from Value, to Value, patch Patch) Patch {
+	if Equal(from, to) {
+		return patch
+	}
+
+	switch {
+	case from.Type() == TypeObject && to.Type() == TypeObject:
+		keys := from.ObjectKeys()
+		slices.Sort(keys)
+		for _, key := range keys {
+			if !to.ObjectHasElm(key) {
+				patch = append(patch, PatchOperation{Op: PatchOpRemove, Path: path.Append(KeyName(key)).Pointer()})
+			}
+		}
+		keys = to.ObjectKeys()
+		slices.Sort(keys)
+		for _, key := range keys {
+			childPath := path.Append(KeyName(key))
+			if !from.ObjectHasElm(key) {
+				patch = append(patch, PatchOperation{Op: PatchOpAdd, Path: childPath.Pointer(), Value: to.ObjectGetElm(key).Clone()})
+				continue
+			}
+			patch = diffImpl(childPath, from.ObjectGetElm(key), to.ObjectGetElm(key), patch)
+		}
+		return patch
+	case from.Type() == TypeArray && to.Type() == TypeArray:
+		n := min(from.ArrayLen(), to.ArrayLen())
+		for i := 0; i < n; i++ {
+			patch = diffImpl(path.Append(KeyIndex(i)), from.ArrayGetElm(i), to.ArrayGetElm(i), patch)
+		}
+		for i := from.ArrayLen() - 1; i >= n; i-- {
+			patch = append(patch, PatchOperation{Op: PatchOpRemove, Path: path.Append(KeyIndex(i)).Pointer()})
+		}
+		for i := n; i < to.ArrayLen(); i++ {
+			patch = append(patch, PatchOperation{Op: PatchOpAdd, Path: path.Append(KeyIndex(i)).Pointer(), Value: to.ArrayGetElm(i).Clone()})
+		}
+		return patch
+	default:
+		return append(patch, PatchOperation{Op: PatchOpReplace, Path: path.Pointer(), Value: to.Clone()})
+	}
+}
+
+// MergePatch returns a new JSON value obtained by applying a JSON Merge Patch (RFC 7386) patch to a JSON value target.
+// A member of patch with null removes the member from target, a member with a JSON object is merged recursively and the other members replace the members of target.
+// The result is a deep copy and target is not modified.
+func MergePatch(target Value, patch Value) Value {
+	if patch.Type() != TypeObject {
+		return patch.Clone()
+	}
+
+	merged := Object()
+	if target.Type() == TypeObject {
+		merged = target.Clone()
+	}
+	for key, val := range patch.ObjectAll() {
+		switch {
+		case val.Type() == TypeNull:
+			merged.ObjectDelElm(key)
+		case merged.ObjectHasElm(key):
+			merged.ObjectSetElm(key, MergePatch(merged.ObjectGetElm(key), val))
+		default:
+			merged.ObjectSetElm(key, MergePatch(Null(), val))
+		}
+	}
+
+	return merged
+}
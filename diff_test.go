@@ -0,0 +1,76 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestDiff(t *testing.T) {
+	type testCase struct {
+		name string
+		from string
+		to   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "equal", from: `{"a":[1,2]}`, to: `{"a":[1.0,2]}`, want: `[]`},
+		{name: "add member", from: `{"a":1}`, to: `{"a":1,"b":2}`, want: `[{"op":"add","path":"/b","value":2}]`},
+		{name: "remove member", from: `{"a":1,"b":2}`, to: `{"b":2}`, want: `[{"op":"remove","path":"/a"}]`},
+		{name: "replace member", from: `{"a":{"x":1}}`, to: `{"a":{"x":"1"}}`, want: `[{"op":"replace","path":"/a/x","value":"1"}]`},
+		{name: "shrink array", from: `[1,2,3]`, to: `[1]`, want: `[{"op":"remove","path":"/2"},{"op":"remove","path":"/1"}]`},
+		{name: "grow array", from: `[1]`, to: `[0,2,3]`, want: `[{"op":"replace","path":"/0","value":0},{"op":"add","path":"/1","value":2},{"op":"add","path":"/2","value":3}]`},
+		{name: "change type", from: `{"a":[]}`, to: `{"a":{}}`, want: `[{"op":"replace","path":"/a","value":{}}]`},
+		{name: "root", from: `1`, to: `null`, want: `[{"op":"replace","path":"","value":null}]`},
+		{name: "escaped", from: `{}`, to: `{"a/b":1}`, want: `[{"op":"add","path":"/a~1b","value":1}]`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			from := mustUnmarshal(t, testCase.from)
+			to := mustUnmarshal(t, testCase.to)
+
+			patch := jsonvalue.Diff(from, to)
+			equal(t, jsonvalue.Equal(mustUnmarshal(t, mustMarshal(t, patch)), mustUnmarshal(t, testCase.want)), true)
+
+			err := patch.Apply(from)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(from, to), true)
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	type testCase struct {
+		target string
+		patch  string
+		want   string
+	}
+	// The test cases are from Appendix A of RFC 7386.
+	testCases := []testCase{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{target: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{target: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{target: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{target: `{"e":null}`, patch: `{"a":1}`, want: `{"e":null,"a":1}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.target+" "+testCase.patch, func(t *testing.T) {
+			target := mustUnmarshal(t, testCase.target)
+			patch := mustUnmarshal(t, testCase.patch)
+
+			got := jsonvalue.MergePatch(target, patch)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+			equal(t, jsonvalue.Equal(target, mustUnmarshal(t, testCase.target)), true)
+		})
+	}
+}
@@ -0,0 +1,584 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ExtJSONMode is a format of MongoDB Extended JSON v2.
+type ExtJSONMode int
+
+const (
+	// ExtJSONCanonical represents all BSON types losslessly with type wrappers such as {"$numberInt": "1"}.
+	ExtJSONCanonical ExtJSONMode = iota
+	// ExtJSONRelaxed represents finite doubles, int32 and int64 as JSON numbers and dates between the years 1970 and 9999 as ISO-8601 strings.
+	ExtJSONRelaxed
+)
+
+const (
+	bsonDouble     byte = 0x01
+	bsonString     byte = 0x02
+	bsonDocument   byte = 0x03
+	bsonArray      byte = 0x04
+	bsonBinary     byte = 0x05
+	bsonUndefined  byte = 0x06
+	bsonObjectID   byte = 0x07
+	bsonBoolean    byte = 0x08
+	bsonDateTime   byte = 0x09
+	bsonNull       byte = 0x0a
+	bsonRegex      byte = 0x0b
+	bsonDBPointer  byte = 0x0c
+	bsonJavaScript byte = 0x0d
+	bsonSymbol     byte = 0x0e
+	bsonCodeWScope byte = 0x0f
+	bsonInt32      byte = 0x10
+	bsonTimestamp  byte = 0x11
+	bsonInt64      byte = 0x12
+	bsonDecimal128 byte = 0x13
+	bsonMinKey     byte = 0xff
+	bsonMaxKey     byte = 0x7f
+)
+
+// extJSONKeys holds the keys identifying the type wrappers of Extended JSON.
+var extJSONKeys = []string{"$binary", "$code", "$date", "$dbPointer", "$maxKey", "$minKey", "$numberDecimal", "$numberDouble", "$numberInt", "$numberLong", "$oid", "$regularExpression", "$scope", "$symbol", "$timestamp", "$undefined", "$uuid"}
+
+// ConvertExtJSON returns a new JSON value converting the MongoDB Extended JSON v2 representation v into the format mode.
+// Type wrappers in either the canonical or the relaxed format are accepted, and the other JSON numbers are read as
+// int32 or int64 if they are written as integers in the ranges and as doubles otherwise.
+// Malformed type wrappers are rejected with errors.
+func ConvertExtJSON(v Value, mode ExtJSONMode) (Value, error) {
+	kind, payload, err := encodeBSONElement(v, 0)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to convert Extended JSON: %w`, err)
+	}
+	d := &bsonDecoder{src: payload, mode: mode}
+	converted, err := d.decodeElement(kind, 0)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to convert Extended JSON: %w`, err)
+	}
+
+	return converted, nil
+}
+
+func appendBSONString(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s)+1))
+	b = append(b, s...)
+
+	return append(b, 0)
+}
+
+func appendBSONCString(b []byte, s string) ([]byte, error) {
+	if strings.IndexByte(s, 0) >= 0 {
+		return nil, fmt.Errorf(`%q must not contain NUL`, s)
+	}
+	b = append(b, s...)
+
+	return append(b, 0), nil
+}
+
+// encodeBSONNumber encodes a JSON number as int32 or int64 if it is written as an integer in the ranges, as a decimal128 if it is written as an integer out of the ranges,
+// and as a double otherwise.
+// An integer not representable in decimal128 exactly is rejected with an error instead of being rounded into a double.
+func encodeBSONNumber(n json.Number) (byte, []byte, error) {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return bsonInt32, binary.LittleEndian.AppendUint32(nil, uint32(int32(i))), nil
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return bsonInt64, binary.LittleEndian.AppendUint64(nil, uint64(i)), nil
+		}
+		d, err := parseDecimal128(s)
+		if err != nil {
+			return 0, nil, fmt.Errorf(`integer %s out of the range of int64 is not representable in decimal128: %w`, s, err)
+		}
+		return bsonDecimal128, d, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf(`number %s is not representable in IEEE 754 double precision: %w`, s, err)
+	}
+
+	return bsonDouble, binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)), nil
+}
+
+// isExtJSONWrapper returns true if a JSON object has a key of the type wrappers.
+func isExtJSONWrapper(v Value) bool {
+	for key := range v.ObjectAll() {
+		if _, found := slices.BinarySearch(extJSONKeys, key); found {
+			return true
+		}
+	}
+
+	return false
+}
+
+func extJSONMember(v Value, key string, typ Type) (Value, error) {
+	if v.Type() != TypeObject || !v.ObjectHasElm(key) {
+		return nil, fmt.Errorf(`member %q is required`, key)
+	}
+	m := v.ObjectGetElm(key)
+	if m.Type() != typ {
+		return nil, fmt.Errorf(`member %q must be %v but got %v`, key, typ, m.Type())
+	}
+
+	return m, nil
+}
+
+var (
+	extJSONUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	extJSONHexPattern  = regexp.MustCompile(`^[0-9a-fA-F]{24}$`)
+)
+
+// encodeExtJSONWrapper encodes a type wrapper of Extended JSON into a BSON element.
+func encodeExtJSONWrapper(v Value, depth int) (byte, []byte, error) {
+	keys := v.ObjectKeys()
+	slices.Sort(keys)
+	wrapper := strings.Join(keys, ",")
+	fail := func(err error) (byte, []byte, error) {
+		return 0, nil, fmt.Errorf(`invalid Extended JSON %s: %w`, wrapper, err)
+	}
+	str := func(key string) (string, error) {
+		m, err := extJSONMember(v, key, TypeString)
+		if err != nil {
+			return "", err
+		}
+		return m.StringGet(), nil
+	}
+
+	switch wrapper {
+	case "$oid":
+		s, err := str("$oid")
+		if err != nil {
+			return fail(err)
+		}
+		oid, err := parseObjectID(s)
+		if err != nil {
+			return fail(err)
+		}
+		return bsonObjectID, oid, nil
+	case "$symbol":
+		s, err := str("$symbol")
+		if err != nil {
+			return fail(err)
+		}
+		return bsonSymbol, appendBSONString(nil, s), nil
+	case "$code":
+		s, err := str("$code")
+		if err != nil {
+			return fail(err)
+		}
+		return bsonJavaScript, appendBSONString(nil, s), nil
+	case "$code,$scope":
+		s, err := str("$code")
+		if err != nil {
+			return fail(err)
+		}
+		scope, err := extJSONMember(v, "$scope", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		var doc bytes.Buffer
+		if err := encodeBSONDocument(&doc, scope, depth+1); err != nil {
+			return fail(err)
+		}
+		b := appendBSONString(nil, s)
+		b = append(b, doc.Bytes()...)
+		return bsonCodeWScope, append(binary.LittleEndian.AppendUint32(nil, uint32(len(b)+4)), b...), nil
+	case "$numberInt":
+		s, err := str("$numberInt")
+		if err != nil {
+			return fail(err)
+		}
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return fail(err)
+		}
+		return bsonInt32, binary.LittleEndian.AppendUint32(nil, uint32(int32(i))), nil
+	case "$numberLong":
+		s, err := str("$numberLong")
+		if err != nil {
+			return fail(err)
+		}
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fail(err)
+		}
+		return bsonInt64, binary.LittleEndian.AppendUint64(nil, uint64(i)), nil
+	case "$numberDouble":
+		s, err := str("$numberDouble")
+		if err != nil {
+			return fail(err)
+		}
+		f, err := parseExtJSONDouble(s)
+		if err != nil {
+			return fail(err)
+		}
+		return bsonDouble, binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)), nil
+	case "$numberDecimal":
+		s, err := str("$numberDecimal")
+		if err != nil {
+			return fail(err)
+		}
+		d, err := parseDecimal128(s)
+		if err != nil {
+			return fail(err)
+		}
+		return bsonDecimal128, d, nil
+	case "$binary":
+		bin, err := extJSONMember(v, "$binary", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		if bin.ObjectLen() != 2 {
+			return fail(fmt.Errorf(`$binary must have only base64 and subType`))
+		}
+		b64, err := extJSONMember(bin, "base64", TypeString)
+		if err != nil {
+			return fail(err)
+		}
+		subType, err := extJSONMember(bin, "subType", TypeString)
+		if err != nil {
+			return fail(err)
+		}
+		data, err := base64.StdEncoding.DecodeString(b64.StringGet())
+		if err != nil {
+			return fail(err)
+		}
+		st, err := strconv.ParseUint(subType.StringGet(), 16, 8)
+		if err != nil || len(subType.StringGet()) > 2 {
+			return fail(fmt.Errorf(`subType must be a hexadecimal byte: %q`, subType.StringGet()))
+		}
+		return bsonBinary, encodeBSONBinary(byte(st), data), nil
+	case "$uuid":
+		s, err := str("$uuid")
+		if err != nil {
+			return fail(err)
+		}
+		if !extJSONUUIDPattern.MatchString(s) {
+			return fail(fmt.Errorf(`invalid UUID %q`, s))
+		}
+		data, _ := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+		return bsonBinary, encodeBSONBinary(4, data), nil
+	case "$timestamp":
+		ts, err := extJSONMember(v, "$timestamp", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		if ts.ObjectLen() != 2 {
+			return fail(fmt.Errorf(`$timestamp must have only t and i`))
+		}
+		var parts [2]uint64
+		for i, key := range []string{"t", "i"} {
+			m, err := extJSONMember(ts, key, TypeNumber)
+			if err != nil {
+				return fail(err)
+			}
+			if parts[i], err = strconv.ParseUint(m.NumberGet().String(), 10, 32); err != nil {
+				return fail(err)
+			}
+		}
+		return bsonTimestamp, binary.LittleEndian.AppendUint64(nil, parts[0]<<32|parts[1]), nil
+	case "$regularExpression":
+		re, err := extJSONMember(v, "$regularExpression", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		if re.ObjectLen() != 2 {
+			return fail(fmt.Errorf(`$regularExpression must have only pattern and options`))
+		}
+		pattern, err := extJSONMember(re, "pattern", TypeString)
+		if err != nil {
+			return fail(err)
+		}
+		options, err := extJSONMember(re, "options", TypeString)
+		if err != nil {
+			return fail(err)
+		}
+		opts := []byte(options.StringGet())
+		slices.Sort(opts)
+		b, err := appendBSONCString(nil, pattern.StringGet())
+		if err != nil {
+			return fail(err)
+		}
+		if b, err = appendBSONCString(b, string(opts)); err != nil {
+			return fail(err)
+		}
+		return bsonRegex, b, nil
+	case "$dbPointer":
+		ptr, err := extJSONMember(v, "$dbPointer", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		if ptr.ObjectLen() != 2 {
+			return fail(fmt.Errorf(`$dbPointer must have only $ref and $id`))
+		}
+		ref, err := extJSONMember(ptr, "$ref", TypeString)
+		if err != nil {
+			return fail(err)
+		}
+		id, err := extJSONMember(ptr, "$id", TypeObject)
+		if err != nil {
+			return fail(err)
+		}
+		kind, oid, err := encodeExtJSONWrapper(id, depth+1)
+		if err != nil || kind != bsonObjectID {
+			return fail(fmt.Errorf(`$id must be an ObjectId`))
+		}
+		return bsonDBPointer, append(appendBSONString(nil, ref.StringGet()), oid...), nil
+	case "$date":
+		ms, err := parseExtJSONDate(v.ObjectGetElm("$date"))
+		if err != nil {
+			return fail(err)
+		}
+		return bsonDateTime, binary.LittleEndian.AppendUint64(nil, uint64(ms)), nil
+	case "$minKey", "$maxKey":
+		m, err := extJSONMember(v, wrapper, TypeNumber)
+		if err != nil {
+			return fail(err)
+		}
+		if m.NumberGet().String() != "1" {
+			return fail(fmt.Errorf(`%s must be 1`, wrapper))
+		}
+		if wrapper == "$minKey" {
+			return bsonMinKey, nil, nil
+		}
+		return bsonMaxKey, nil, nil
+	case "$undefined":
+		m, err := extJSONMember(v, "$undefined", TypeBoolean)
+		if err != nil {
+			return fail(err)
+		}
+		if !m.BooleanGet() {
+			return fail(fmt.Errorf(`$undefined must be true`))
+		}
+		return bsonUndefined, nil, nil
+	default:
+		return fail(fmt.Errorf(`unknown type wrapper`))
+	}
+}
+
+func parseObjectID(s string) ([]byte, error) {
+	if !extJSONHexPattern.MatchString(s) {
+		return nil, fmt.Errorf(`ObjectId must be 24 hexadecimal digits: %q`, s)
+	}
+	oid, _ := hex.DecodeString(s)
+
+	return oid, nil
+}
+
+func encodeBSONBinary(subType byte, data []byte) []byte {
+	if subType == 2 {
+		// The old binary subtype contains the length of the data again.
+		data = append(binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data...)
+	}
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, subType)
+
+	return append(b, data...)
+}
+
+// parseExtJSONDate parses the value of $date, which is an ISO-8601 string, {"$numberLong": "..."} or an integer, into milliseconds since the Unix epoch.
+func parseExtJSONDate(v Value) (int64, error) {
+	switch v.Type() {
+	case TypeString:
+		t, err := time.Parse(time.RFC3339Nano, v.StringGet())
+		if err != nil {
+			return 0, err
+		}
+		return t.UnixMilli(), nil
+	case TypeNumber:
+		return strconv.ParseInt(v.NumberGet().String(), 10, 64)
+	case TypeObject:
+		m, err := extJSONMember(v, "$numberLong", TypeString)
+		if err != nil || v.ObjectLen() != 1 {
+			return 0, fmt.Errorf(`$date must be a string, an integer or {"$numberLong": "..."}`)
+		}
+		return strconv.ParseInt(m.StringGet(), 10, 64)
+	default:
+		return 0, fmt.Errorf(`$date must be a string, an integer or {"$numberLong": "..."}`)
+	}
+}
+
+func parseExtJSONDouble(s string) (float64, error) {
+	switch s {
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	if !json.Valid([]byte(s)) {
+		return 0, fmt.Errorf(`invalid double %q`, s)
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// formatExtJSONDouble formats a double in the canonical format, or as a JSON number in the relaxed format if it is finite.
+// Integral doubles are formatted with a fraction to be distinguished from integers.
+func formatExtJSONDouble(f float64, mode ExtJSONMode) Value {
+	var s string
+	switch {
+	case math.IsInf(f, 1):
+		s = "Infinity"
+	case math.IsInf(f, -1):
+		s = "-Infinity"
+	case math.IsNaN(f):
+		s = "NaN"
+	default:
+		s = formatCanonicalNumber(f)
+		if math.Signbit(f) && f == 0 {
+			s = "-0"
+		}
+		mantissa, exponent, hasExponent := strings.Cut(s, "e")
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		s = mantissa
+		if hasExponent {
+			s += "E" + exponent
+		}
+		if mode == ExtJSONRelaxed {
+			return Number(json.Number(s))
+		}
+	}
+
+	return Object(Props{"$numberDouble": String(s)})
+}
+
+// formatExtJSONDate formats milliseconds since the Unix epoch as $date.
+func formatExtJSONDate(ms int64, mode ExtJSONMode) Value {
+	t := time.UnixMilli(ms).UTC()
+	if mode == ExtJSONRelaxed && 1970 <= t.Year() && t.Year() <= 9999 {
+		return Object(Props{"$date": String(t.Format("2006-01-02T15:04:05.999Z07:00"))})
+	}
+
+	return Object(Props{"$date": Object(Props{"$numberLong": String(strconv.FormatInt(ms, 10))})})
+}
+
+const (
+	decimal128ExponentBias = 6176
+	decimal128MaxExponent  = 6111
+	decimal128MinExponent  = -6176
+	decimal128MaxDigits    = 34
+)
+
+var decimal128Pattern = regexp.MustCompile(`^([+-]?)(?:([0-9]+)(?:\.([0-9]*))?|\.([0-9]+))(?:[eE]([+-]?[0-9]+))?$`)
+
+// parseDecimal128 parses a decimal string into the little-endian representation of IEEE 754 decimal128 in the binary integer decimal encoding.
+// Strings not representable exactly are rejected with errors.
+func parseDecimal128(s string) ([]byte, error) {
+	var high, low uint64
+	negative := strings.HasPrefix(s, "-")
+	switch strings.ToLower(strings.TrimLeft(s, "+-")) {
+	case "nan":
+		high = 0x1f << 58
+	case "inf", "infinity":
+		high = 0x1e << 58
+	default:
+		m := decimal128Pattern.FindStringSubmatch(s)
+		if m == nil {
+			return nil, fmt.Errorf(`invalid decimal %q`, s)
+		}
+		integer, fraction := m[2], m[3]+m[4]
+		exp := 0
+		if m[5] != "" {
+			e, err := strconv.Atoi(m[5])
+			if err != nil {
+				return nil, fmt.Errorf(`exponent of decimal %q out of range`, s)
+			}
+			exp = e
+		}
+		exp -= len(fraction)
+		digits := strings.TrimLeft(integer+fraction, "0")
+		for len(digits) > decimal128MaxDigits && strings.HasSuffix(digits, "0") {
+			digits, exp = digits[:len(digits)-1], exp+1
+		}
+		for exp > decimal128MaxExponent && len(digits) > 0 && len(digits) < decimal128MaxDigits {
+			digits, exp = digits+"0", exp-1
+		}
+		for exp < decimal128MinExponent && strings.HasSuffix(digits, "0") {
+			digits, exp = digits[:len(digits)-1], exp+1
+		}
+		if digits == "" {
+			exp = max(min(exp, decimal128MaxExponent), decimal128MinExponent)
+		}
+		if len(digits) > decimal128MaxDigits || exp > decimal128MaxExponent || exp < decimal128MinExponent {
+			return nil, fmt.Errorf(`decimal %q is not representable in decimal128 exactly`, s)
+		}
+		coefficient, _ := new(big.Int).SetString("0"+digits, 10)
+		low = new(big.Int).And(coefficient, new(big.Int).SetUint64(math.MaxUint64)).Uint64()
+		high = uint64(exp+decimal128ExponentBias)<<49 | new(big.Int).Rsh(coefficient, 64).Uint64()
+	}
+	if negative {
+		high |= 1 << 63
+	}
+
+	return binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(nil, low), high), nil
+}
+
+// formatDecimal128 formats the little-endian representation of IEEE 754 decimal128 as a string.
+func formatDecimal128(b []byte) string {
+	low, high := binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:])
+	sign := ""
+	if high>>63 == 1 {
+		sign = "-"
+	}
+
+	var exp int
+	coefficient := new(big.Int)
+	switch {
+	case high>>58&0x1f == 0x1f:
+		return "NaN"
+	case high>>58&0x1f == 0x1e:
+		return sign + "Infinity"
+	case high>>61&0x3 == 0x3:
+		// The coefficient in this form always exceeds the maximum and is treated as zero.
+		exp = int(high>>47&0x3fff) - decimal128ExponentBias
+	default:
+		exp = int(high>>49&0x3fff) - decimal128ExponentBias
+		coefficient.SetUint64(high & (1<<49 - 1))
+		coefficient.Lsh(coefficient, 64).Or(coefficient, new(big.Int).SetUint64(low))
+		if len(coefficient.String()) > decimal128MaxDigits {
+			coefficient.SetUint64(0)
+		}
+	}
+
+	digits := coefficient.String()
+	adjusted := exp + len(digits) - 1
+	if exp <= 0 && adjusted >= -6 {
+		if exp == 0 {
+			return sign + digits
+		}
+		if n := -exp; len(digits) <= n {
+			return sign + "0." + strings.Repeat("0", n-len(digits)) + digits
+		} else {
+			return sign + digits[:len(digits)-n] + "." + digits[len(digits)-n:]
+		}
+	}
+
+	s := digits[:1]
+	if len(digits) > 1 {
+		s += "." + digits[1:]
+	}
+	e := strconv.Itoa(adjusted)
+	if adjusted >= 0 {
+		e = "+" + e
+	}
+
+	return sign + s + "E" + e
+}
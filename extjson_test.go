@@ -0,0 +1,83 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestConvertExtJSON(t *testing.T) {
+	type testCase struct {
+		name      string
+		in        string
+		canonical string
+		relaxed   string
+	}
+	testCases := []testCase{
+		{name: "numbers", in: `[1,3000000000,1.5,-0.0]`, canonical: `[{"$numberInt":"1"},{"$numberLong":"3000000000"},{"$numberDouble":"1.5"},{"$numberDouble":"-0.0"}]`, relaxed: `[1,3000000000,1.5,-0.0]`},
+		{name: "wrapped numbers", in: `[{"$numberInt":"7"},{"$numberLong":"7"},{"$numberDouble":"7"},{"$numberDouble":"1e-7"}]`, canonical: `[{"$numberInt":"7"},{"$numberLong":"7"},{"$numberDouble":"7.0"},{"$numberDouble":"1.0E-7"}]`, relaxed: `[7,7,7.0,1.0E-7]`},
+		{name: "dates", in: `{"a":{"$date":"2012-12-24T21:15:30.501+09:00"},"b":{"$date":-62135596800000},"c":{"$date":{"$numberLong":"0"}}}`, canonical: `{"a":{"$date":{"$numberLong":"1356351330501"}},"b":{"$date":{"$numberLong":"-62135596800000"}},"c":{"$date":{"$numberLong":"0"}}}`, relaxed: `{"a":{"$date":"2012-12-24T12:15:30.501Z"},"b":{"$date":{"$numberLong":"-62135596800000"}},"c":{"$date":"1970-01-01T00:00:00Z"}}`},
+		{name: "uuid", in: `{"$uuid":"73ffd264-44b3-4c69-90e8-e7d1dfc035d4"}`, canonical: `{"$binary":{"base64":"c//SZESzTGmQ6OfR38A11A==","subType":"04"}}`, relaxed: `{"$binary":{"base64":"c//SZESzTGmQ6OfR38A11A==","subType":"04"}}`},
+		{name: "regular expression options", in: `{"$regularExpression":{"pattern":"a","options":"xmi"}}`, canonical: `{"$regularExpression":{"pattern":"a","options":"imx"}}`, relaxed: `{"$regularExpression":{"pattern":"a","options":"imx"}}`},
+		{name: "nested document", in: `{"a":{"b":[{"$oid":"56e1fc72e0c917e9c4714161"}]}}`, canonical: `{"a":{"b":[{"$oid":"56e1fc72e0c917e9c4714161"}]}}`, relaxed: `{"a":{"b":[{"$oid":"56e1fc72e0c917e9c4714161"}]}}`},
+		{name: "literals", in: `[null,true,"x"]`, canonical: `[null,true,"x"]`, relaxed: `[null,true,"x"]`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			canonical, err := jsonvalue.ConvertExtJSON(mustUnmarshal(t, testCase.in), jsonvalue.ExtJSONCanonical)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, canonical)), string(mustMarshal(t, mustUnmarshal(t, testCase.canonical))))
+
+			relaxed, err := jsonvalue.ConvertExtJSON(mustUnmarshal(t, testCase.in), jsonvalue.ExtJSONRelaxed)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, relaxed)), string(mustMarshal(t, mustUnmarshal(t, testCase.relaxed))))
+		})
+	}
+}
+
+func TestConvertExtJSON_Decimal128(t *testing.T) {
+	testCases := map[string]string{
+		"0":                                   "0",
+		"-0":                                  "-0",
+		"0.00":                                "0.00",
+		"1.23":                                "1.23",
+		"+12.0":                               "12.0",
+		"1E+3":                                "1E+3",
+		"1000":                                "1000",
+		"0.000001234":                         "0.000001234",
+		"0.0000001234":                        "1.234E-7",
+		"-.5":                                 "-0.5",
+		"1e-6176":                             "1E-6176",
+		"1E+6144":                             "1.000000000000000000000000000000000E+6144",
+		"0E+9999":                             "0E+6111",
+		"9999999999999999999999999999999999":  "9999999999999999999999999999999999",
+		"12345678901234567890123456789012340": "1.234567890123456789012345678901234E+34",
+		"Infinity":                            "Infinity",
+		"-inf":                                "-Infinity",
+		"NaN":                                 "NaN",
+	}
+	for in, want := range testCases {
+		t.Run(in, func(t *testing.T) {
+			got, err := jsonvalue.ConvertExtJSON(jsonvalue.Object(jsonvalue.Props{"$numberDecimal": jsonvalue.String(in)}), jsonvalue.ExtJSONCanonical)
+			equal(t, err, nil)
+			equal(t, got.ObjectGetElm("$numberDecimal").StringGet(), want)
+		})
+	}
+}
+
+func TestConvertExtJSON_Error(t *testing.T) {
+	testCases := map[string]string{
+		"unknown wrapper key":  `{"$oid":"56e1fc72e0c917e9c4714161","x":1}`,
+		"invalid decimal":      `{"$numberDecimal":"1.2.3"}`,
+		"decimal out of range": `{"$numberDecimal":"1E+6145"}`,
+		"decimal too precise":  `{"$numberDecimal":"1E-6177"}`,
+		"invalid date":         `{"$date":{"$numberLong":"x"}}`,
+		"nested invalid":       `[{"a":{"$numberLong":1}}]`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.ConvertExtJSON(mustUnmarshal(t, in), jsonvalue.ExtJSONCanonical)
+			IsNotNil(t, err)
+		})
+	}
+}
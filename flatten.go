@@ -0,0 +1,293 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// PathNotation is a notation of Paths in strings used by Flatten and Unflatten.
+// The root Path is represented as the empty string in every notation.
+type PathNotation int
+
+const (
+	// PathNotationDotted joins the keys with dots such as a.b.0, where member names consisting of digits are indices.
+	// Dots and backslashes in member names are escaped as \. and \\, member names consisting of digits are prefixed with \,
+	// and the empty member name is written as \e.
+	PathNotationDotted PathNotation = iota
+	// PathNotationPointer represents Paths as JSON Pointers such as /a/b/0, where tokens consisting of digits without leading zeros are read as indices.
+	// Member names which are read as indices cannot be represented in this notation.
+	PathNotationPointer
+	// PathNotationBracketed represents Paths such as a.b[0]["c d"], where member names which are not identifiers are quoted as JSON strings in brackets.
+	PathNotationBracketed
+)
+
+var bracketedIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*`)
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isPointerIndex returns true if a token of a JSON Pointer is read as an index in PathNotationPointer.
+func isPointerIndex(token string) bool {
+	return isDigits(token) && (token == "0" || token[0] != '0')
+}
+
+// Format formats a Path in the notation.
+func (n PathNotation) Format(path Path) string {
+	var b strings.Builder
+	for i, key := range path {
+		switch n {
+		case PathNotationDotted:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			switch name := key.String(); {
+			case key.IsIndex():
+				b.WriteString(name)
+			case name == "":
+				b.WriteString(`\e`)
+			case isDigits(name):
+				b.WriteString(`\` + name)
+			default:
+				b.WriteString(strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(name))
+			}
+		case PathNotationPointer:
+			b.WriteString(path[i : i+1].Pointer())
+		case PathNotationBracketed:
+			switch name := key.String(); {
+			case key.IsIndex():
+				b.WriteString("[" + name + "]")
+			case bracketedIdentifierPattern.FindString(name) == name && name != "":
+				if i > 0 {
+					b.WriteByte('.')
+				}
+				b.WriteString(name)
+			default:
+				q, _ := json.Marshal(name)
+				b.WriteString("[" + string(q) + "]")
+			}
+		default:
+			assert.Unexpected(`invalid PathNotation: %v`, n)
+		}
+	}
+
+	return b.String()
+}
+
+// Parse parses a string in the notation into a Path.
+func (n PathNotation) Parse(s string) (Path, error) {
+	if s == "" {
+		return Path{}, nil
+	}
+
+	var path Path
+	var err error
+	switch n {
+	case PathNotationDotted:
+		path, err = parseDottedPath(s)
+	case PathNotationPointer:
+		path, err = parsePointerPath(s)
+	case PathNotationBracketed:
+		path, err = parseBracketedPath(s)
+	default:
+		return assert.Unexpected2[Path, error](`invalid PathNotation: %v`, n)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse Path %q: %w`, s, err)
+	}
+
+	return path, nil
+}
+
+func parseDottedPath(s string) (Path, error) {
+	segments := []string{""}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return nil, fmt.Errorf(`incomplete escape at the end`)
+			}
+			segments[len(segments)-1] += s[i : i+2]
+			i++
+		case '.':
+			segments = append(segments, "")
+		default:
+			segments[len(segments)-1] += s[i : i+1]
+		}
+	}
+
+	path := Path{}
+	for _, segment := range segments {
+		switch {
+		case segment == `\e`:
+			path = path.Append(KeyName(""))
+			continue
+		case isDigits(segment):
+			i, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, err
+			}
+			path = path.Append(KeyIndex(i))
+			continue
+		case strings.HasPrefix(segment, `\`) && isDigits(segment[1:]):
+			path = path.Append(KeyName(segment[1:]))
+			continue
+		}
+		var name strings.Builder
+		for i := 0; i < len(segment); i++ {
+			if segment[i] == '\\' {
+				i++
+				if segment[i] != '.' && segment[i] != '\\' {
+					return nil, fmt.Errorf(`invalid escape %q`, segment[i-1:i+1])
+				}
+			}
+			name.WriteByte(segment[i])
+		}
+		path = path.Append(KeyName(name.String()))
+	}
+
+	return path, nil
+}
+
+func parsePointerPath(s string) (Path, error) {
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf(`JSON Pointer must start with "/"`)
+	}
+	path := Path{}
+	for _, token := range strings.Split(s[1:], "/") {
+		if isPointerIndex(token) {
+			i, err := strconv.Atoi(token)
+			if err != nil {
+				return nil, err
+			}
+			path = path.Append(KeyIndex(i))
+			continue
+		}
+		if strings.Contains(strings.NewReplacer("~0", "", "~1", "").Replace(token), "~") {
+			return nil, fmt.Errorf(`invalid escape in %q`, token)
+		}
+		path = path.Append(KeyName(strings.NewReplacer("~1", "/", "~0", "~").Replace(token)))
+	}
+
+	return path, nil
+}
+
+func parseBracketedPath(s string) (Path, error) {
+	path := Path{}
+	for pos := 0; pos < len(s); {
+		switch {
+		case s[pos] == '[' && pos+1 < len(s) && s[pos+1] == '"':
+			end := pos + 2
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end+1 >= len(s) || s[end+1] != ']' {
+				return nil, fmt.Errorf(`unterminated bracket at %d`, pos)
+			}
+			var name string
+			if err := json.Unmarshal([]byte(s[pos+1:end+1]), &name); err != nil {
+				return nil, fmt.Errorf(`invalid quoted name at %d: %w`, pos, err)
+			}
+			path = path.Append(KeyName(name))
+			pos = end + 2
+		case s[pos] == '[':
+			end := strings.IndexByte(s[pos:], ']')
+			if end < 0 || !isDigits(s[pos+1:pos+end]) {
+				return nil, fmt.Errorf(`invalid index at %d`, pos)
+			}
+			i, err := strconv.Atoi(s[pos+1 : pos+end])
+			if err != nil {
+				return nil, fmt.Errorf(`invalid index at %d: %w`, pos, err)
+			}
+			path = path.Append(KeyIndex(i))
+			pos += end + 1
+		case s[pos] == '.' && len(path) > 0 || pos == 0:
+			if pos > 0 {
+				pos++
+			}
+			name := bracketedIdentifierPattern.FindString(s[pos:])
+			if name == "" {
+				return nil, fmt.Errorf(`identifier expected at %d`, pos)
+			}
+			path = path.Append(KeyName(name))
+			pos += len(name)
+		default:
+			return nil, fmt.Errorf(`unexpected character %q at %d`, s[pos], pos)
+		}
+	}
+
+	return path, nil
+}
+
+// Flatten returns the leaves of a JSON value v keyed by their Paths formatted in the notation.
+// The leaves are the scalars and the empty JSON objects and arrays.
+// An error is returned if a member name cannot be represented in the notation, e.g. "0" in PathNotationPointer.
+func Flatten(v Value, notation PathNotation) (map[string]Value, error) {
+	leaves := map[string]Value{}
+	err := Walk(v, func(path Path, val Value) error {
+		if notation == PathNotationPointer && len(path) > 0 {
+			if key := path[len(path)-1]; !key.IsIndex() && isPointerIndex(key.String()) {
+				return fmt.Errorf(`member name %q at %q is not distinguishable from an array index`, key.String(), path.Pointer())
+			}
+		}
+		if val.Type() == TypeObject && val.ObjectLen() > 0 || val.Type() == TypeArray && val.ArrayLen() > 0 {
+			return nil
+		}
+		leaves[notation.Format(path)] = val.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`fail to flatten: %w`, err)
+	}
+
+	return leaves, nil
+}
+
+// Unflatten returns a new JSON value reconstructed from leaves keyed by their Paths in the notation, which reverses Flatten.
+// Missing elements of JSON arrays are filled with null, and Unflatten returns an empty JSON object if leaves is empty.
+// An error is returned if a key cannot be parsed or the Paths conflict with each other.
+func Unflatten(leaves map[string]Value, notation PathNotation) (Value, error) {
+	type leaf struct {
+		path Path
+		val  Value
+	}
+	sorted := []leaf{}
+	for key, val := range leaves {
+		path, err := notation.Parse(key)
+		if err != nil {
+			return nil, fmt.Errorf(`fail to unflatten: %w`, err)
+		}
+		sorted = append(sorted, leaf{path: path, val: val})
+	}
+	slices.SortFunc(sorted, func(a, b leaf) bool { return a.path.Compare(b.path) < 0 })
+
+	switch {
+	case len(sorted) == 0:
+		return Object(), nil
+	case len(sorted[0].path) == 0:
+		if len(sorted) > 1 {
+			return nil, fmt.Errorf(`fail to unflatten: root JSON value conflicts with %q`, notation.Format(sorted[1].path))
+		}
+		return sorted[0].val.Clone(), nil
+	}
+
+	root := Object()
+	if sorted[0].path[0].IsIndex() {
+		root = Array()
+	}
+	for _, l := range sorted {
+		if err := insertAt(root, l.path, l.val.Clone(), false); err != nil {
+			return nil, fmt.Errorf(`fail to unflatten: %w`, err)
+		}
+	}
+
+	return root, nil
+}
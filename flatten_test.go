@@ -0,0 +1,166 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestPathNotation_Format(t *testing.T) {
+	path := jsonvalue.Path{jsonvalue.KeyName("a.b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("0"), jsonvalue.KeyName(""), jsonvalue.KeyName(`c\d/~`), jsonvalue.KeyName("e_1")}
+	type testCase struct {
+		notation jsonvalue.PathNotation
+		want     string
+	}
+	testCases := []testCase{
+		{notation: jsonvalue.PathNotationDotted, want: `a\.b.0.\0.\e.c\\d/~.e_1`},
+		{notation: jsonvalue.PathNotationPointer, want: `/a.b/0/0//c\d~1~0/e_1`},
+		{notation: jsonvalue.PathNotationBracketed, want: `["a.b"][0]["0"][""]["c\\d/~"].e_1`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.want, func(t *testing.T) {
+			equal(t, testCase.notation.Format(path), testCase.want)
+			equal(t, testCase.notation.Format(jsonvalue.Path{}), "")
+		})
+	}
+}
+
+func TestPathNotation_Parse(t *testing.T) {
+	type testCase struct {
+		notation jsonvalue.PathNotation
+		in       string
+		want     jsonvalue.Path
+	}
+	testCases := []testCase{
+		{notation: jsonvalue.PathNotationDotted, in: ``, want: jsonvalue.Path{}},
+		{notation: jsonvalue.PathNotationDotted, in: `a\.b.0.\0.\e.c\\d/~.e_1`, want: jsonvalue.Path{jsonvalue.KeyName("a.b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("0"), jsonvalue.KeyName(""), jsonvalue.KeyName(`c\d/~`), jsonvalue.KeyName("e_1")}},
+		{notation: jsonvalue.PathNotationDotted, in: `a..10`, want: jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName(""), jsonvalue.KeyIndex(10)}},
+		{notation: jsonvalue.PathNotationPointer, in: `/a.b/0/00//c\d~1~0`, want: jsonvalue.Path{jsonvalue.KeyName("a.b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("00"), jsonvalue.KeyName(""), jsonvalue.KeyName(`c\d/~`)}},
+		{notation: jsonvalue.PathNotationBracketed, in: `a.b[0]["c]\"d"][12].$e`, want: jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName(`c]"d`), jsonvalue.KeyIndex(12), jsonvalue.KeyName("$e")}},
+		{notation: jsonvalue.PathNotationBracketed, in: `[1].a`, want: jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("a")}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.in, func(t *testing.T) {
+			got, err := testCase.notation.Parse(testCase.in)
+			equal(t, err, nil)
+			equal(t, got.Equals(testCase.want), true)
+		})
+	}
+}
+
+func TestPathNotation_Parse_Error(t *testing.T) {
+	type testCase struct {
+		notation jsonvalue.PathNotation
+		in       string
+	}
+	testCases := []testCase{
+		{notation: jsonvalue.PathNotationDotted, in: `a\`},
+		{notation: jsonvalue.PathNotationDotted, in: `a\b`},
+		{notation: jsonvalue.PathNotationPointer, in: `a/b`},
+		{notation: jsonvalue.PathNotationPointer, in: `/a~2`},
+		{notation: jsonvalue.PathNotationBracketed, in: `a[`},
+		{notation: jsonvalue.PathNotationBracketed, in: `a[x]`},
+		{notation: jsonvalue.PathNotationBracketed, in: `a["b"`},
+		{notation: jsonvalue.PathNotationBracketed, in: `a..b`},
+		{notation: jsonvalue.PathNotationBracketed, in: `a b`},
+		{notation: jsonvalue.PathNotationBracketed, in: `1a`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.in, func(t *testing.T) {
+			_, err := testCase.notation.Parse(testCase.in)
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	in := `{"db":{"host":"x","ports":[1,2]},"a.b":{"0":true},"empty":{},"list":[],"":null}`
+	type testCase struct {
+		notation jsonvalue.PathNotation
+		in       string
+		want     map[string]string
+	}
+	testCases := []testCase{
+		{notation: jsonvalue.PathNotationDotted, in: in, want: map[string]string{`db.host`: `"x"`, `db.ports.0`: `1`, `db.ports.1`: `2`, `a\.b.\0`: `true`, `empty`: `{}`, `list`: `[]`, `\e`: `null`}},
+		{notation: jsonvalue.PathNotationPointer, in: `{"db":{"host":"x","ports":[1,2]},"a.b":{"00":true},"empty":{},"list":[],"":null}`, want: map[string]string{`/db/host`: `"x"`, `/db/ports/0`: `1`, `/db/ports/1`: `2`, `/a.b/00`: `true`, `/empty`: `{}`, `/list`: `[]`, `/`: `null`}},
+		{notation: jsonvalue.PathNotationBracketed, in: in, want: map[string]string{`db.host`: `"x"`, `db.ports[0]`: `1`, `db.ports[1]`: `2`, `["a.b"]["0"]`: `true`, `empty`: `{}`, `list`: `[]`, `[""]`: `null`}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.notation.Format(jsonvalue.Path{jsonvalue.KeyName("db"), jsonvalue.KeyIndex(0)}), func(t *testing.T) {
+			v := mustUnmarshal(t, testCase.in)
+			got, err := jsonvalue.Flatten(v, testCase.notation)
+			equal(t, err, nil)
+			equal(t, len(got), len(testCase.want))
+			for key, want := range testCase.want {
+				equal(t, string(mustMarshal(t, got[key])), want)
+			}
+
+			back, err := jsonvalue.Unflatten(got, testCase.notation)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(back, v), true)
+		})
+	}
+	t.Run("scalar", func(t *testing.T) {
+		got, err := jsonvalue.Flatten(jsonvalue.Number(1), jsonvalue.PathNotationDotted)
+		equal(t, err, nil)
+		equal(t, len(got), 1)
+		equal(t, string(mustMarshal(t, got[""])), `1`)
+	})
+}
+
+func TestFlatten_Error(t *testing.T) {
+	testCases := map[string]string{
+		"digit member name":          `{"1":""}`,
+		"zero member name":           `{"a":{"0":1}}`,
+		"digit member name in array": `[{"10":null}]`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.Flatten(mustUnmarshal(t, in), jsonvalue.PathNotationPointer)
+			IsNotNil(t, err)
+
+			_, err = jsonvalue.Flatten(mustUnmarshal(t, in), jsonvalue.PathNotationBracketed)
+			equal(t, err, nil)
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	type testCase struct {
+		name     string
+		notation jsonvalue.PathNotation
+		in       map[string]jsonvalue.Value
+		want     string
+	}
+	testCases := []testCase{
+		{name: "empty", in: map[string]jsonvalue.Value{}, want: `{}`},
+		{name: "root", in: map[string]jsonvalue.Value{"": jsonvalue.String("x")}, want: `"x"`},
+		{name: "root array", notation: jsonvalue.PathNotationBracketed, in: map[string]jsonvalue.Value{"[1].a": jsonvalue.Number(1), "[0]": jsonvalue.Number(0)}, want: `[0,{"a":1}]`},
+		{name: "sparse array", in: map[string]jsonvalue.Value{"a.2": jsonvalue.Number(2)}, want: `{"a":[null,null,2]}`},
+		{name: "pointer", notation: jsonvalue.PathNotationPointer, in: map[string]jsonvalue.Value{"/db/hosts/1": jsonvalue.String("b"), "/db/hosts/0": jsonvalue.String("a"), "/db/name": jsonvalue.String("x")}, want: `{"db":{"hosts":["a","b"],"name":"x"}}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.Unflatten(testCase.in, testCase.notation)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, got)), string(mustMarshal(t, mustUnmarshal(t, testCase.want))))
+		})
+	}
+}
+
+func TestUnflatten_Error(t *testing.T) {
+	testCases := map[string]map[string]jsonvalue.Value{
+		"root and leaf":     {"": jsonvalue.Null(), "a": jsonvalue.Null()},
+		"leaf and nested":   {"a": jsonvalue.Null(), "a.b": jsonvalue.Null()},
+		"object and array":  {"a.b": jsonvalue.Null(), "a.0": jsonvalue.Null()},
+		"root object/array": {"a": jsonvalue.Null(), "0": jsonvalue.Null()},
+		"invalid key":       {`a\`: jsonvalue.Null()},
+		"too large index":   {"a.99999999999": jsonvalue.Null()},
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.Unflatten(in, jsonvalue.PathNotationDotted)
+			IsNotNil(t, err)
+		})
+	}
+}
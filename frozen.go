@@ -0,0 +1,259 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// Frozen models an immutable JSON-structured data.
+// A Frozen value never changes after creation, so it can be shared between goroutines without synchronization.
+// Updating methods return a new Frozen value sharing unchanged subtrees with the original one.
+type Frozen interface {
+	json.Marshaler
+	// Type returns JSON type.
+	Type() Type
+	// Thaw returns a mutable deep copy of this JSON value.
+	Thaw() Value
+	// NumberGet returns this JSON value as a number.
+	NumberGet() json.Number
+	// StringGet returns this JSON value as a string.
+	StringGet() string
+	// BooleanGet returns this JSON value as a boolean.
+	BooleanGet() bool
+	// ObjectKeys returns keys of this JSON value as a object.
+	ObjectKeys() []string
+	// ObjectHasElm returns whether this JSON value as a object has the key.
+	ObjectHasElm(key string) bool
+	// ObjectGetElm returns a JSON value associated the key.
+	ObjectGetElm(key string) Frozen
+	// ObjectLen returns the number of keys.
+	ObjectLen() int
+	// ArrayGetElm returns a JSON value indexed.
+	ArrayGetElm(index int) Frozen
+	// ArrayLen returns the number of elements.
+	ArrayLen() int
+	// Get returns the JSON value specified by the Path in the same way as Find.
+	Get(path Path) (Frozen, bool)
+	// With returns a new JSON value in which the JSON value specified by the Path is replaced with v.
+	// The parent of the Path must exist, and the last key may add a member to an object or an element to the back of an array.
+	With(path Path, v Frozen) Frozen
+	// Without returns a new JSON value in which the JSON value specified by the Path is removed.
+	// Removing an array element shifts the following elements, which copies the whole array.
+	Without(path Path) Frozen
+}
+
+// frozen implements Frozen
+type frozen struct {
+	typ        Type
+	numberVal  json.Number
+	booleanVal bool
+	stringVal  string
+	objectVal  hamt
+	arrayVal   vector
+}
+
+// Freeze returns an immutable deep copy of a JSON value v.
+func Freeze(v Value) Frozen {
+	switch v.Type() {
+	case TypeNull:
+		return &frozen{typ: TypeNull}
+	case TypeBoolean:
+		return &frozen{typ: TypeBoolean, booleanVal: v.BooleanGet()}
+	case TypeNumber:
+		return &frozen{typ: TypeNumber, numberVal: v.NumberGet()}
+	case TypeString:
+		return &frozen{typ: TypeString, stringVal: v.StringGet()}
+	case TypeArray:
+		a := newVector()
+		for i := 0; i < v.ArrayLen(); i++ {
+			a = a.push(Freeze(v.ArrayGetElm(i)))
+		}
+		return &frozen{typ: TypeArray, arrayVal: a}
+	case TypeObject:
+		o := hamt{}
+		for _, k := range v.ObjectKeys() {
+			o = o.set(k, Freeze(v.ObjectGetElm(k)))
+		}
+		return &frozen{typ: TypeObject, objectVal: o}
+	default:
+		return assert.Unexpected1[Frozen](`invalid JsonType: %v`, v.Type())
+	}
+}
+
+func (f *frozen) MarshalJSON() ([]byte, error) {
+	return f.Thaw().MarshalJSON()
+}
+
+func (f *frozen) Type() Type {
+	return f.typ
+}
+
+func (f *frozen) Thaw() Value {
+	switch f.Type() {
+	case TypeNull:
+		return Null()
+	case TypeBoolean:
+		return Boolean(f.booleanVal)
+	case TypeNumber:
+		return Number(f.numberVal)
+	case TypeString:
+		return String(f.stringVal)
+	case TypeArray:
+		a := Array()
+		for i := 0; i < f.arrayVal.len(); i++ {
+			a.ArrayAddElm(f.arrayVal.get(i).Thaw())
+		}
+		return a
+	case TypeObject:
+		o := Object()
+		f.objectVal.each(func(key string, val Frozen) {
+			o.ObjectSetElm(key, val.Thaw())
+		})
+		return o
+	default:
+		return assert.Unexpected1[Value](`invalid JsonType: %v`, f.Type())
+	}
+}
+
+func (f *frozen) NumberGet() json.Number {
+	assert.Params(f.Type() == TypeNumber, "Frozen must be JSON number")
+
+	return f.numberVal
+}
+func (f *frozen) StringGet() string {
+	assert.Params(f.Type() == TypeString, "Frozen must be JSON string")
+
+	return f.stringVal
+}
+func (f *frozen) BooleanGet() bool {
+	assert.Params(f.Type() == TypeBoolean, "Frozen must be JSON boolean")
+
+	return f.booleanVal
+}
+func (f *frozen) ObjectKeys() []string {
+	assert.Params(f.Type() == TypeObject, "Frozen must be JSON object")
+
+	keys := []string{}
+	f.objectVal.each(func(key string, _ Frozen) {
+		keys = append(keys, key)
+	})
+
+	return keys
+}
+func (f *frozen) ObjectHasElm(key string) bool {
+	assert.Params(f.Type() == TypeObject, "Frozen must be JSON object")
+
+	_, ok := f.objectVal.get(key)
+
+	return ok
+}
+func (f *frozen) ObjectGetElm(key string) Frozen {
+	assert.Params(f.Type() == TypeObject, "Frozen must be JSON object")
+
+	val, ok := f.objectVal.get(key)
+	assert.Params(ok, "Frozen object must have key: %v", key)
+
+	return val
+}
+func (f *frozen) ObjectLen() int {
+	assert.Params(f.Type() == TypeObject, "Frozen must be JSON object")
+
+	return f.objectVal.size
+}
+func (f *frozen) ArrayGetElm(index int) Frozen {
+	assert.Params(f.Type() == TypeArray, "Frozen must be JSON array")
+	assert.Params(0 <= index && index < f.ArrayLen(), "index must be in [0, %d)", f.ArrayLen())
+
+	return f.arrayVal.get(index)
+}
+func (f *frozen) ArrayLen() int {
+	assert.Params(f.Type() == TypeArray, "Frozen must be JSON array")
+
+	return f.arrayVal.len()
+}
+
+func (f *frozen) Get(path Path) (Frozen, bool) {
+	var v Frozen = f
+	for _, key := range path {
+		switch {
+		case key.IsIndex() && v.Type() == TypeArray:
+			if key.Int() >= v.ArrayLen() {
+				return nil, false
+			}
+			v = v.ArrayGetElm(key.Int())
+		case !key.IsIndex() && v.Type() == TypeObject:
+			if !v.ObjectHasElm(key.String()) {
+				return nil, false
+			}
+			v = v.ObjectGetElm(key.String())
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func (f *frozen) With(path Path, v Frozen) Frozen {
+	assert.Params(v != nil, "Frozen must not be nil")
+
+	if path.Len() == 0 {
+		return v
+	}
+
+	key := path.Get(0)
+	switch {
+	case key.IsIndex() && f.Type() == TypeArray:
+		index := key.Int()
+		assert.Params(index <= f.ArrayLen(), "index must be in [0, %d]", f.ArrayLen())
+		if index == f.ArrayLen() {
+			assert.Params(path.Len() == 1, "Path must exist: %v", path)
+			return &frozen{typ: TypeArray, arrayVal: f.arrayVal.push(v)}
+		}
+		elm := f.arrayVal.get(index).With(path.Slice(1, path.Len()), v)
+		return &frozen{typ: TypeArray, arrayVal: f.arrayVal.set(index, elm)}
+	case !key.IsIndex() && f.Type() == TypeObject:
+		name := key.String()
+		if !f.ObjectHasElm(name) {
+			assert.Params(path.Len() == 1, "Path must exist: %v", path)
+			return &frozen{typ: TypeObject, objectVal: f.objectVal.set(name, v)}
+		}
+		elm := f.ObjectGetElm(name).With(path.Slice(1, path.Len()), v)
+		return &frozen{typ: TypeObject, objectVal: f.objectVal.set(name, elm)}
+	default:
+		return assert.Unexpected1[Frozen]("Key %v is not applicable to JSON %v", key, f.Type())
+	}
+}
+
+func (f *frozen) Without(path Path) Frozen {
+	assert.Params(path.Len() > 0, "Path must not be empty")
+
+	key := path.Get(0)
+	switch {
+	case key.IsIndex() && f.Type() == TypeArray:
+		index := key.Int()
+		assert.Params(index < f.ArrayLen(), "index must be in [0, %d)", f.ArrayLen())
+		if path.Len() > 1 {
+			elm := f.arrayVal.get(index).Without(path.Slice(1, path.Len()))
+			return &frozen{typ: TypeArray, arrayVal: f.arrayVal.set(index, elm)}
+		}
+		a := newVector()
+		for i := 0; i < f.ArrayLen(); i++ {
+			if i != index {
+				a = a.push(f.arrayVal.get(i))
+			}
+		}
+		return &frozen{typ: TypeArray, arrayVal: a}
+	case !key.IsIndex() && f.Type() == TypeObject:
+		name := key.String()
+		assert.Params(f.ObjectHasElm(name), "Frozen object must have key: %v", name)
+		if path.Len() > 1 {
+			elm := f.ObjectGetElm(name).Without(path.Slice(1, path.Len()))
+			return &frozen{typ: TypeObject, objectVal: f.objectVal.set(name, elm)}
+		}
+		return &frozen{typ: TypeObject, objectVal: f.objectVal.del(name)}
+	default:
+		return assert.Unexpected1[Frozen]("Key %v is not applicable to JSON %v", key, f.Type())
+	}
+}
@@ -0,0 +1,196 @@
+package jsonvalue
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+// hamt is a persistent hash array mapped trie from member names to Frozen values.
+// Every update returns a new hamt sharing unchanged nodes with the original one.
+type hamt struct {
+	root *hamtNode
+	size int
+}
+
+const (
+	hamtBits  = 5
+	hamtWidth = 1 << hamtBits
+	hamtMask  = hamtWidth - 1
+	hamtDepth = 64 / hamtBits
+)
+
+var hamtSeed = maphash.MakeSeed()
+
+// hamtNode is either a bitmap indexed node or, below hamtDepth levels, a collision node holding entries linearly.
+type hamtNode struct {
+	bitmap  uint32
+	entries []hamtEntry
+}
+
+// hamtEntry holds either a member or a child node.
+type hamtEntry struct {
+	key   string
+	val   Frozen
+	child *hamtNode
+}
+
+func hamtHash(key string) uint64 {
+	return maphash.String(hamtSeed, key)
+}
+
+func (m hamt) get(key string) (Frozen, bool) {
+	if m.root == nil {
+		return nil, false
+	}
+
+	return m.root.get(key, hamtHash(key), 0)
+}
+
+func (m hamt) set(key string, val Frozen) hamt {
+	root := m.root
+	if root == nil {
+		root = &hamtNode{}
+	}
+	root, added := root.set(key, hamtHash(key), 0, val)
+	if added {
+		return hamt{root: root, size: m.size + 1}
+	}
+
+	return hamt{root: root, size: m.size}
+}
+
+func (m hamt) del(key string) hamt {
+	if m.root == nil {
+		return m
+	}
+	root, removed := m.root.del(key, hamtHash(key), 0)
+	if !removed {
+		return m
+	}
+
+	return hamt{root: root, size: m.size - 1}
+}
+
+func (m hamt) each(fn func(key string, val Frozen)) {
+	if m.root != nil {
+		m.root.each(fn)
+	}
+}
+
+func (n *hamtNode) position(hash uint64, depth int) (bit uint32, pos int) {
+	bit = 1 << ((hash >> (depth * hamtBits)) & hamtMask)
+
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode) get(key string, hash uint64, depth int) (Frozen, bool) {
+	if depth >= hamtDepth {
+		for _, e := range n.entries {
+			if e.key == key {
+				return e.val, true
+			}
+		}
+		return nil, false
+	}
+
+	bit, pos := n.position(hash, depth)
+	if n.bitmap&bit == 0 {
+		return nil, false
+	}
+	e := n.entries[pos]
+	if e.child != nil {
+		return e.child.get(key, hash, depth+1)
+	}
+	if e.key == key {
+		return e.val, true
+	}
+
+	return nil, false
+}
+
+func (n *hamtNode) set(key string, hash uint64, depth int, val Frozen) (*hamtNode, bool) {
+	if depth >= hamtDepth {
+		entries := append([]hamtEntry{}, n.entries...)
+		for i, e := range entries {
+			if e.key == key {
+				entries[i] = hamtEntry{key: key, val: val}
+				return &hamtNode{entries: entries}, false
+			}
+		}
+		return &hamtNode{entries: append(entries, hamtEntry{key: key, val: val})}, true
+	}
+
+	bit, pos := n.position(hash, depth)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry, 0, len(n.entries)+1)
+		entries = append(entries, n.entries[:pos]...)
+		entries = append(entries, hamtEntry{key: key, val: val})
+		entries = append(entries, n.entries[pos:]...)
+		return &hamtNode{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+
+	entries := append([]hamtEntry{}, n.entries...)
+	e := entries[pos]
+	switch {
+	case e.child != nil:
+		child, added := e.child.set(key, hash, depth+1, val)
+		entries[pos] = hamtEntry{child: child}
+		return &hamtNode{bitmap: n.bitmap, entries: entries}, added
+	case e.key == key:
+		entries[pos] = hamtEntry{key: key, val: val}
+		return &hamtNode{bitmap: n.bitmap, entries: entries}, false
+	default:
+		child, _ := (&hamtNode{}).set(e.key, hamtHash(e.key), depth+1, e.val)
+		child, _ = child.set(key, hash, depth+1, val)
+		entries[pos] = hamtEntry{child: child}
+		return &hamtNode{bitmap: n.bitmap, entries: entries}, true
+	}
+}
+
+func (n *hamtNode) del(key string, hash uint64, depth int) (*hamtNode, bool) {
+	if depth >= hamtDepth {
+		for i, e := range n.entries {
+			if e.key == key {
+				entries := append(append([]hamtEntry{}, n.entries[:i]...), n.entries[i+1:]...)
+				return &hamtNode{entries: entries}, true
+			}
+		}
+		return n, false
+	}
+
+	bit, pos := n.position(hash, depth)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	e := n.entries[pos]
+	if e.child != nil {
+		child, removed := e.child.del(key, hash, depth+1)
+		if !removed {
+			return n, false
+		}
+		entries := append([]hamtEntry{}, n.entries...)
+		if len(child.entries) == 0 {
+			entries = append(entries[:pos], entries[pos+1:]...)
+			return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}, true
+		}
+		entries[pos] = hamtEntry{child: child}
+		return &hamtNode{bitmap: n.bitmap, entries: entries}, true
+	}
+	if e.key != key {
+		return n, false
+	}
+	entries := append(append([]hamtEntry{}, n.entries[:pos]...), n.entries[pos+1:]...)
+
+	return &hamtNode{bitmap: n.bitmap &^ bit, entries: entries}, true
+}
+
+func (n *hamtNode) each(fn func(key string, val Frozen)) {
+	for _, e := range n.entries {
+		if e.child != nil {
+			e.child.each(fn)
+		} else {
+			fn(e.key, e.val)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package jsonvalue_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestFreeze(t *testing.T) {
+	v := jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Null(),
+		"b": jsonvalue.Number(123),
+		"c": jsonvalue.String("abc"),
+		"d": jsonvalue.Boolean(true),
+		"e": jsonvalue.Object(jsonvalue.Props{"x": jsonvalue.Null()}),
+		"f": jsonvalue.Array(jsonvalue.Null(), jsonvalue.Number(1)),
+	})
+	f := jsonvalue.Freeze(v)
+	v.ObjectSetElm("a", jsonvalue.Number(1))
+
+	equal(t, f.Type(), jsonvalue.TypeObject)
+	equal(t, f.ObjectLen(), 6)
+	equal(t, f.ObjectGetElm("a").Type(), jsonvalue.TypeNull)
+	equal(t, f.ObjectGetElm("b").NumberGet().String(), "123")
+	equal(t, f.ObjectGetElm("c").StringGet(), "abc")
+	equal(t, f.ObjectGetElm("d").BooleanGet(), true)
+	equal(t, f.ObjectGetElm("e").ObjectHasElm("x"), true)
+	equal(t, f.ObjectGetElm("f").ArrayLen(), 2)
+	equal(t, len(f.ObjectKeys()), 6)
+
+	b, err := f.MarshalJSON()
+	equal(t, err, nil)
+	equal(t, string(b), `{"a":null,"b":123,"c":"abc","d":true,"e":{"x":null},"f":[null,1]}`)
+}
+
+func TestFrozen_Thaw(t *testing.T) {
+	f := jsonvalue.Freeze(jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Array(jsonvalue.Number(1)),
+	}))
+	v := f.Thaw()
+	v.ObjectGetElm("a").ArrayAddElm(jsonvalue.Number(2))
+
+	equal(t, v.ObjectGetElm("a").ArrayLen(), 2)
+	equal(t, f.ObjectGetElm("a").ArrayLen(), 1)
+}
+
+func TestFrozen_Get(t *testing.T) {
+	f := jsonvalue.Freeze(jsonvalue.Object(jsonvalue.Props{
+		"0": jsonvalue.Array(jsonvalue.Null(), jsonvalue.Boolean(true)),
+	}))
+	t.Run("found", func(t *testing.T) {
+		a, ok := f.Get(jsonvalue.Path{jsonvalue.KeyName("0"), jsonvalue.KeyIndex(1)})
+		equal(t, ok, true)
+		equal(t, a.BooleanGet(), true)
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, ok := f.Get(jsonvalue.Path{jsonvalue.KeyIndex(0)})
+		equal(t, ok, false)
+	})
+}
+
+func TestFrozen_With(t *testing.T) {
+	f := jsonvalue.Freeze(jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Object(jsonvalue.Props{"x": jsonvalue.Null()}),
+		"b": jsonvalue.Array(jsonvalue.Null()),
+	}))
+	t.Run("root", func(t *testing.T) {
+		g := f.With(jsonvalue.Path{}, jsonvalue.Freeze(jsonvalue.Null()))
+		equal(t, g.Type(), jsonvalue.TypeNull)
+	})
+	t.Run("replace member", func(t *testing.T) {
+		g := f.With(jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("x")}, jsonvalue.Freeze(jsonvalue.Number(1)))
+		equal(t, g.ObjectGetElm("a").ObjectGetElm("x").Type(), jsonvalue.TypeNumber)
+		equal(t, f.ObjectGetElm("a").ObjectGetElm("x").Type(), jsonvalue.TypeNull)
+		equal(t, g.ObjectGetElm("b"), f.ObjectGetElm("b"))
+	})
+	t.Run("add member", func(t *testing.T) {
+		g := f.With(jsonvalue.Path{jsonvalue.KeyName("c")}, jsonvalue.Freeze(jsonvalue.Number(1)))
+		equal(t, g.ObjectLen(), 3)
+		equal(t, f.ObjectLen(), 2)
+	})
+	t.Run("replace element", func(t *testing.T) {
+		g := f.With(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)}, jsonvalue.Freeze(jsonvalue.Number(1)))
+		equal(t, g.ObjectGetElm("b").ArrayGetElm(0).Type(), jsonvalue.TypeNumber)
+		equal(t, f.ObjectGetElm("b").ArrayGetElm(0).Type(), jsonvalue.TypeNull)
+		equal(t, g.ObjectGetElm("a"), f.ObjectGetElm("a"))
+	})
+	t.Run("add element", func(t *testing.T) {
+		g := f.With(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(1)}, jsonvalue.Freeze(jsonvalue.Number(1)))
+		equal(t, g.ObjectGetElm("b").ArrayLen(), 2)
+		equal(t, f.ObjectGetElm("b").ArrayLen(), 1)
+	})
+}
+
+func TestFrozen_Without(t *testing.T) {
+	f := jsonvalue.Freeze(jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Object(jsonvalue.Props{"x": jsonvalue.Null()}),
+		"b": jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1), jsonvalue.Number(2)),
+	}))
+	t.Run("member", func(t *testing.T) {
+		g := f.Without(jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("x")})
+		equal(t, g.ObjectGetElm("a").ObjectLen(), 0)
+		equal(t, f.ObjectGetElm("a").ObjectLen(), 1)
+	})
+	t.Run("element", func(t *testing.T) {
+		g := f.Without(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(1)})
+		b, _ := g.ObjectGetElm("b").MarshalJSON()
+		equal(t, string(b), `[0,2]`)
+		equal(t, f.ObjectGetElm("b").ArrayLen(), 3)
+	})
+}
+
+func TestFrozen_Large(t *testing.T) {
+	n := 5000
+	t.Run("array", func(t *testing.T) {
+		f := jsonvalue.Freeze(jsonvalue.Array())
+		snapshots := []jsonvalue.Frozen{}
+		for i := 0; i < n; i++ {
+			f = f.With(jsonvalue.Path{jsonvalue.KeyIndex(i)}, jsonvalue.Freeze(jsonvalue.Number(i)))
+			snapshots = append(snapshots, f)
+		}
+		for i := 0; i < n; i += 7 {
+			f = f.With(jsonvalue.Path{jsonvalue.KeyIndex(i)}, jsonvalue.Freeze(jsonvalue.Number(-i)))
+		}
+		equal(t, f.ArrayLen(), n)
+		for i := 0; i < n; i++ {
+			want := i
+			if i%7 == 0 {
+				want = -i
+			}
+			equal(t, f.ArrayGetElm(i).NumberGet().String(), strconv.Itoa(want))
+		}
+		for i, s := range snapshots {
+			equal(t, s.ArrayLen(), i+1)
+			equal(t, s.ArrayGetElm(i).NumberGet().String(), strconv.Itoa(i))
+		}
+	})
+	t.Run("object", func(t *testing.T) {
+		f := jsonvalue.Freeze(jsonvalue.Object())
+		for i := 0; i < n; i++ {
+			f = f.With(jsonvalue.Path{jsonvalue.KeyName(fmt.Sprint(i))}, jsonvalue.Freeze(jsonvalue.Number(i)))
+		}
+		g := f
+		for i := 0; i < n; i += 2 {
+			g = g.Without(jsonvalue.Path{jsonvalue.KeyName(fmt.Sprint(i))})
+		}
+		equal(t, f.ObjectLen(), n)
+		equal(t, len(f.ObjectKeys()), n)
+		equal(t, g.ObjectLen(), n/2)
+		equal(t, len(g.ObjectKeys()), n/2)
+		for i := 0; i < n; i++ {
+			equal(t, f.ObjectGetElm(fmt.Sprint(i)).NumberGet().String(), strconv.Itoa(i))
+			equal(t, g.ObjectHasElm(fmt.Sprint(i)), i%2 == 1)
+		}
+	})
+}
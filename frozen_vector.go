@@ -0,0 +1,125 @@
+package jsonvalue
+
+// vector is a persistent vector trie of Frozen values with a tail buffer.
+// Every update returns a new vector sharing unchanged nodes with the original one.
+type vector struct {
+	count int
+	shift int
+	root  *vectorNode
+	tail  []Frozen
+}
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// vectorNode is either a branch node holding nodes or a leaf node holding values.
+type vectorNode struct {
+	nodes  []*vectorNode
+	leaves []Frozen
+}
+
+func newVector() vector {
+	return vector{shift: vectorBits, root: &vectorNode{}}
+}
+
+func (v vector) len() int {
+	return v.count
+}
+
+func (v vector) tailOffset() int {
+	if v.count < vectorWidth {
+		return 0
+	}
+
+	return ((v.count - 1) >> vectorBits) << vectorBits
+}
+
+func (v vector) get(index int) Frozen {
+	if index >= v.tailOffset() {
+		return v.tail[index&vectorMask]
+	}
+
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.nodes[(index>>level)&vectorMask]
+	}
+
+	return node.leaves[index&vectorMask]
+}
+
+func (v vector) set(index int, val Frozen) vector {
+	if index >= v.tailOffset() {
+		tail := append([]Frozen{}, v.tail...)
+		tail[index&vectorMask] = val
+		return vector{count: v.count, shift: v.shift, root: v.root, tail: tail}
+	}
+
+	return vector{count: v.count, shift: v.shift, root: v.root.set(v.shift, index, val), tail: v.tail}
+}
+
+func (n *vectorNode) set(level int, index int, val Frozen) *vectorNode {
+	if level == 0 {
+		leaves := append([]Frozen{}, n.leaves...)
+		leaves[index&vectorMask] = val
+		return &vectorNode{leaves: leaves}
+	}
+
+	nodes := append([]*vectorNode{}, n.nodes...)
+	sub := (index >> level) & vectorMask
+	nodes[sub] = nodes[sub].set(level-vectorBits, index, val)
+
+	return &vectorNode{nodes: nodes}
+}
+
+func (v vector) push(val Frozen) vector {
+	if v.count-v.tailOffset() < vectorWidth {
+		tail := make([]Frozen, len(v.tail), len(v.tail)+1)
+		copy(tail, v.tail)
+		return vector{count: v.count + 1, shift: v.shift, root: v.root, tail: append(tail, val)}
+	}
+
+	tailNode := &vectorNode{leaves: v.tail}
+	shift := v.shift
+	var root *vectorNode
+	if (v.count >> vectorBits) > (1 << v.shift) {
+		root = &vectorNode{nodes: []*vectorNode{v.root, newVectorPath(v.shift, tailNode)}}
+		shift += vectorBits
+	} else {
+		root = v.root.pushTail(v.count, v.shift, tailNode)
+	}
+
+	return vector{count: v.count + 1, shift: shift, root: root, tail: []Frozen{val}}
+}
+
+func (n *vectorNode) pushTail(count int, level int, tailNode *vectorNode) *vectorNode {
+	nodes := append([]*vectorNode{}, n.nodes...)
+	sub := ((count - 1) >> level) & vectorMask
+
+	var child *vectorNode
+	switch {
+	case level == vectorBits:
+		child = tailNode
+	case sub < len(nodes):
+		child = nodes[sub].pushTail(count, level-vectorBits, tailNode)
+	default:
+		child = newVectorPath(level-vectorBits, tailNode)
+	}
+	if sub < len(nodes) {
+		nodes[sub] = child
+	} else {
+		nodes = append(nodes, child)
+	}
+
+	return &vectorNode{nodes: nodes}
+}
+
+func newVectorPath(level int, node *vectorNode) *vectorNode {
+	if level == 0 {
+		return node
+	}
+
+	return &vectorNode{nodes: []*vectorNode{newVectorPath(level-vectorBits, node)}}
+}
@@ -1,6 +1,6 @@
 module github.com/Jumpaku/go-json-value
 
-go 1.20
+go 1.23
 
 require (
 	github.com/Jumpaku/go-assert v1.0.0
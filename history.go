@@ -0,0 +1,204 @@
+package jsonvalue
+
+import (
+	"fmt"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// History records changes on a JSON value and supports undo and redo of them.
+// Changes must be made through the Observable returned by Value.
+// Each change is recorded as a pair of JSON Patches, one reapplying it and the other reverting it.
+// A History must not be used by multiple goroutines concurrently.
+type History struct {
+	value       Observable
+	undo        []historyEntry
+	redo        []historyEntry
+	group       *historyEntry
+	groupDepth  int
+	replaying   bool
+	checkpoints map[string]int
+}
+
+type historyEntry struct {
+	forward Patch
+	inverse Patch
+}
+
+// NewHistory returns a History recording changes on a JSON value v.
+// v must not be modified directly while it is used through the History.
+func NewHistory(v Value) *History {
+	return newHistory(Observe(v))
+}
+
+func newHistory(o Observable) *History {
+	h := &History{value: o, checkpoints: map[string]int{}}
+	h.value.Subscribe(Path{}, h.record)
+
+	return h
+}
+
+// Value returns the Observable through which the JSON value is modified.
+func (h *History) Value() Observable {
+	return h.value
+}
+
+func (h *History) record(e ChangeEvent) {
+	if h.replaying {
+		return
+	}
+
+	pointer := e.Path.Pointer()
+	var forward, inverse PatchOperation
+	switch e.Op {
+	case OpSet:
+		if e.Old == nil {
+			forward = PatchOperation{Op: PatchOpAdd, Path: pointer, Value: e.New.Clone()}
+			inverse = PatchOperation{Op: PatchOpRemove, Path: pointer}
+		} else {
+			forward = PatchOperation{Op: PatchOpReplace, Path: pointer, Value: e.New.Clone()}
+			inverse = PatchOperation{Op: PatchOpReplace, Path: pointer, Value: e.Old.Clone()}
+		}
+	case OpAdd:
+		forward = PatchOperation{Op: PatchOpAdd, Path: pointer, Value: e.New.Clone()}
+		inverse = PatchOperation{Op: PatchOpRemove, Path: pointer}
+	case OpDelete:
+		forward = PatchOperation{Op: PatchOpRemove, Path: pointer}
+		inverse = PatchOperation{Op: PatchOpAdd, Path: pointer, Value: e.Old.Clone()}
+	case OpAssign:
+		forward = PatchOperation{Op: PatchOpReplace, Path: pointer, Value: e.New.Clone()}
+		inverse = PatchOperation{Op: PatchOpReplace, Path: pointer, Value: e.Old.Clone()}
+	default:
+		assert.Unexpected("unexpected Op: %v", e.Op)
+	}
+
+	if h.group != nil {
+		h.group.forward = append(h.group.forward, forward)
+		h.group.inverse = append(Patch{inverse}, h.group.inverse...)
+		return
+	}
+
+	h.push(historyEntry{forward: Patch{forward}, inverse: Patch{inverse}})
+}
+
+func (h *History) push(entry historyEntry) {
+	for name, mark := range h.checkpoints {
+		if mark > len(h.undo) {
+			delete(h.checkpoints, name)
+		}
+	}
+	h.undo = append(h.undo, entry)
+	h.redo = nil
+}
+
+// BeginGroup starts grouping changes so that they are undone and redone at once.
+// Groups can be nested, and the changes are recorded when the outermost group ends.
+func (h *History) BeginGroup() {
+	if h.groupDepth == 0 {
+		h.group = &historyEntry{}
+	}
+	h.groupDepth++
+}
+
+// EndGroup ends grouping changes started by BeginGroup.
+func (h *History) EndGroup() {
+	assert.State(h.groupDepth > 0, "EndGroup must be called after BeginGroup")
+
+	h.groupDepth--
+	if h.groupDepth > 0 {
+		return
+	}
+
+	group := h.group
+	h.group = nil
+	if len(group.forward) > 0 {
+		h.push(*group)
+	}
+}
+
+// CanUndo returns whether there are changes to be undone.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo returns whether there are undone changes to be redone.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Undo reverts the last recorded change or group of changes.
+// If there is no change to be undone, Undo returns false.
+func (h *History) Undo() bool {
+	assert.State(h.groupDepth == 0, "Undo must not be called while grouping changes")
+
+	if !h.CanUndo() {
+		return false
+	}
+
+	entry := h.undo[len(h.undo)-1]
+	h.replay(entry.inverse)
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, entry)
+
+	return true
+}
+
+// Redo reapplies the last undone change or group of changes.
+// If there is no change to be redone, Redo returns false.
+func (h *History) Redo() bool {
+	assert.State(h.groupDepth == 0, "Redo must not be called while grouping changes")
+
+	if !h.CanRedo() {
+		return false
+	}
+
+	entry := h.redo[len(h.redo)-1]
+	h.replay(entry.forward)
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, entry)
+
+	return true
+}
+
+func (h *History) replay(patch Patch) {
+	h.replaying = true
+	defer func() { h.replaying = false }()
+
+	err := patch.Apply(h.value)
+	assert.State(err == nil, "recorded patch must be applicable: %w", err)
+}
+
+// UndoPatch returns a JSON Patch reverting all the recorded changes that can be undone.
+func (h *History) UndoPatch() Patch {
+	patch := Patch{}
+	for i := len(h.undo) - 1; i >= 0; i-- {
+		patch = append(patch, h.undo[i].inverse...)
+	}
+
+	return patch
+}
+
+// Checkpoint names the current state so that it can be restored by RestoreCheckpoint.
+// A checkpoint is discarded when the changes after it are undone and other changes are recorded.
+func (h *History) Checkpoint(name string) {
+	assert.State(h.groupDepth == 0, "Checkpoint must not be called while grouping changes")
+
+	h.checkpoints[name] = len(h.undo)
+}
+
+// RestoreCheckpoint undoes or redoes changes to restore the state named by Checkpoint.
+func (h *History) RestoreCheckpoint(name string) error {
+	mark, ok := h.checkpoints[name]
+	if !ok {
+		return fmt.Errorf(`checkpoint %q not found`, name)
+	}
+
+	for len(h.undo) > mark {
+		h.Undo()
+	}
+	for len(h.undo) < mark {
+		h.Redo()
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestHistory_UndoRedo(t *testing.T) {
+	original := `{"a":{"x":1},"b":[1,2]}`
+	h := jsonvalue.NewHistory(mustUnmarshal(t, original))
+	v := h.Value()
+
+	v.ObjectGetElm("a").ObjectSetElm("x", jsonvalue.Number(2))
+	v.ObjectGetElm("a").ObjectSetElm("y", jsonvalue.Null())
+	v.ObjectDelElm("b")
+	v.ObjectSetElm("c", jsonvalue.Array())
+	v.ObjectGetElm("c").ArrayAddElm(jsonvalue.Number(1), jsonvalue.Number(2))
+	v.ObjectGetElm("c").ArraySetElm(0, jsonvalue.String("x"))
+	v.ObjectGetElm("a").Assign(jsonvalue.Boolean(true))
+	edited := mustMarshal(t, v)
+	equal(t, edited, `{"a":true,"c":["x",2]}`)
+
+	for h.CanUndo() {
+		equal(t, h.Undo(), true)
+	}
+	equal(t, h.Undo(), false)
+	equal(t, jsonvalue.Equal(v, mustUnmarshal(t, original)), true)
+
+	for h.CanRedo() {
+		equal(t, h.Redo(), true)
+	}
+	equal(t, h.Redo(), false)
+	equal(t, mustMarshal(t, v), edited)
+}
+
+func TestHistory_Redo_Discarded(t *testing.T) {
+	h := jsonvalue.NewHistory(jsonvalue.Object())
+	v := h.Value()
+	v.ObjectSetElm("a", jsonvalue.Null())
+	h.Undo()
+	v.ObjectSetElm("b", jsonvalue.Null())
+
+	equal(t, h.CanRedo(), false)
+	equal(t, mustMarshal(t, v), `{"b":null}`)
+}
+
+func TestHistory_Group(t *testing.T) {
+	h := jsonvalue.NewHistory(jsonvalue.Object())
+	v := h.Value()
+	h.BeginGroup()
+	v.ObjectSetElm("a", jsonvalue.Null())
+	h.BeginGroup()
+	v.ObjectSetElm("b", jsonvalue.Null())
+	h.EndGroup()
+	v.ObjectSetElm("c", jsonvalue.Null())
+	h.EndGroup()
+	v.ObjectSetElm("d", jsonvalue.Null())
+
+	h.Undo()
+	equal(t, mustMarshal(t, v), `{"a":null,"b":null,"c":null}`)
+	h.Undo()
+	equal(t, mustMarshal(t, v), `{}`)
+	equal(t, h.CanUndo(), false)
+	h.Redo()
+	equal(t, mustMarshal(t, v), `{"a":null,"b":null,"c":null}`)
+}
+
+func TestHistory_Checkpoint(t *testing.T) {
+	h := jsonvalue.NewHistory(jsonvalue.Object())
+	v := h.Value()
+	v.ObjectSetElm("a", jsonvalue.Null())
+	h.Checkpoint("first")
+	v.ObjectSetElm("b", jsonvalue.Null())
+	h.Checkpoint("second")
+	v.ObjectSetElm("c", jsonvalue.Null())
+
+	equal(t, h.RestoreCheckpoint("first"), nil)
+	equal(t, mustMarshal(t, v), `{"a":null}`)
+	equal(t, h.RestoreCheckpoint("second"), nil)
+	equal(t, mustMarshal(t, v), `{"a":null,"b":null}`)
+
+	h.RestoreCheckpoint("first")
+	v.ObjectSetElm("d", jsonvalue.Null())
+	IsNotNil(t, h.RestoreCheckpoint("second"))
+	IsNotNil(t, h.RestoreCheckpoint("unknown"))
+	equal(t, h.RestoreCheckpoint("first"), nil)
+	equal(t, mustMarshal(t, v), `{"a":null}`)
+}
+
+func TestHistory_UndoPatch(t *testing.T) {
+	h := jsonvalue.NewHistory(mustUnmarshal(t, `{"a":1}`))
+	v := h.Value()
+	v.ObjectSetElm("a", jsonvalue.Number(2))
+	v.ObjectSetElm("b", jsonvalue.Number(3))
+
+	equal(t, mustMarshal(t, h.UndoPatch()), `[{"op":"remove","path":"/b"},{"op":"replace","path":"/a","value":1}]`)
+}
+
+func TestHistory_Array(t *testing.T) {
+	original := `[0,1,2,3]`
+	h := jsonvalue.NewHistory(mustUnmarshal(t, original))
+	v := h.Value()
+	v.ArrayInsertElm(1, jsonvalue.String("x"), jsonvalue.String("y"))
+	v.ArrayDelElm(4)
+	v.ArraySplice(0, 2, jsonvalue.Null())
+	v.ArrayPrepend(jsonvalue.Boolean(true))
+	v.ArraySort(jsonvalue.Compare)
+	edited := mustMarshal(t, v)
+	equal(t, edited, `[null,true,1,3,"y"]`)
+
+	for h.Undo() {
+	}
+	equal(t, mustMarshal(t, v), original)
+	for h.Redo() {
+	}
+	equal(t, mustMarshal(t, v), edited)
+}
+
+func TestHistory_ChildAcrossShift(t *testing.T) {
+	original := `{"b":[{"x":1},{"x":2},{"x":3}]}`
+	h := jsonvalue.NewHistory(mustUnmarshal(t, original))
+	v := h.Value()
+
+	b := v.ObjectGetElm("b")
+	c := b.ArrayGetElm(1)
+	b.ArrayDelElm(0)
+	c.ObjectSetElm("x", jsonvalue.Number(99))
+	b.ArrayInsertElm(0, jsonvalue.Null())
+	c.ObjectSetElm("y", jsonvalue.Number(0))
+	edited := mustMarshal(t, v)
+	equal(t, edited, `{"b":[null,{"x":99,"y":0},{"x":3}]}`)
+
+	for h.CanUndo() {
+		equal(t, h.Undo(), true)
+	}
+	equal(t, jsonvalue.Equal(v, mustUnmarshal(t, original)), true)
+
+	for h.CanRedo() {
+		equal(t, h.Redo(), true)
+	}
+	equal(t, mustMarshal(t, v), edited)
+}
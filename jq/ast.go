@@ -0,0 +1,78 @@
+package jq
+
+import (
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+// node is a node of the syntax tree of a jq program.
+type node interface{}
+
+type (
+	identityNode  struct{}
+	literalNode   struct{ value jsonvalue.Value }
+	textNode      struct{ text string }
+	variableNode  struct{ name string }
+	formatNode    struct{ name string }
+	negateNode    struct{ operand node }
+	arrayNode     struct{ body node }
+	iterateNode   struct{ target node }
+	pipeNode      struct{ left, right node }
+	commaNode     struct{ left, right node }
+	alternateNode struct{ left, right node }
+	andNode       struct{ left, right node }
+	orNode        struct{ left, right node }
+	indexNode     struct{ target, index node }
+	sliceNode     struct{ target, from, to node }
+	binaryNode    struct {
+		op          string
+		left, right node
+	}
+	assignNode struct {
+		op          string
+		left, right node
+	}
+	stringNode struct {
+		format string
+		parts  []node
+	}
+	objectNode struct{ entries []objectEntry }
+	ifNode     struct{ cond, then, els node }
+	tryNode    struct{ body, catch node }
+	bindNode   struct {
+		source node
+		name   string
+		body   node
+	}
+	reduceNode struct {
+		source       node
+		name         string
+		init, update node
+	}
+	foreachNode struct {
+		source                node
+		name                  string
+		init, update, extract node
+	}
+	funcDefNode struct {
+		def  *funcDef
+		rest node
+	}
+	callNode struct {
+		name string
+		args []node
+	}
+)
+
+type objectEntry struct {
+	key node
+	// value is nil for the shorthand forms such as {a} and {$x}.
+	value node
+}
+
+// funcDef is a definition of a function by def.
+// A parameter prefixed with '$' is a value parameter.
+type funcDef struct {
+	name   string
+	params []string
+	body   node
+}
@@ -0,0 +1,752 @@
+package jq
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Jumpaku/go-assert"
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+// builtinFunc is a function implemented in Go, which receives the arguments as unevaluated filters.
+type builtinFunc func(args []node, e *env, in pv, emit emitFunc) error
+
+// builtins maps the keys in the form of name/arity to the builtin functions implemented in Go.
+var builtins map[string]builtinFunc
+
+// preludeSource defines the builtin functions implemented in jq.
+const preludeSource = `
+def error(msg): msg | error;
+def select(f): if f then . else empty end;
+def map(f): [.[] | f];
+def map_values(f): .[] |= f;
+def recurse(f): def r: ., (f | r); r;
+def recurse(f; cond): def r: ., (f | select(cond) | r); r;
+def recurse: recurse(.[]?);
+def values: select(. != null);
+def nulls: select(. == null);
+def booleans: select(type == "boolean");
+def numbers: select(type == "number");
+def strings: select(type == "string");
+def arrays: select(type == "array");
+def objects: select(type == "object");
+def iterables: select(type | . == "array" or . == "object");
+def scalars: select(type | . != "array" and . != "object");
+def isempty(g): first((g | false), true);
+def any: reduce .[] as $x (false; . or $x);
+def all: reduce .[] as $x (true; . and $x);
+def any(f): reduce (.[] | f) as $x (false; . or $x);
+def all(f): reduce (.[] | f) as $x (true; . and $x);
+def any(g; cond): isempty(first(g | cond | select(.))) | not;
+def all(g; cond): isempty(first(g | cond | select(. | not)));
+def IN(s): any(s == .; .);
+def in(xs): . as $x | xs | has($x);
+def inside(xs): . as $x | xs | contains($x);
+def add(f): reduce f as $x (null; . + $x);
+def range($x): range(0; $x);
+def first: .[0];
+def last: .[-1];
+def nth($n): .[$n];
+def nth($n; f): if $n < 0 then error("out of bounds negative array index") else last(limit($n + 1; f)) end;
+def until(cond; update): def _until: if cond then . else (update | _until) end; _until;
+def while(cond; update): def _while: if cond then ., (update | _while) else empty end; _while;
+def repeat(f): def _repeat: ., (f | _repeat); _repeat;
+def del(f): delpaths([path(f)]);
+def paths: path(..) | select(length > 0);
+def paths(node_filter): . as $dot | paths | select(. as $p | $dot | getpath($p) | node_filter);
+def leaf_paths: paths(scalars);
+def to_entries: [keys_unsorted[] as $k | {key: $k, value: .[$k]}];
+def from_entries: reduce .[] as $x ({};
+	. + {($x | if .key == null then .k // .name // .Name // .K // .Key else .key end | if type == "string" then . else tojson end):
+		($x | if has("value") then .value else .v end)});
+def with_entries(f): to_entries | map(f) | from_entries;
+def walk(f): def w: if type == "object" then map_values(w) elif type == "array" then map(w) else . end | f; w;
+def toarray: if type == "array" then . else [.] end;
+def env: $ENV;
+`
+
+var prelude *env
+
+func init() {
+	builtins = map[string]builtinFunc{
+		"empty/0": func(args []node, e *env, in pv, emit emitFunc) error { return nil },
+		"error/0": func(args []node, e *env, in pv, emit emitFunc) error { return &Error{Value: in.value} },
+		"not/0": simple(func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+			return jsonvalue.Boolean(!isTruthy(v)), nil
+		}),
+		"path/1":      builtinPath,
+		"getpath/1":   builtinGetPath,
+		"first/1":     builtinFirst,
+		"last/1":      builtinLast,
+		"limit/2":     builtinLimit,
+		"range/2":     builtinRange,
+		"range/3":     builtinRange,
+		"sort_by/1":   byKeys(sortBy),
+		"group_by/1":  byKeys(groupBy),
+		"unique_by/1": byKeys(uniqueBy),
+		"min_by/1":    byKeys(minBy),
+		"max_by/1":    byKeys(maxBy),
+	}
+	for name, fn := range simpleBuiltins {
+		builtins[name] = simple(fn)
+	}
+
+	defs, err := parseDefs(preludeSource)
+	assert.State(err == nil, "fail to parse prelude: %w", err)
+	for _, def := range defs {
+		prelude = prelude.bindFunc(def)
+	}
+	for _, def := range defs {
+		err := checkDef(def, newScope())
+		assert.State(err == nil, "prelude must be valid: %w", err)
+	}
+}
+
+func environ() jsonvalue.Value {
+	props := jsonvalue.Props{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			props[k] = jsonvalue.String(v)
+		}
+	}
+
+	return jsonvalue.Object(props)
+}
+
+// simple returns a builtin function calling fn with each combination of the outputs of the arguments.
+func simple(fn func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error)) builtinFunc {
+	return func(args []node, e *env, in pv, emit emitFunc) error {
+		return evalArgs(args, e, in, make([]jsonvalue.Value, len(args)), func(values []jsonvalue.Value) error {
+			v, err := fn(in.value, values)
+			if err != nil {
+				return err
+			}
+			return emit(derive(in, v))
+		})
+	}
+}
+
+// evalArgs evaluates the arguments from the last one so that the earlier arguments vary faster.
+func evalArgs(args []node, e *env, in pv, values []jsonvalue.Value, f func([]jsonvalue.Value) error) error {
+	if len(args) == 0 {
+		return f(values)
+	}
+
+	last := len(args) - 1
+	return eval(args[last], e, plain(in), func(o pv) error {
+		values[last] = o.value
+		return evalArgs(args[:last], e, in, values, f)
+	})
+}
+
+func builtinPath(args []node, e *env, in pv, emit emitFunc) error {
+	if in.mode == modeBroken {
+		return invalidPath(in.value)
+	}
+
+	return eval(args[0], e, pv{value: in.value, path: jsonvalue.Path{}, mode: modeTracked}, func(o pv) error {
+		if o.mode != modeTracked {
+			return invalidPath(o.value)
+		}
+		return emit(derive(in, pathValue(o.path)))
+	})
+}
+
+func builtinGetPath(args []node, e *env, in pv, emit emitFunc) error {
+	return eval(args[0], e, plain(in), func(o pv) error {
+		p, err := valuePath(o.value)
+		if err != nil {
+			return err
+		}
+		out := in
+		for _, k := range p {
+			if out, err = indexValue(out, keyValue(k)); err != nil {
+				return err
+			}
+		}
+		return emit(out)
+	})
+}
+
+func builtinFirst(args []node, e *env, in pv, emit emitFunc) error {
+	stop := &stopError{}
+	err := eval(args[0], e, in, func(o pv) error {
+		if err := emit(o); err != nil {
+			return err
+		}
+		return stop
+	})
+	if err == stop {
+		return nil
+	}
+
+	return err
+}
+
+func builtinLast(args []node, e *env, in pv, emit emitFunc) error {
+	var last *pv
+	err := eval(args[0], e, in, func(o pv) error {
+		last = &o
+		return nil
+	})
+	if err != nil || last == nil {
+		return err
+	}
+
+	return emit(*last)
+}
+
+func builtinLimit(args []node, e *env, in pv, emit emitFunc) error {
+	return eval(args[0], e, plain(in), func(n pv) error {
+		if n.value.Type() != jsonvalue.TypeNumber {
+			return errorf(`limit requires a number but got %s`, describeValue(n.value))
+		}
+		limit := int(toFloat(n.value))
+		if limit <= 0 {
+			return nil
+		}
+
+		count := 0
+		stop := &stopError{}
+		err := eval(args[1], e, in, func(o pv) error {
+			if err := emit(o); err != nil {
+				return err
+			}
+			count++
+			if count >= limit {
+				return stop
+			}
+			return nil
+		})
+		if err == stop {
+			return nil
+		}
+		return err
+	})
+}
+
+func builtinRange(args []node, e *env, in pv, emit emitFunc) error {
+	return evalArgs(args, e, in, make([]jsonvalue.Value, len(args)), func(values []jsonvalue.Value) error {
+		for _, v := range values {
+			if v.Type() != jsonvalue.TypeNumber {
+				return errorf(`range bounds must be numbers but got %s`, describeValue(v))
+			}
+		}
+		from, upto, by := toFloat(values[0]), toFloat(values[1]), 1.0
+		if len(values) == 3 {
+			by = toFloat(values[2])
+		}
+		for x := from; (by > 0 && x < upto) || (by < 0 && x > upto); x += by {
+			if err := emit(derive(in, numberValue(x))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// byKeys returns a builtin function calling fn with the elements of the input array and their keys, which are the arrays of the outputs of the argument.
+func byKeys(fn func(elms, keys []jsonvalue.Value) jsonvalue.Value) builtinFunc {
+	return func(args []node, e *env, in pv, emit emitFunc) error {
+		if in.value.Type() != jsonvalue.TypeArray {
+			return errorf(`cannot index %s with number`, typeName(in.value))
+		}
+		elms := elements(in.value)
+		keys := make([]jsonvalue.Value, len(elms))
+		for i, elm := range elms {
+			key := jsonvalue.Array()
+			err := eval(args[0], e, pv{value: elm}, func(o pv) error {
+				key.ArrayAddElm(o.value)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			keys[i] = key
+		}
+		return emit(derive(in, fn(elms, keys)))
+	}
+}
+
+// sortIndices returns the indices of keys in the stable order of keys.
+func sortIndices(keys []jsonvalue.Value) []int {
+	indices := make([]int, len(keys))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool { return compare(keys[indices[i]], keys[indices[j]]) < 0 })
+
+	return indices
+}
+
+func sortBy(elms, keys []jsonvalue.Value) jsonvalue.Value {
+	sorted := jsonvalue.Array()
+	for _, i := range sortIndices(keys) {
+		sorted.ArrayAddElm(elms[i])
+	}
+
+	return sorted
+}
+
+func groupBy(elms, keys []jsonvalue.Value) jsonvalue.Value {
+	groups := jsonvalue.Array()
+	var group jsonvalue.Value
+	var groupKey jsonvalue.Value
+	for _, i := range sortIndices(keys) {
+		if group == nil || compare(groupKey, keys[i]) != 0 {
+			group, groupKey = jsonvalue.Array(), keys[i]
+			groups.ArrayAddElm(group)
+		}
+		group.ArrayAddElm(elms[i])
+	}
+
+	return groups
+}
+
+func uniqueBy(elms, keys []jsonvalue.Value) jsonvalue.Value {
+	unique := jsonvalue.Array()
+	for _, group := range groupBy(elms, keys).ArrayAll() {
+		unique.ArrayAddElm(group.ArrayGetElm(0))
+	}
+
+	return unique
+}
+
+func minBy(elms, keys []jsonvalue.Value) jsonvalue.Value {
+	if len(elms) == 0 {
+		return jsonvalue.Null()
+	}
+
+	return elms[sortIndices(keys)[0]]
+}
+
+func maxBy(elms, keys []jsonvalue.Value) jsonvalue.Value {
+	if len(elms) == 0 {
+		return jsonvalue.Null()
+	}
+	m := 0
+	for i := range keys {
+		if compare(keys[i], keys[m]) >= 0 {
+			m = i
+		}
+	}
+
+	return elms[m]
+}
+
+func identityKeys(v jsonvalue.Value) ([]jsonvalue.Value, error) {
+	if v.Type() != jsonvalue.TypeArray {
+		return nil, errorf(`%s cannot be sorted, as it is not an array`, describeValue(v))
+	}
+
+	return elements(v), nil
+}
+
+func mathFunc(fn func(float64) float64) func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+	return func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != jsonvalue.TypeNumber {
+			return nil, errorf(`%s number required`, describeValue(v))
+		}
+		return numberValue(fn(toFloat(v))), nil
+	}
+}
+
+func stringFunc(name string, fn func(s string) jsonvalue.Value) func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+	return func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != jsonvalue.TypeString {
+			return nil, errorf(`%s cannot be %s, as it is not a string`, describeValue(v), name)
+		}
+		return fn(v.StringGet()), nil
+	}
+}
+
+var simpleBuiltins = map[string]func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error){
+	"length/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		switch v.Type() {
+		case jsonvalue.TypeNull:
+			return jsonvalue.Number(0), nil
+		case jsonvalue.TypeNumber:
+			return numberValue(math.Abs(toFloat(v))), nil
+		case jsonvalue.TypeString:
+			return jsonvalue.Number(utf8.RuneCountInString(v.StringGet())), nil
+		case jsonvalue.TypeArray:
+			return jsonvalue.Number(v.ArrayLen()), nil
+		case jsonvalue.TypeObject:
+			return jsonvalue.Number(v.ObjectLen()), nil
+		default:
+			return nil, errorf(`%s has no length`, describeValue(v))
+		}
+	},
+	"utf8bytelength/0": stringFunc("measured in bytes", func(s string) jsonvalue.Value {
+		return jsonvalue.Number(len(s))
+	}),
+	"type/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return jsonvalue.String(typeName(v)), nil
+	},
+	"keys/0":          keys,
+	"keys_unsorted/0": keys,
+	"has/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		k := args[0]
+		switch {
+		case v.Type() == jsonvalue.TypeObject && k.Type() == jsonvalue.TypeString:
+			return jsonvalue.Boolean(v.ObjectHasElm(k.StringGet())), nil
+		case v.Type() == jsonvalue.TypeArray && k.Type() == jsonvalue.TypeNumber:
+			i := toFloat(k)
+			return jsonvalue.Boolean(0 <= i && i < float64(v.ArrayLen())), nil
+		default:
+			return nil, errorf(`cannot check whether %s has a %s key`, typeName(v), typeName(k))
+		}
+	},
+	"contains/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != args[0].Type() {
+			return nil, errorf(`%s and %s cannot have their containment checked`, describeValue(v), describeValue(args[0]))
+		}
+		return jsonvalue.Boolean(contains(v, args[0])), nil
+	},
+	"add/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		var elms []jsonvalue.Value
+		switch v.Type() {
+		case jsonvalue.TypeNull:
+			return v, nil
+		case jsonvalue.TypeArray:
+			elms = elements(v)
+		case jsonvalue.TypeObject:
+			for _, k := range sortedKeys(v) {
+				elms = append(elms, v.ObjectGetElm(k))
+			}
+		default:
+			return nil, errorf(`cannot iterate over %s`, describeValue(v))
+		}
+		var sum jsonvalue.Value = jsonvalue.Null()
+		for _, elm := range elms {
+			var err error
+			if sum, err = add(sum, elm); err != nil {
+				return nil, err
+			}
+		}
+		return sum, nil
+	},
+	"floor/0": mathFunc(math.Floor),
+	"ceil/0":  mathFunc(math.Ceil),
+	"round/0": mathFunc(math.Round),
+	"sqrt/0":  mathFunc(math.Sqrt),
+	"fabs/0":  mathFunc(math.Abs),
+	"abs/0":   mathFunc(math.Abs),
+	"log/0":   mathFunc(math.Log),
+	"exp/0":   mathFunc(math.Exp),
+	"pow/2": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(args[0], args[1], jsonvalue.TypeNumber) {
+			return nil, errorf(`pow requires numbers`)
+		}
+		return numberValue(math.Pow(toFloat(args[0]), toFloat(args[1]))), nil
+	},
+	"infinite/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return numberValue(math.Inf(1)), nil
+	},
+	"tostring/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return jsonvalue.String(toText(v)), nil
+	},
+	"tojson/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return jsonvalue.String(toJSON(v)), nil
+	},
+	"fromjson/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != jsonvalue.TypeString {
+			return nil, errorf(`%s cannot be parsed, as it is not a string`, describeValue(v))
+		}
+		return fromJSON(v.StringGet())
+	},
+	"tonumber/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		switch v.Type() {
+		case jsonvalue.TypeNumber:
+			return v, nil
+		case jsonvalue.TypeString:
+			parsed, err := fromJSON(strings.TrimSpace(v.StringGet()))
+			if err != nil || parsed.Type() != jsonvalue.TypeNumber {
+				return nil, errorf(`cannot parse '%s' as a number`, v.StringGet())
+			}
+			return parsed, nil
+		default:
+			return nil, errorf(`%s cannot be parsed as a number`, describeValue(v))
+		}
+	},
+	"ascii_downcase/0": stringFunc("lowercased", func(s string) jsonvalue.Value {
+		return jsonvalue.String(mapASCII(s, 'A', 'Z', 'a'-'A'))
+	}),
+	"ascii_upcase/0": stringFunc("uppercased", func(s string) jsonvalue.Value {
+		return jsonvalue.String(mapASCII(s, 'a', 'z', 'A'-'a'))
+	}),
+	"trim/0": stringFunc("trimmed", func(s string) jsonvalue.Value {
+		return jsonvalue.String(strings.TrimSpace(s))
+	}),
+	"ltrim/0": stringFunc("trimmed", func(s string) jsonvalue.Value {
+		return jsonvalue.String(strings.TrimLeft(s, " \t\n\r\f\v"))
+	}),
+	"rtrim/0": stringFunc("trimmed", func(s string) jsonvalue.Value {
+		return jsonvalue.String(strings.TrimRight(s, " \t\n\r\f\v"))
+	}),
+	"explode/0": stringFunc("exploded", func(s string) jsonvalue.Value {
+		a := jsonvalue.Array()
+		for _, r := range s {
+			a.ArrayAddElm(jsonvalue.Number(int(r)))
+		}
+		return a
+	}),
+	"implode/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != jsonvalue.TypeArray {
+			return nil, errorf(`%s cannot be imploded, as it is not an array`, describeValue(v))
+		}
+		var b strings.Builder
+		for _, elm := range v.ArrayAll() {
+			if elm.Type() != jsonvalue.TypeNumber {
+				return nil, errorf(`%s cannot be imploded, as it is not a codepoint`, describeValue(elm))
+			}
+			b.WriteRune(rune(toFloat(elm)))
+		}
+		return jsonvalue.String(b.String()), nil
+	},
+	"ltrimstr/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(v, args[0], jsonvalue.TypeString) {
+			return v, nil
+		}
+		return jsonvalue.String(strings.TrimPrefix(v.StringGet(), args[0].StringGet())), nil
+	},
+	"rtrimstr/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(v, args[0], jsonvalue.TypeString) {
+			return v, nil
+		}
+		return jsonvalue.String(strings.TrimSuffix(v.StringGet(), args[0].StringGet())), nil
+	},
+	"startswith/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(v, args[0], jsonvalue.TypeString) {
+			return nil, errorf(`startswith() requires string inputs`)
+		}
+		return jsonvalue.Boolean(strings.HasPrefix(v.StringGet(), args[0].StringGet())), nil
+	},
+	"endswith/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(v, args[0], jsonvalue.TypeString) {
+			return nil, errorf(`endswith() requires string inputs`)
+		}
+		return jsonvalue.Boolean(strings.HasSuffix(v.StringGet(), args[0].StringGet())), nil
+	},
+	"split/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if !bothType(v, args[0], jsonvalue.TypeString) {
+			return nil, errorf(`split input and separator must be strings`)
+		}
+		return split(v.StringGet(), args[0].StringGet()), nil
+	},
+	"join/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if v.Type() != jsonvalue.TypeArray {
+			return nil, errorf(`cannot iterate over %s`, describeValue(v))
+		}
+		if args[0].Type() != jsonvalue.TypeString {
+			return nil, errorf(`join separator must be a string`)
+		}
+		parts := []string{}
+		for _, elm := range v.ArrayAll() {
+			switch elm.Type() {
+			case jsonvalue.TypeNull:
+				parts = append(parts, "")
+			case jsonvalue.TypeArray, jsonvalue.TypeObject:
+				return nil, errorf(`cannot join with %s`, typeName(elm))
+			default:
+				parts = append(parts, toText(elm))
+			}
+		}
+		return jsonvalue.String(strings.Join(parts, args[0].StringGet())), nil
+	},
+	"test/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return test(v, args[0], jsonvalue.Null())
+	},
+	"test/2": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return test(v, args[0], args[1])
+	},
+	"sort/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		elms, err := identityKeys(v)
+		if err != nil {
+			return nil, err
+		}
+		return sortBy(elms, elms), nil
+	},
+	"unique/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		elms, err := identityKeys(v)
+		if err != nil {
+			return nil, err
+		}
+		return uniqueBy(elms, elms), nil
+	},
+	"min/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		elms, err := identityKeys(v)
+		if err != nil {
+			return nil, err
+		}
+		return minBy(elms, elms), nil
+	},
+	"max/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		elms, err := identityKeys(v)
+		if err != nil {
+			return nil, err
+		}
+		return maxBy(elms, elms), nil
+	},
+	"reverse/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		switch v.Type() {
+		case jsonvalue.TypeNull:
+			return jsonvalue.Array(), nil
+		case jsonvalue.TypeString:
+			runes := []rune(v.StringGet())
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return jsonvalue.String(string(runes)), nil
+		case jsonvalue.TypeArray:
+			reversed := jsonvalue.Array()
+			for i := v.ArrayLen() - 1; i >= 0; i-- {
+				reversed.ArrayAddElm(v.ArrayGetElm(i))
+			}
+			return reversed, nil
+		default:
+			return nil, errorf(`cannot reverse %s`, describeValue(v))
+		}
+	},
+	"flatten/0": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		return flatten(v, -1)
+	},
+	"flatten/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if args[0].Type() != jsonvalue.TypeNumber || toFloat(args[0]) < 0 {
+			return nil, errorf(`flatten depth must not be negative`)
+		}
+		return flatten(v, int(toFloat(args[0])))
+	},
+	"setpath/2": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		p, err := valuePath(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return setPath(v, p, args[1])
+	},
+	"delpaths/1": func(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+		if args[0].Type() != jsonvalue.TypeArray {
+			return nil, errorf(`paths must be specified as an array`)
+		}
+		paths := []jsonvalue.Path{}
+		for _, elm := range args[0].ArrayAll() {
+			p, err := valuePath(elm)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, p)
+		}
+		return deletePaths(v, paths)
+	},
+}
+
+func keys(v jsonvalue.Value, args []jsonvalue.Value) (jsonvalue.Value, error) {
+	a := jsonvalue.Array()
+	switch v.Type() {
+	case jsonvalue.TypeObject:
+		for _, k := range sortedKeys(v) {
+			a.ArrayAddElm(jsonvalue.String(k))
+		}
+	case jsonvalue.TypeArray:
+		for i := 0; i < v.ArrayLen(); i++ {
+			a.ArrayAddElm(jsonvalue.Number(i))
+		}
+	default:
+		return nil, errorf(`%s has no keys`, describeValue(v))
+	}
+
+	return a, nil
+}
+
+func contains(a, b jsonvalue.Value) bool {
+	switch {
+	case bothType(a, b, jsonvalue.TypeObject):
+		for k, bv := range b.ObjectAll() {
+			if !a.ObjectHasElm(k) || !contains(a.ObjectGetElm(k), bv) {
+				return false
+			}
+		}
+		return true
+	case bothType(a, b, jsonvalue.TypeArray):
+		for _, bv := range b.ArrayAll() {
+			found := false
+			for _, av := range a.ArrayAll() {
+				if contains(av, bv) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case bothType(a, b, jsonvalue.TypeString):
+		return strings.Contains(a.StringGet(), b.StringGet())
+	default:
+		return compare(a, b) == 0
+	}
+}
+
+// fromJSON parses a JSON text s, which must consist of exactly one JSON value.
+func fromJSON(s string) (jsonvalue.Value, error) {
+	v := jsonvalue.Null()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return nil, errorf(`%s (while parsing '%s')`, err, s)
+	}
+
+	return v, nil
+}
+
+func mapASCII(s string, from, to byte, delta int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if from <= c && c <= to {
+			b[i] = byte(int(c) + delta)
+		}
+	}
+
+	return string(b)
+}
+
+func test(v, re, flags jsonvalue.Value) (jsonvalue.Value, error) {
+	if !bothType(v, re, jsonvalue.TypeString) {
+		return nil, errorf(`%s cannot be matched, as it is not a string`, describeValue(v))
+	}
+
+	prefix := ""
+	if flags.Type() == jsonvalue.TypeString {
+		for _, f := range flags.StringGet() {
+			switch f {
+			case 'i', 's':
+				prefix += string(f)
+			case 'g', 'n':
+			default:
+				return nil, errorf(`%s is not a valid modifier string`, flags.StringGet())
+			}
+		}
+	}
+	if prefix != "" {
+		prefix = "(?" + prefix + ")"
+	}
+
+	r, err := regexp.Compile(prefix + re.StringGet())
+	if err != nil {
+		return nil, errorf(`%s (at offset 0) is not a valid regex: %s`, re.StringGet(), err.Error())
+	}
+
+	return jsonvalue.Boolean(r.MatchString(v.StringGet())), nil
+}
+
+func flatten(v jsonvalue.Value, depth int) (jsonvalue.Value, error) {
+	if v.Type() != jsonvalue.TypeArray {
+		return nil, errorf(`cannot iterate over %s`, describeValue(v))
+	}
+
+	return jsonvalue.FlattenArray(v, depth), nil
+}
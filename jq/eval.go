@@ -0,0 +1,711 @@
+package jq
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+// pathMode represents whether the paths of the values are tracked, which is required by path(f) and the assignment operators.
+type pathMode int
+
+const (
+	// modePlain does not track paths.
+	modePlain pathMode = iota
+	// modeTracked tracks the path of the value from the input of the path expression.
+	modeTracked
+	// modeBroken indicates that the value is not reachable by a path from the input of the path expression.
+	modeBroken
+)
+
+// pv is a JSON value with its path.
+type pv struct {
+	value jsonvalue.Value
+	path  jsonvalue.Path
+	mode  pathMode
+}
+
+type emitFunc func(pv) error
+
+func plain(in pv) pv {
+	return pv{value: in.value}
+}
+
+// derive returns a value computed from in, which has no path.
+func derive(in pv, v jsonvalue.Value) pv {
+	if in.mode == modePlain {
+		return pv{value: v}
+	}
+
+	return pv{value: v, mode: modeBroken}
+}
+
+// env is a scope of variables and functions as a linked list.
+type env struct {
+	parent *env
+	// variable is the name of the variable bound in this scope if closure is nil.
+	variable string
+	value    jsonvalue.Value
+	// closure is the function bound in this scope.
+	closure *closure
+	// depth counts the nested evaluations in a run, which is shared by the scopes created in the run and is nil in the prelude.
+	depth *int
+}
+
+// maxEvalDepth bounds the nested evaluations so that a deep recursion is reported as an error instead of overflowing the goroutine stack.
+// The evaluations are nested while the outputs are emitted as well as while the nodes are evaluated, since they are processed on the same stack.
+const maxEvalDepth = 1 << 18
+
+// runScope returns a scope for a run of a query, which counts its nested evaluations.
+func runScope() *env {
+	return &env{parent: prelude, depth: new(int)}
+}
+
+type closure struct {
+	def *funcDef
+	env *env
+}
+
+func funcKey(name string, arity int) string {
+	return name + "/" + strconv.Itoa(arity)
+}
+
+func (e *env) depthCounter() *int {
+	if e == nil {
+		return nil
+	}
+
+	return e.depth
+}
+
+func (e *env) bindVariable(name string, v jsonvalue.Value) *env {
+	return &env{parent: e, variable: name, value: v, depth: e.depthCounter()}
+}
+
+// bindFunc binds a function defined by def, which can call itself recursively.
+func (e *env) bindFunc(def *funcDef) *env {
+	bound := &env{parent: e, depth: e.depthCounter()}
+	bound.closure = &closure{def: def, env: bound}
+
+	return bound
+}
+
+func (e *env) bindClosure(c *closure) *env {
+	return &env{parent: e, closure: c, depth: e.depthCounter()}
+}
+
+func (e *env) lookupVariable(name string) (jsonvalue.Value, bool) {
+	for s := e; s != nil; s = s.parent {
+		if s.closure == nil && s.variable == name {
+			return s.value, true
+		}
+	}
+
+	return nil, false
+}
+
+func (e *env) lookupFunc(name string, arity int) (*closure, bool) {
+	for s := e; s != nil; s = s.parent {
+		if s.closure != nil && s.closure.def.name == name && len(s.closure.def.params) == arity {
+			return s.closure, true
+		}
+	}
+
+	return nil, false
+}
+
+// Error is an error raised while running a query, such as by error/1 or by applying an operation to unsupported JSON values.
+type Error struct {
+	// Value is the JSON value of the error, which is caught by try-catch.
+	Value jsonvalue.Value
+}
+
+func (e *Error) Error() string {
+	if e.Value.Type() == jsonvalue.TypeString {
+		return e.Value.StringGet()
+	}
+
+	return toJSON(e.Value) + " (not a string)"
+}
+
+func errorf(format string, args ...any) error {
+	return &Error{Value: jsonvalue.String(fmt.Sprintf(format, args...))}
+}
+
+// passError carries an error raised by the downstream of try or alternative so that it is not caught by them.
+type passError struct {
+	err error
+}
+
+func (e *passError) Error() string {
+	return e.err.Error()
+}
+
+// stopError stops a generator early, which is used by first(f), limit(n; f) and iteration.
+// It is compared by pointer, so it must not be zero-sized so that each instance has a distinct address.
+type stopError struct{ _ byte }
+
+func (e *stopError) Error() string {
+	return "stop"
+}
+
+// guard wraps emit so that the errors raised by emit are distinguished by unguard from the errors raised by the generator.
+func guard(emit emitFunc) emitFunc {
+	return func(o pv) error {
+		if err := emit(o); err != nil {
+			return &passError{err: err}
+		}
+		return nil
+	}
+}
+
+// unguard returns the error raised by emit wrapped by guard and whether err was raised by emit.
+func unguard(err error) (error, bool) {
+	if p, ok := err.(*passError); ok {
+		return p.err, true
+	}
+
+	return err, false
+}
+
+func isTruthy(v jsonvalue.Value) bool {
+	switch v.Type() {
+	case jsonvalue.TypeNull:
+		return false
+	case jsonvalue.TypeBoolean:
+		return v.BooleanGet()
+	default:
+		return true
+	}
+}
+
+func eval(n node, e *env, in pv, emit emitFunc) error {
+	if depth := e.depthCounter(); depth != nil {
+		if *depth >= maxEvalDepth {
+			return errorf(`evaluation is nested too deeply`)
+		}
+		*depth++
+		defer func() { *depth-- }()
+	}
+
+	switch n := n.(type) {
+	case *identityNode:
+		return emit(in)
+	case *literalNode:
+		return emit(derive(in, n.value))
+	case *variableNode:
+		v, ok := e.lookupVariable(n.name)
+		if !ok && n.name == "ENV" {
+			v, ok = environ(), true
+		}
+		if !ok {
+			return errorf(`$%s is not defined`, n.name)
+		}
+		return emit(derive(in, v))
+	case *formatNode:
+		s, err := applyFormat(n.name, in.value)
+		if err != nil {
+			return err
+		}
+		return emit(derive(in, jsonvalue.String(s)))
+	case *negateNode:
+		return eval(n.operand, e, plain(in), func(o pv) error {
+			if o.value.Type() != jsonvalue.TypeNumber {
+				return errorf(`%s (%s) cannot be negated`, typeName(o.value), toJSON(o.value))
+			}
+			return emit(derive(in, numberValue(-toFloat(o.value))))
+		})
+	case *pipeNode:
+		return eval(n.left, e, in, func(o pv) error {
+			return eval(n.right, e, o, emit)
+		})
+	case *commaNode:
+		if err := eval(n.left, e, in, emit); err != nil {
+			return err
+		}
+		return eval(n.right, e, in, emit)
+	case *arrayNode:
+		a := jsonvalue.Array()
+		if n.body != nil {
+			err := eval(n.body, e, plain(in), func(o pv) error {
+				a.ArrayAddElm(o.value)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return emit(derive(in, a))
+	case *objectNode:
+		return evalObject(n.entries, e, in, jsonvalue.Props{}, emit)
+	case *stringNode:
+		return evalString(n, len(n.parts)-1, "", e, in, emit)
+	case *indexNode:
+		return eval(n.index, e, plain(in), func(index pv) error {
+			return eval(n.target, e, in, func(target pv) error {
+				o, err := indexValue(target, index.value)
+				if err != nil {
+					return err
+				}
+				return emit(o)
+			})
+		})
+	case *sliceNode:
+		return evalSlice(n, e, in, emit)
+	case *iterateNode:
+		return eval(n.target, e, in, func(target pv) error {
+			return iterateValue(target, emit)
+		})
+	case *tryNode:
+		err := eval(n.body, e, in, guard(emit))
+		if err, ok := unguard(err); ok || err == nil {
+			return err
+		}
+		jqErr, ok := err.(*Error)
+		if !ok {
+			return err
+		}
+		if n.catch == nil {
+			return nil
+		}
+		return eval(n.catch, e, derive(in, jqErr.Value), emit)
+	case *binaryNode:
+		return eval(n.right, e, plain(in), func(r pv) error {
+			return eval(n.left, e, plain(in), func(l pv) error {
+				v, err := binaryOp(n.op, l.value, r.value)
+				if err != nil {
+					return err
+				}
+				return emit(derive(in, v))
+			})
+		})
+	case *andNode:
+		return eval(n.left, e, plain(in), func(l pv) error {
+			if !isTruthy(l.value) {
+				return emit(derive(in, jsonvalue.Boolean(false)))
+			}
+			return eval(n.right, e, plain(in), func(r pv) error {
+				return emit(derive(in, jsonvalue.Boolean(isTruthy(r.value))))
+			})
+		})
+	case *orNode:
+		return eval(n.left, e, plain(in), func(l pv) error {
+			if isTruthy(l.value) {
+				return emit(derive(in, jsonvalue.Boolean(true)))
+			}
+			return eval(n.right, e, plain(in), func(r pv) error {
+				return emit(derive(in, jsonvalue.Boolean(isTruthy(r.value))))
+			})
+		})
+	case *alternateNode:
+		found := false
+		guarded := guard(emit)
+		err := eval(n.left, e, in, func(o pv) error {
+			if !isTruthy(o.value) {
+				return nil
+			}
+			found = true
+			return guarded(o)
+		})
+		if err, ok := unguard(err); ok {
+			return err
+		}
+		if _, ok := err.(*Error); !ok && err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+		return eval(n.right, e, in, emit)
+	case *assignNode:
+		return evalAssign(n, e, in, emit)
+	case *ifNode:
+		return eval(n.cond, e, plain(in), func(c pv) error {
+			if isTruthy(c.value) {
+				return eval(n.then, e, in, emit)
+			}
+			if n.els == nil {
+				return emit(in)
+			}
+			return eval(n.els, e, in, emit)
+		})
+	case *bindNode:
+		return eval(n.source, e, plain(in), func(o pv) error {
+			return eval(n.body, e.bindVariable(n.name, o.value), in, emit)
+		})
+	case *reduceNode:
+		return eval(n.init, e, plain(in), func(init pv) error {
+			acc := init.value
+			err := eval(n.source, e, plain(in), func(x pv) error {
+				var last jsonvalue.Value = jsonvalue.Null()
+				err := eval(n.update, e.bindVariable(n.name, x.value), pv{value: acc}, func(o pv) error {
+					last = o.value
+					return nil
+				})
+				acc = last
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			return emit(derive(in, acc))
+		})
+	case *foreachNode:
+		return eval(n.init, e, plain(in), func(init pv) error {
+			acc := init.value
+			return eval(n.source, e, plain(in), func(x pv) error {
+				scope := e.bindVariable(n.name, x.value)
+				return eval(n.update, scope, pv{value: acc}, func(o pv) error {
+					acc = o.value
+					if n.extract == nil {
+						return emit(derive(in, o.value))
+					}
+					return eval(n.extract, scope, o, func(x pv) error {
+						return emit(derive(in, x.value))
+					})
+				})
+			})
+		})
+	case *funcDefNode:
+		return eval(n.rest, e.bindFunc(n.def), in, emit)
+	case *callNode:
+		return evalCall(n, e, in, emit)
+	default:
+		return fmt.Errorf(`unexpected node %T`, n)
+	}
+}
+
+func evalObject(entries []objectEntry, e *env, in pv, props jsonvalue.Props, emit emitFunc) error {
+	if len(entries) == 0 {
+		return emit(derive(in, jsonvalue.Object(props)))
+	}
+
+	entry := entries[0]
+	return eval(entry.key, e, plain(in), func(key pv) error {
+		if key.value.Type() != jsonvalue.TypeString {
+			return errorf(`object keys must be strings but got %s (%s)`, typeName(key.value), toJSON(key.value))
+		}
+		set := func(val pv) error {
+			next := jsonvalue.Props{key.value.StringGet(): val.value}
+			for k, v := range props {
+				if _, ok := next[k]; !ok {
+					next[k] = v
+				}
+			}
+			return evalObject(entries[1:], e, in, next, emit)
+		}
+		if entry.value == nil {
+			val, err := indexValue(plain(in), key.value)
+			if err != nil {
+				return err
+			}
+			return set(val)
+		}
+		return eval(entry.value, e, plain(in), set)
+	})
+}
+
+// evalString evaluates the parts of a string from the last one so that the later parts vary slower.
+func evalString(n *stringNode, i int, suffix string, e *env, in pv, emit emitFunc) error {
+	if i < 0 {
+		return emit(derive(in, jsonvalue.String(suffix)))
+	}
+
+	if t, ok := n.parts[i].(*textNode); ok {
+		return evalString(n, i-1, t.text+suffix, e, in, emit)
+	}
+
+	return eval(n.parts[i], e, plain(in), func(o pv) error {
+		format := n.format
+		if format == "" {
+			format = "text"
+		}
+		s, err := applyFormat(format, o.value)
+		if err != nil {
+			return err
+		}
+		return evalString(n, i-1, s+suffix, e, in, emit)
+	})
+}
+
+func appendPath(p jsonvalue.Path, k jsonvalue.Key) jsonvalue.Path {
+	q := make(jsonvalue.Path, len(p), len(p)+1)
+	copy(q, p)
+
+	return append(q, k)
+}
+
+func invalidPath(v jsonvalue.Value) error {
+	return errorf(`invalid path expression with result %s`, toJSON(v))
+}
+
+// indexValue returns .[index] of in, extending the path if tracked.
+func indexValue(in pv, index jsonvalue.Value) (pv, error) {
+	if in.mode == modeBroken {
+		return pv{}, invalidPath(in.value)
+	}
+
+	v := in.value
+	switch {
+	case index.Type() == jsonvalue.TypeString && (v.Type() == jsonvalue.TypeObject || v.Type() == jsonvalue.TypeNull):
+		out := pv{value: jsonvalue.Null(), mode: in.mode}
+		if v.Type() == jsonvalue.TypeObject && v.ObjectHasElm(index.StringGet()) {
+			out.value = v.ObjectGetElm(index.StringGet())
+		}
+		if in.mode == modeTracked {
+			out.path = appendPath(in.path, jsonvalue.KeyName(index.StringGet()))
+		}
+		return out, nil
+	case index.Type() == jsonvalue.TypeNumber && (v.Type() == jsonvalue.TypeArray || v.Type() == jsonvalue.TypeNull):
+		// huge indices are clamped so that they are not converted into negative ints
+		i := int(math.Min(math.Floor(toFloat(index)), math.MaxInt32))
+		if i < 0 && v.Type() == jsonvalue.TypeArray {
+			i += v.ArrayLen()
+		}
+		out := pv{value: jsonvalue.Null(), mode: in.mode}
+		if v.Type() == jsonvalue.TypeArray && 0 <= i && i < v.ArrayLen() {
+			out.value = v.ArrayGetElm(i)
+		}
+		if in.mode == modeTracked {
+			if i < 0 {
+				return pv{}, errorf(`out of bounds negative array index`)
+			}
+			out.path = appendPath(in.path, jsonvalue.KeyIndex(i))
+		}
+		return out, nil
+	case index.Type() == jsonvalue.TypeNull && v.Type() == jsonvalue.TypeNull:
+		return pv{value: jsonvalue.Null(), mode: in.mode, path: in.path}, nil
+	default:
+		if index.Type() == jsonvalue.TypeString {
+			return pv{}, errorf(`cannot index %s with "%s"`, typeName(v), index.StringGet())
+		}
+		return pv{}, errorf(`cannot index %s with %s`, typeName(v), typeName(index))
+	}
+}
+
+func iterateValue(in pv, emit emitFunc) error {
+	if in.mode == modeBroken {
+		return invalidPath(in.value)
+	}
+
+	v := in.value
+	switch v.Type() {
+	case jsonvalue.TypeArray:
+		for i, elm := range v.ArrayAll() {
+			out := pv{value: elm, mode: in.mode}
+			if in.mode == modeTracked {
+				out.path = appendPath(in.path, jsonvalue.KeyIndex(i))
+			}
+			if err := emit(out); err != nil {
+				return err
+			}
+		}
+		return nil
+	case jsonvalue.TypeObject:
+		for _, key := range sortedKeys(v) {
+			out := pv{value: v.ObjectGetElm(key), mode: in.mode}
+			if in.mode == modeTracked {
+				out.path = appendPath(in.path, jsonvalue.KeyName(key))
+			}
+			if err := emit(out); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errorf(`cannot iterate over %s`, describeValue(v))
+	}
+}
+
+func evalSlice(n *sliceNode, e *env, in pv, emit emitFunc) error {
+	bound := func(b node, f func(pv) error) error {
+		if b == nil {
+			return f(pv{value: jsonvalue.Null()})
+		}
+		return eval(b, e, plain(in), f)
+	}
+
+	return bound(n.to, func(to pv) error {
+		return bound(n.from, func(from pv) error {
+			return eval(n.target, e, in, func(target pv) error {
+				if target.mode != modePlain {
+					return errorf(`slices are not supported in path expressions`)
+				}
+				v, err := sliceValue(target.value, from.value, to.value)
+				if err != nil {
+					return err
+				}
+				return emit(pv{value: v})
+			})
+		})
+	})
+}
+
+func sliceValue(v, from, to jsonvalue.Value) (jsonvalue.Value, error) {
+	var length int
+	switch v.Type() {
+	case jsonvalue.TypeNull:
+		return jsonvalue.Null(), nil
+	case jsonvalue.TypeArray:
+		length = v.ArrayLen()
+	case jsonvalue.TypeString:
+		length = len([]rune(v.StringGet()))
+	default:
+		return nil, errorf(`cannot index %s with object`, typeName(v))
+	}
+
+	clamp := func(b jsonvalue.Value, def int) (int, error) {
+		switch b.Type() {
+		case jsonvalue.TypeNull:
+			return def, nil
+		case jsonvalue.TypeNumber:
+			i := int(toFloat(b))
+			if i < 0 {
+				i += length
+			}
+			return max(0, min(length, i)), nil
+		default:
+			return 0, errorf(`start and end indices of an array slice must be numbers`)
+		}
+	}
+	begin, err := clamp(from, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := clamp(to, length)
+	if err != nil {
+		return nil, err
+	}
+	end = max(begin, end)
+
+	if v.Type() == jsonvalue.TypeString {
+		return jsonvalue.String(string([]rune(v.StringGet())[begin:end])), nil
+	}
+
+	return v.ArraySlice(begin, end), nil
+}
+
+func evalCall(n *callNode, e *env, in pv, emit emitFunc) error {
+	if c, ok := e.lookupFunc(n.name, len(n.args)); ok {
+		return callClosure(c, n.args, e, in, emit)
+	}
+	if b, ok := builtins[funcKey(n.name, len(n.args))]; ok {
+		return b(n.args, e, in, emit)
+	}
+
+	return errorf(`%s/%d is not defined`, n.name, len(n.args))
+}
+
+func callClosure(c *closure, args []node, caller *env, in pv, emit emitFunc) error {
+	return bindParams(c, 0, &env{parent: c.env, depth: caller.depthCounter()}, args, caller, in, emit)
+}
+
+// bindParams binds the i-th and subsequent parameters and evaluates the body of the function.
+// A filter parameter is bound to a closure of the argument in the caller scope, and a value parameter is bound to each output of the argument.
+func bindParams(c *closure, i int, scope *env, args []node, caller *env, in pv, emit emitFunc) error {
+	if i == len(args) {
+		return eval(c.def.body, scope, in, emit)
+	}
+
+	param := c.def.params[i]
+	if param[0] != '$' {
+		scope = scope.bindClosure(&closure{def: &funcDef{name: param, body: args[i]}, env: caller})
+		return bindParams(c, i+1, scope, args, caller, in, emit)
+	}
+
+	name := param[1:]
+	return eval(args[i], caller, plain(in), func(arg pv) error {
+		scope := scope.bindVariable(name, arg.value)
+		scope = scope.bindClosure(&closure{def: &funcDef{name: name, body: &variableNode{name: name}}, env: scope})
+		return bindParams(c, i+1, scope, args, caller, in, emit)
+	})
+}
+
+// collectPaths returns the paths of the outputs of a path expression n applied to v.
+func collectPaths(n node, e *env, v jsonvalue.Value) ([]jsonvalue.Path, error) {
+	paths := []jsonvalue.Path{}
+	err := eval(n, e, pv{value: v, path: jsonvalue.Path{}, mode: modeTracked}, func(o pv) error {
+		if o.mode != modeTracked {
+			return invalidPath(o.value)
+		}
+		paths = append(paths, o.path)
+		return nil
+	})
+
+	return paths, err
+}
+
+func evalAssign(n *assignNode, e *env, in pv, emit emitFunc) error {
+	paths, err := collectPaths(n.left, e, in.value)
+	if err != nil {
+		return err
+	}
+
+	if n.op == "|=" {
+		result := in.value
+		deleted := []jsonvalue.Path{}
+		for _, path := range paths {
+			old, err := getPath(result, path)
+			if err != nil {
+				return err
+			}
+			updated, err := first(n.right, e, pv{value: old})
+			if err != nil {
+				return err
+			}
+			if updated == nil {
+				deleted = append(deleted, path)
+				continue
+			}
+			if result, err = setPath(result, path, updated); err != nil {
+				return err
+			}
+		}
+		if result, err = deletePaths(result, deleted); err != nil {
+			return err
+		}
+		return emit(derive(in, result))
+	}
+
+	return eval(n.right, e, plain(in), func(r pv) error {
+		result := in.value
+		for _, path := range paths {
+			updated := r.value
+			if n.op != "=" {
+				old, err := getPath(result, path)
+				if err != nil {
+					return err
+				}
+				if n.op == "//=" {
+					if isTruthy(old) {
+						updated = old
+					}
+				} else if updated, err = binaryOp(n.op[:1], old, r.value); err != nil {
+					return err
+				}
+			}
+			var err error
+			if result, err = setPath(result, path, updated); err != nil {
+				return err
+			}
+		}
+		return emit(derive(in, result))
+	})
+}
+
+// first returns the first output of n applied to in, or nil if there is no output.
+func first(n node, e *env, in pv) (jsonvalue.Value, error) {
+	var result jsonvalue.Value
+	stop := &stopError{}
+	err := eval(n, e, in, func(o pv) error {
+		result = o.value
+		return stop
+	})
+	if err != nil && err != stop {
+		return nil, err
+	}
+
+	return result, nil
+}
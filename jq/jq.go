@@ -0,0 +1,243 @@
+// Package jq implements an interpreter of a subset of the jq language for JSON values of jsonvalue.
+//
+// The supported features include pipes, commas, paths such as .foo, .[n], .[] and .[n:m], optional suffixes,
+// literals, array and object construction, string interpolation and formats such as @base64,
+// arithmetic, comparison and logical operators, alternatives, if-then-elif-else, try-catch,
+// variable bindings by "as", reduce, foreach, user functions by def, path expressions with path(f),
+// the assignment operators such as = and |=, and the common builtin functions such as select, map, keys, has,
+// to_entries, from_entries, with_entries, sort_by, group_by, paths, getpath, setpath and del.
+//
+// Unlike jq, the members of a JSON object are iterated in the order of their keys,
+// because JSON objects of jsonvalue do not keep the insertion order.
+package jq
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/Jumpaku/go-assert"
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+// Query is a compiled jq program.
+type Query struct {
+	root node
+}
+
+// Compile parses a jq program and checks that the functions and the variables used in it are defined.
+func Compile(expr string) (*Query, error) {
+	root, err := parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to compile jq program: %w`, err)
+	}
+	if err := check(root, newScope()); err != nil {
+		return nil, fmt.Errorf(`fail to compile jq program: %w`, err)
+	}
+
+	return &Query{root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the program cannot be compiled.
+func MustCompile(expr string) *Query {
+	q, err := Compile(expr)
+	assert.Params(err == nil, "jq program must be valid: %w", err)
+
+	return q
+}
+
+// All returns an iterator over the outputs of the program applied to an input v.
+// If an error is raised while running, it is yielded with a nil Value and the iteration stops.
+// The outputs are deep copies and v is not modified.
+func (q *Query) All(v jsonvalue.Value) iter.Seq2[jsonvalue.Value, error] {
+	return func(yield func(jsonvalue.Value, error) bool) {
+		stop := &stopError{}
+		err := eval(q.root, runScope(), pv{value: v}, func(o pv) error {
+			if !yield(o.value.Clone(), nil) {
+				return stop
+			}
+			return nil
+		})
+		if err != nil && err != stop {
+			yield(nil, err)
+		}
+	}
+}
+
+// Run returns the outputs of the program applied to an input v.
+// If an error is raised while running, Run returns the outputs before the error together with the error.
+// The outputs are deep copies and v is not modified.
+func (q *Query) Run(v jsonvalue.Value) ([]jsonvalue.Value, error) {
+	outputs := []jsonvalue.Value{}
+	for o, err := range q.All(v) {
+		if err != nil {
+			return outputs, err
+		}
+		outputs = append(outputs, o)
+	}
+
+	return outputs, nil
+}
+
+// scope holds the names of the functions and the variables visible at a node for checking.
+type scope struct {
+	parent   *scope
+	function string
+	variable string
+}
+
+func newScope() *scope {
+	s := &scope{variable: "ENV"}
+	for p := prelude; p != nil; p = p.parent {
+		if p.closure != nil {
+			s = s.withFunc(p.closure.def.name, len(p.closure.def.params))
+		}
+	}
+
+	return s
+}
+
+func (s *scope) withFunc(name string, arity int) *scope {
+	return &scope{parent: s, function: funcKey(name, arity)}
+}
+
+func (s *scope) withVariable(name string) *scope {
+	return &scope{parent: s, variable: name}
+}
+
+func (s *scope) hasFunc(name string, arity int) bool {
+	key := funcKey(name, arity)
+	for t := s; t != nil; t = t.parent {
+		if t.function == key {
+			return true
+		}
+	}
+	_, ok := builtins[key]
+
+	return ok
+}
+
+func (s *scope) hasVariable(name string) bool {
+	for t := s; t != nil; t = t.parent {
+		if t.function == "" && t.variable == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkDef(def *funcDef, s *scope) error {
+	body := s.withFunc(def.name, len(def.params))
+	for _, param := range def.params {
+		if param[0] == '$' {
+			body = body.withVariable(param[1:]).withFunc(param[1:], 0)
+		} else {
+			body = body.withFunc(param, 0)
+		}
+	}
+
+	return check(def.body, body)
+}
+
+// check reports an error if a function or a variable used in n is not defined.
+func check(n node, s *scope) error {
+	switch n := n.(type) {
+	case *identityNode, *literalNode, *textNode:
+		return nil
+	case *variableNode:
+		if !s.hasVariable(n.name) {
+			return fmt.Errorf(`$%s is not defined`, n.name)
+		}
+		return nil
+	case *formatNode:
+		if !isFormat(n.name) {
+			return fmt.Errorf(`@%s is not a valid format`, n.name)
+		}
+		return nil
+	case *negateNode:
+		return check(n.operand, s)
+	case *arrayNode:
+		if n.body == nil {
+			return nil
+		}
+		return check(n.body, s)
+	case *iterateNode:
+		return check(n.target, s)
+	case *pipeNode:
+		return checkAll(s, n.left, n.right)
+	case *commaNode:
+		return checkAll(s, n.left, n.right)
+	case *alternateNode:
+		return checkAll(s, n.left, n.right)
+	case *andNode:
+		return checkAll(s, n.left, n.right)
+	case *orNode:
+		return checkAll(s, n.left, n.right)
+	case *binaryNode:
+		return checkAll(s, n.left, n.right)
+	case *assignNode:
+		return checkAll(s, n.left, n.right)
+	case *indexNode:
+		return checkAll(s, n.target, n.index)
+	case *sliceNode:
+		return checkAll(s, n.target, n.from, n.to)
+	case *stringNode:
+		if n.format != "" {
+			if err := check(&formatNode{name: n.format}, s); err != nil {
+				return err
+			}
+		}
+		return checkAll(s, n.parts...)
+	case *objectNode:
+		for _, entry := range n.entries {
+			if err := checkAll(s, entry.key, entry.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ifNode:
+		return checkAll(s, n.cond, n.then, n.els)
+	case *tryNode:
+		return checkAll(s, n.body, n.catch)
+	case *bindNode:
+		if err := check(n.source, s); err != nil {
+			return err
+		}
+		return check(n.body, s.withVariable(n.name))
+	case *reduceNode:
+		if err := checkAll(s, n.source, n.init); err != nil {
+			return err
+		}
+		return check(n.update, s.withVariable(n.name))
+	case *foreachNode:
+		if err := checkAll(s, n.source, n.init); err != nil {
+			return err
+		}
+		return checkAll(s.withVariable(n.name), n.update, n.extract)
+	case *funcDefNode:
+		if err := checkDef(n.def, s); err != nil {
+			return err
+		}
+		return check(n.rest, s.withFunc(n.def.name, len(n.def.params)))
+	case *callNode:
+		if !s.hasFunc(n.name, len(n.args)) {
+			return fmt.Errorf(`%s/%d is not defined`, n.name, len(n.args))
+		}
+		return checkAll(s, n.args...)
+	default:
+		return fmt.Errorf(`unexpected node %T`, n)
+	}
+}
+
+func checkAll(s *scope, nodes ...node) error {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		if err := check(n, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
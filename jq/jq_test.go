@@ -0,0 +1,384 @@
+package jq_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+	"github.com/Jumpaku/go-json-value/jq"
+)
+
+func equal[T any](t *testing.T, got T, want T) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%#v\nwant:\n%#v", got, want)
+	}
+}
+
+func mustUnmarshal(t *testing.T, s string) jsonvalue.Value {
+	t.Helper()
+
+	v := jsonvalue.Null()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("fail to unmarshal %s: %v", s, err)
+	}
+	return v
+}
+
+func run(t *testing.T, program string, input string) []string {
+	t.Helper()
+
+	q, err := jq.Compile(program)
+	if err != nil {
+		t.Fatalf("fail to compile %s: %v", program, err)
+	}
+	outputs, err := q.Run(mustUnmarshal(t, input))
+	if err != nil {
+		t.Fatalf("fail to run %s: %v", program, err)
+	}
+
+	texts := []string{}
+	for _, o := range outputs {
+		b, err := json.Marshal(o)
+		if err != nil {
+			t.Fatalf("fail to marshal %v: %v", o, err)
+		}
+		texts = append(texts, string(b))
+	}
+	return texts
+}
+
+type testcase struct {
+	program string
+	input   string
+	want    []string
+}
+
+func runTestcases(t *testing.T, testcases []testcase) {
+	t.Helper()
+	for _, tc := range testcases {
+		t.Run(tc.program, func(t *testing.T) {
+			equal(t, run(t, tc.program, tc.input), tc.want)
+		})
+	}
+}
+
+func TestQuery_Run_Basic(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`.`, `{"a":1}`, []string{`{"a":1}`}},
+		{`.a`, `{"a":1}`, []string{`1`}},
+		{`.a.b`, `{"a":{"b":"x"}}`, []string{`"x"`}},
+		{`."a b"`, `{"a b":1}`, []string{`1`}},
+		{`.["a"]`, `{"a":1}`, []string{`1`}},
+		{`.missing`, `{"a":1}`, []string{`null`}},
+		{`.[1]`, `[1,2,3]`, []string{`2`}},
+		{`.[-1]`, `[1,2,3]`, []string{`3`}},
+		{`.[5]`, `[1,2,3]`, []string{`null`}},
+		{`.[1:]`, `[1,2,3]`, []string{`[2,3]`}},
+		{`.[:-1]`, `[1,2,3]`, []string{`[1,2]`}},
+		{`.[1:3]`, `"abcd"`, []string{`"bc"`}},
+		{`.[]`, `[1,2,3]`, []string{`1`, `2`, `3`}},
+		{`.[]`, `{"b":2,"a":1}`, []string{`1`, `2`}},
+		{`.a[].b`, `{"a":[{"b":1},{"b":2}]}`, []string{`1`, `2`}},
+		{`.[]?`, `1`, []string{}},
+		{`.a?`, `[1]`, []string{}},
+		{`..`, `[[1]]`, []string{`[[1]]`, `[1]`, `1`}},
+		{`.a, .b`, `{"a":1,"b":2}`, []string{`1`, `2`}},
+		{`.a | .b`, `{"a":{"b":3}}`, []string{`3`}},
+		{`1, "x", null, true, [1,2], {"k": 1}`, `null`, []string{`1`, `"x"`, `null`, `true`, `[1,2]`, `{"k":1}`}},
+		{`[.[] | . * 2]`, `[1,2,3]`, []string{`[2,4,6]`}},
+		{`[]`, `null`, []string{`[]`}},
+	})
+}
+
+func TestQuery_Run_Object(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`{a, b: .c}`, `{"a":1,"c":2}`, []string{`{"a":1,"b":2}`}},
+		{`{"x y": 1, (.k): 2}`, `{"k":"z"}`, []string{`{"x y":1,"z":2}`}},
+		{`{a: (1,2)}`, `null`, []string{`{"a":1}`, `{"a":2}`}},
+		{`. as $x | {$x}`, `1`, []string{`{"x":1}`}},
+		{`{"\(.k)": .v}`, `{"k":"a","v":1}`, []string{`{"a":1}`}},
+		{`{a: .x | . + 1}`, `{"x":1}`, []string{`{"a":2}`}},
+		{`{if: 1}`, `null`, []string{`{"if":1}`}},
+	})
+}
+
+func TestQuery_Run_Operators(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`1 + 2 * 3`, `null`, []string{`7`}},
+		{`(1 + 2) * 3`, `null`, []string{`9`}},
+		{`10 / 4, 10 % 3, -.`, `5`, []string{`2.5`, `1`, `-5`}},
+		{`"a" + "b", [1] + [2], {"a":1} + {"b":2}, null + 1`, `null`, []string{`"ab"`, `[1,2]`, `{"a":1,"b":2}`, `1`}},
+		{`[1,2,3,1] - [1]`, `null`, []string{`[2,3]`}},
+		{`{"a":{"b":1}} * {"a":{"c":2}}`, `null`, []string{`{"a":{"b":1,"c":2}}`}},
+		{`"a,b" / ","`, `null`, []string{`["a","b"]`}},
+		{`"ab" * 2`, `null`, []string{`"abab"`}},
+		{`"ab" * 0, "ab" * -1, "ab" * 0.5`, `null`, []string{`null`, `null`, `"ab"`}},
+		{`.[3] = 1`, `null`, []string{`[null,null,null,1]`}},
+		{`(1,2) + (10,20)`, `null`, []string{`11`, `12`, `21`, `22`}},
+		{`1 == 1.0, 1 != 2, 1 < "a", [] > {}`, `null`, []string{`true`, `true`, `true`, `false`}},
+		{`true and false, true or false, (null | not)`, `null`, []string{`false`, `true`, `true`}},
+		{`.a // "default"`, `{}`, []string{`"default"`}},
+		{`.a // "default"`, `{"a":false}`, []string{`"default"`}},
+		{`(.[] | select(. > 1)) // 0`, `[1,2,3]`, []string{`2`, `3`}},
+		{`error("x") // 1`, `null`, []string{`1`}},
+	})
+}
+
+func TestQuery_Run_Control(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`if . > 1 then "big" elif . == 1 then "one" else "small" end`, `2`, []string{`"big"`}},
+		{`if . > 1 then "big" elif . == 1 then "one" else "small" end`, `1`, []string{`"one"`}},
+		{`if . > 1 then "big" end`, `0`, []string{`0`}},
+		{`try error("x") catch .`, `null`, []string{`"x"`}},
+		{`try error({"a":1}) catch .a`, `null`, []string{`1`}},
+		{`[.[] | try (if . == 2 then error("e") else . end)]`, `[1,2,3]`, []string{`[1,3]`}},
+		{`try (1, error("x"), 3) catch .`, `null`, []string{`1`, `"x"`}},
+		{`.[] as $x | $x * 10`, `[1,2]`, []string{`10`, `20`}},
+		{`. as $x | [1,2] | map(. + $x)`, `10`, []string{`[11,12]`}},
+		{`reduce .[] as $x (0; . + $x)`, `[1,2,3]`, []string{`6`}},
+		{`reduce empty as $x (0; . + 1)`, `null`, []string{`0`}},
+		{`foreach .[] as $x (0; . + $x)`, `[1,2,3]`, []string{`1`, `3`, `6`}},
+		{`foreach .[] as $x (0; . + $x; [$x, .])`, `[1,2]`, []string{`[1,1]`, `[2,3]`}},
+		{`[limit(3; range(10))]`, `null`, []string{`[0,1,2]`}},
+		{`first(range(10;20)), last(range(3))`, `null`, []string{`10`, `2`}},
+		{`[range(0; 10; 3)], [range(5; 0; -2)]`, `null`, []string{`[0,3,6,9]`, `[5,3,1]`}},
+		{`isempty(empty), isempty(1)`, `null`, []string{`true`, `false`}},
+		{`[.[] | numbers]`, `[1,"a",null,2]`, []string{`[1,2]`}},
+		{`[1,2] | until(length > 4; . + .)`, `null`, []string{`[1,2,1,2,1,2,1,2]`}},
+		{`[1 | while(. < 8; . * 2)]`, `null`, []string{`[1,2,4]`}},
+		{`0 | until(. >= 10000; . + 1)`, `null`, []string{`10000`}},
+		{`try (def f: f; f) catch .`, `null`, []string{`"evaluation is nested too deeply"`}},
+	})
+}
+
+func TestQuery_Run_String(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`"x=\(.x), y=\(.y)"`, `{"x":1,"y":"a"}`, []string{`"x=1, y=a"`}},
+		{`"\(1,2)-\(3,4)"`, `null`, []string{`"1-3"`, `"2-3"`, `"1-4"`, `"2-4"`}},
+		{`"nested \("in \("ner")")"`, `null`, []string{`"nested in ner"`}},
+		{`@base64, (@base64 | @base64d)`, `"hello"`, []string{`"aGVsbG8="`, `"hello"`}},
+		{`@csv, @tsv`, `[1,"a\"b",null,"c\td"]`, []string{`"1,\"a\"\"b\",,\"c\td\""`, `"1\ta\"b\t\tc\\td"`}},
+		{`@html == "&lt;a href=&#39;x&#39;&gt;"`, `"<a href='x'>"`, []string{`true`}},
+		{`@uri "q=\(.)"`, `"a b&c"`, []string{`"q=a%20b%26c"`}},
+		{`@json "v=\(.)"`, `{"a":"b"}`, []string{`"v={\"a\":\"b\"}"`}},
+		{`@sh "echo \(.)"`, `"it's"`, []string{`"echo 'it'\\''s'"`}},
+		{`"é😀"`, `null`, []string{`"é😀"`}},
+		{`"\(1)", "\(null)", "x\(1)", "\("a")"`, `null`, []string{`"1"`, `"null"`, `"x1"`, `"a"`}},
+		{`@json "v=\("x")"`, `null`, []string{`"v=\"x\""`}},
+		{`@sh "echo \("a'b")"`, `null`, []string{`"echo 'a'\\''b'"`}},
+		{`@uri "\("a'b")"`, `null`, []string{`"a%27b"`}},
+		{`@text "\(1)"`, `null`, []string{`"1"`}},
+	})
+}
+
+func TestQuery_Run_Functions(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`def inc: . + 1; map(inc)`, `[1,2]`, []string{`[2,3]`}},
+		{`def f(g): [g, g]; f(.a)`, `{"a":1}`, []string{`[1,1]`}},
+		{`def f($a; $b): $a + $b; f(.x; .y)`, `{"x":1,"y":2}`, []string{`3`}},
+		{`def f(a): a + 1; f(10, 20)`, `null`, []string{`11`, `21`}},
+		{`def fac: if . <= 1 then 1 else . * (. - 1 | fac) end; fac`, `5`, []string{`120`}},
+		{`def f: def g: 3; g * 2; f`, `null`, []string{`6`}},
+		{`def f(x): x * 2; def g: f(.); g`, `3`, []string{`6`}},
+		{`def map(f): "shadowed"; map(.)`, `[1]`, []string{`"shadowed"`}},
+		{`def f: reduce .[] as $x (0; . + $x); [[1,2],[3]] | map(f)`, `null`, []string{`[3,3]`}},
+	})
+}
+
+func TestQuery_Run_Builtins(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`length`, `[1,2]`, []string{`2`}},
+		{`map(length)`, `["abc", {"a":1}, null, -3]`, []string{`[3,1,0,3]`}},
+		{`keys, has("a"), has("z")`, `{"b":1,"a":2}`, []string{`["a","b"]`, `true`, `false`}},
+		{`map(select(.a > 1))`, `[{"a":1},{"a":2},{"a":3}]`, []string{`[{"a":2},{"a":3}]`}},
+		{`to_entries`, `{"a":1,"b":2}`, []string{`[{"key":"a","value":1},{"key":"b","value":2}]`}},
+		{`from_entries`, `[{"key":"a","value":1},{"k":"b","v":2},{"name":1,"value":3}]`, []string{`{"1":3,"a":1,"b":2}`}},
+		{`with_entries(.value += 1)`, `{"a":1,"b":2}`, []string{`{"a":2,"b":3}`}},
+		{`with_entries(select(.key != "a"))`, `{"a":1,"b":2}`, []string{`{"b":2}`}},
+		{`add`, `[1,2,3]`, []string{`6`}},
+		{`add`, `["a","b"]`, []string{`"ab"`}},
+		{`any, all`, `[true,false]`, []string{`true`, `false`}},
+		{`any(. > 2), all(. > 0)`, `[1,2,3]`, []string{`true`, `true`}},
+		{`sort, sort_by(-.), unique, min, max, reverse`, `[3,1,2,1]`, []string{`[1,1,2,3]`, `[3,2,1,1]`, `[1,2,3]`, `1`, `3`, `[1,2,1,3]`}},
+		{`group_by(.t) | map(map(.v))`, `[{"t":"a","v":1},{"t":"b","v":2},{"t":"a","v":3}]`, []string{`[[1,3],[2]]`}},
+		{`unique_by(.t) | map(.v)`, `[{"t":"a","v":1},{"t":"b","v":2},{"t":"a","v":3}]`, []string{`[1,2]`}},
+		{`min_by(.v).v, max_by(.v).v`, `[{"t":"a","v":1},{"t":"b","v":2},{"t":"a","v":3}]`, []string{`1`, `3`}},
+		{`tostring, tojson, (tojson | fromjson)`, `{"a":[1]}`, []string{`"{\"a\":[1]}"`, `"{\"a\":[1]}"`, `{"a":[1]}`}},
+		{`map(tonumber)`, `["1.5", 2]`, []string{`[1.5,2]`}},
+		{`map(type)`, `[null,true,1,"a",[],{}]`, []string{`["null","boolean","number","string","array","object"]`}},
+		{`split(", ")`, `"a, b"`, []string{`["a","b"]`}},
+		{`join("-")`, `["a",1,null,true]`, []string{`"a-1--true"`}},
+		{`ascii_downcase, ascii_upcase`, `"aBc"`, []string{`"abc"`, `"ABC"`}},
+		{`ltrimstr("a"), rtrimstr("c"), startswith("ab"), endswith("x")`, `"abc"`, []string{`"bc"`, `"ab"`, `true`, `false`}},
+		{`test("B"), test("B"; "i")`, `"abc"`, []string{`false`, `true`}},
+		{`explode, (explode | implode)`, `"ab"`, []string{`[97,98]`, `"ab"`}},
+		{`contains({"a":[1]}), contains({"b":1})`, `{"a":[1,2],"c":"x"}`, []string{`true`, `false`}},
+		{`contains("bar")`, `"foobar"`, []string{`true`}},
+		{`inside([1,2,3])`, `[1]`, []string{`true`}},
+		{`flatten, flatten(1)`, `[1,[2,[3]]]`, []string{`[1,2,3]`, `[1,2,[3]]`}},
+		{`floor, ceil, round, sqrt`, `4`, []string{`4`, `4`, `4`, `2`}},
+		{`pow(2; 10), (16 | sqrt)`, `null`, []string{`1024`, `4`}},
+		{`walk(if type == "number" then . + 1 else . end)`, `{"a":[1,{"b":2}]}`, []string{`{"a":[2,{"b":3}]}`}},
+		{`map_values(. * 10)`, `{"a":1,"b":2}`, []string{`{"a":10,"b":20}`}},
+		{`first, last, nth(1)`, `[1,2,3]`, []string{`1`, `3`, `2`}},
+		{`[recurse(if . < 3 then . + 1 else empty end)]`, `0`, []string{`[0,1,2,3]`}},
+		{`IN(1, 2), IN(3)`, `2`, []string{`true`, `false`}},
+		{`toarray, (1 | toarray)`, `[1]`, []string{`[1]`, `[1]`}},
+	})
+}
+
+func TestQuery_Run_Paths(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`path(.a[0].b)`, `null`, []string{`["a",0,"b"]`}},
+		{`[path(..)]`, `{"a":[1]}`, []string{`[[],["a"],["a",0]]`}},
+		{`[paths]`, `{"a":[1],"b":2}`, []string{`[["a"],["a",0],["b"]]`}},
+		{`[leaf_paths]`, `{"a":[1],"b":2}`, []string{`[["a",0],["b"]]`}},
+		{`[paths(type == "number")]`, `{"a":[1],"b":"x"}`, []string{`[["a",0]]`}},
+		{`path(.[] | select(. > 1))`, `[1,2,3]`, []string{`[1]`, `[2]`}},
+		{`path(.a // .b)`, `{"b":1}`, []string{`["b"]`}},
+		{`path(if .a then .a else .b end)`, `{"a":1}`, []string{`["a"]`}},
+		{`path(getpath(["a","b"]))`, `null`, []string{`["a","b"]`}},
+		{`path(.[-1])`, `[1,2]`, []string{`[1]`}},
+		{`getpath(["a",0,"b"]), getpath(["x","y"])`, `{"a":[{"b":1}]}`, []string{`1`, `null`}},
+		{`setpath(["a",1]; 5)`, `{"a":[0]}`, []string{`{"a":[0,5]}`}},
+		{`setpath([]; 1)`, `{"a":[0]}`, []string{`1`}},
+		{`delpaths([["a",0],["b"]])`, `{"a":[1,2],"b":1,"c":1}`, []string{`{"a":[2],"c":1}`}},
+		{`del(.a, .c)`, `{"a":1,"b":2,"c":3}`, []string{`{"b":2}`}},
+		{`del(.[1, 2])`, `[0,1,2,3]`, []string{`[0,3]`}},
+		{`del(.[] | select(. % 2 == 0))`, `[1,2,3,4]`, []string{`[1,3]`}},
+		{`to_entries | map(.key)`, `[10,20]`, []string{`[0,1]`}},
+	})
+}
+
+func TestQuery_Run_Assign(t *testing.T) {
+	runTestcases(t, []testcase{
+		{`.a = 1`, `{}`, []string{`{"a":1}`}},
+		{`.a = .b`, `{"b":2}`, []string{`{"a":2,"b":2}`}},
+		{`.a.b |= . + 1`, `{"a":{"b":1}}`, []string{`{"a":{"b":2}}`}},
+		{`.[] |= . * 2`, `[1,2]`, []string{`[2,4]`}},
+		{`.[] += 1, .[] -= 1, .[] *= 2, .[] /= 2, .[] %= 2`, `[3]`, []string{`[4]`, `[2]`, `[6]`, `[1.5]`, `[1]`}},
+		{`.a //= 5`, `{"a":1}`, []string{`{"a":1}`}},
+		{`.b //= 5`, `{"a":1}`, []string{`{"a":1,"b":5}`}},
+		{`(.a, .b) = (1, 2)`, `null`, []string{`{"a":1,"b":1}`, `{"a":2,"b":2}`}},
+		{`.[] |= empty`, `[1,2,3]`, []string{`[]`}},
+		{`(.[] | select(. > 1)) |= 0`, `[1,2,3]`, []string{`[1,0,0]`}},
+		{`.a[2] = 1`, `{}`, []string{`{"a":[null,null,1]}`}},
+	})
+}
+
+func TestQuery_Run_Variables(t *testing.T) {
+	t.Setenv("JQ_TEST_VAR", "value")
+	runTestcases(t, []testcase{
+		{`$ENV.JQ_TEST_VAR, env.JQ_TEST_VAR`, `null`, []string{`"value"`, `"value"`}},
+		{`$__loc__ | .line, .file == "<stdin>"`, `null`, []string{`1`, `true`}},
+	})
+}
+
+func TestQuery_Run_Error(t *testing.T) {
+	testcases := []struct {
+		program string
+		input   string
+		want    string
+		outputs int
+	}{
+		{`error("boom")`, `null`, `boom`, 0},
+		{`error({"a":1})`, `null`, `{"a":1} (not a string)`, 0},
+		{`.a`, `[1]`, `cannot index array with "a"`, 0},
+		{`.[0]`, `{}`, `cannot index object with number`, 0},
+		{`.[]`, `1`, `cannot iterate over number (1)`, 0},
+		{`1, 2, error("x"), 4`, `null`, `x`, 2},
+		{`{} + 1`, `null`, `object ({}) and number (1) cannot be added`, 0},
+		{`1 / 0`, `null`, `number (1) and number (0) cannot be divided because the divisor is zero`, 0},
+		{`path(1)`, `null`, `invalid path expression with result 1`, 0},
+		{`path(.a | tostring)`, `null`, `invalid path expression with result "null"`, 0},
+		{`(try 1) | error("y")`, `null`, `y`, 0},
+		{`try (1 | error("inner")) catch error("outer: " + .)`, `null`, `outer: inner`, 0},
+		{`first(1, error("x")), error("y")`, `null`, `y`, 1},
+		{`"a" * 1e19`, `null`, `repeat string result too long`, 0},
+		{`"a" * .`, `1e1000`, `repeat string result too long`, 0},
+		{`"ab" * 1e8`, `null`, `repeat string result too long`, 0},
+		{`.[1e8] = 1`, `null`, `array index too large`, 0},
+		{`.[1e19] = 1`, `[]`, `array index too large`, 0},
+		{`setpath([1e8]; 1)`, `null`, `array index too large`, 0},
+		{`setpath([1e19]; 1)`, `null`, `array index too large`, 0},
+		{`fromjson`, `"{"`, `unexpected end of JSON input (while parsing '{')`, 0},
+		{`fromjson`, `"1 2"`, `invalid character '2' after top-level value (while parsing '1 2')`, 0},
+		{`fromjson`, `1`, `number (1) cannot be parsed, as it is not a string`, 0},
+		{`tonumber`, `"0x10"`, `cannot parse '0x10' as a number`, 0},
+		{`tonumber`, `"1 2"`, `cannot parse '1 2' as a number`, 0},
+		{`def f: f; f`, `null`, `evaluation is nested too deeply`, 0},
+		{`def f(g): g | f(g); f(.)`, `null`, `evaluation is nested too deeply`, 0},
+		{`last(repeat(1))`, `null`, `evaluation is nested too deeply`, 0},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.program, func(t *testing.T) {
+			outputs, err := jq.MustCompile(tc.program).Run(mustUnmarshal(t, tc.input))
+			equal(t, len(outputs), tc.outputs)
+			if err == nil {
+				t.Fatalf("error expected")
+			}
+			equal(t, err.Error(), tc.want)
+			var jqErr *jq.Error
+			equal(t, errors.As(err, &jqErr), true)
+		})
+	}
+}
+
+func TestCompile_Error(t *testing.T) {
+	programs := []string{
+		`.[`,
+		`{a: }`,
+		`"unterminated`,
+		`1 +`,
+		`if . then 1`,
+		`undefined_function`,
+		`map`,
+		`$undefined`,
+		`def f: $x; . as $x | f`,
+		`@unknown`,
+		`1 == 2 == 3`,
+		`reduce . as $x (0)`,
+		`"\(1"`,
+	}
+	for _, program := range programs {
+		t.Run(program, func(t *testing.T) {
+			_, err := jq.Compile(program)
+			if err == nil {
+				t.Errorf("error expected")
+			}
+		})
+	}
+}
+
+func TestQuery_All(t *testing.T) {
+	q := jq.MustCompile(`range(100)`)
+	got := []string{}
+	for v, err := range q.All(jsonvalue.Null()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.NumberGet().String())
+		if len(got) == 3 {
+			break
+		}
+	}
+	equal(t, got, []string{"0", "1", "2"})
+}
+
+func TestQuery_Run_Immutable(t *testing.T) {
+	v := mustUnmarshal(t, `{"a":{"b":[1,2]}}`)
+	outputs, err := jq.MustCompile(`.a.b[0] = 9, (.a.b |= map(. + 1)), del(.a)`).Run(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal(t, len(outputs), 3)
+
+	b, _ := json.Marshal(v)
+	equal(t, string(b), `{"a":{"b":[1,2]}}`)
+
+	outputs[0].ObjectSetElm("x", jsonvalue.Null())
+	equal(t, v.ObjectHasElm("x"), false)
+}
@@ -0,0 +1,324 @@
+package jq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenField
+	tokenVariable
+	tokenNumber
+	tokenString
+	tokenFormat
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	// text is the identifier, the keyword, the field name without '.', the variable name without '$', the number literal, the format name without '@' or the punctuation.
+	text string
+	// parts holds the literal parts and the source texts of the interpolations of a string.
+	parts []stringPart
+	pos   int
+}
+
+type stringPart struct {
+	literal       string
+	interpolation string
+	isExpr        bool
+}
+
+var keywords = map[string]bool{
+	"def": true, "if": true, "then": true, "elif": true, "else": true, "end": true,
+	"as": true, "reduce": true, "foreach": true, "try": true, "catch": true,
+	"and": true, "or": true, "__loc__": true,
+}
+
+// puncts are ordered so that longer punctuations are matched first.
+var puncts = []string{
+	"?//", "//=", "|=", "+=", "-=", "*=", "/=", "%=", "==", "!=", "<=", ">=", "//", "..",
+	".", "[", "]", "{", "}", "(", ")", "|", ",", ":", ";", "=", "<", ">", "+", "-", "*", "/", "%", "?",
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func tokenize(src string) ([]token, error) {
+	l := &lexer{src: src}
+	tokens := []token{}
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return fmt.Errorf(`syntax error at %d: %s`, l.pos, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) skipSpaces() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (l *lexer) ident() string {
+	begin := l.pos
+	for l.pos < len(l.src) && (isIdentPart(l.src[l.pos]) || (l.src[l.pos] == ':' && l.pos+1 < len(l.src) && l.src[l.pos+1] == ':')) {
+		if l.src[l.pos] == ':' {
+			l.pos++
+		}
+		l.pos++
+	}
+
+	return l.src[begin:l.pos]
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpaces()
+	pos := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: pos}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case isIdentStart(c):
+		name := l.ident()
+		if keywords[name] {
+			return token{kind: tokenKeyword, text: name, pos: pos}, nil
+		}
+		return token{kind: tokenIdent, text: name, pos: pos}, nil
+	case c == '$':
+		l.pos++
+		if l.pos >= len(l.src) || !isIdentStart(l.src[l.pos]) {
+			return token{}, l.errorf(`variable name expected after '$'`)
+		}
+		return token{kind: tokenVariable, text: l.ident(), pos: pos}, nil
+	case c == '@':
+		l.pos++
+		if l.pos >= len(l.src) || !isIdentStart(l.src[l.pos]) {
+			return token{}, l.errorf(`format name expected after '@'`)
+		}
+		return token{kind: tokenFormat, text: l.ident(), pos: pos}, nil
+	case c == '.' && l.pos+1 < len(l.src) && isIdentStart(l.src[l.pos+1]):
+		l.pos++
+		begin := l.pos
+		for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenField, text: l.src[begin:l.pos], pos: pos}, nil
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		return l.number()
+	case c == '"':
+		parts, err := l.string()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenString, parts: parts, pos: pos}, nil
+	}
+
+	for _, p := range puncts {
+		if strings.HasPrefix(l.src[l.pos:], p) {
+			l.pos += len(p)
+			return token{kind: tokenPunct, text: p, pos: pos}, nil
+		}
+	}
+
+	return token{}, l.errorf(`unexpected character %q`, c)
+}
+
+func (l *lexer) number() (token, error) {
+	pos := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		digits := l.pos
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		if digits == l.pos {
+			return token{}, l.errorf(`invalid number literal %q`, l.src[pos:l.pos])
+		}
+	}
+
+	text := l.src[pos:l.pos]
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return token{}, l.errorf(`invalid number literal %q`, text)
+	}
+
+	return token{kind: tokenNumber, text: text, pos: pos}, nil
+}
+
+// string scans a string literal which may contain interpolations \(...).
+func (l *lexer) string() ([]stringPart, error) {
+	l.pos++
+	parts := []stringPart{}
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return nil, l.errorf(`unterminated string`)
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			if b.Len() > 0 || len(parts) == 0 {
+				parts = append(parts, stringPart{literal: b.String()})
+			}
+			return parts, nil
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return nil, l.errorf(`unterminated string`)
+			}
+			e := l.src[l.pos+1]
+			l.pos += 2
+			switch e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				r, err := l.unicodeEscape()
+				if err != nil {
+					return nil, err
+				}
+				b.WriteRune(r)
+			case '(':
+				src, err := l.interpolation()
+				if err != nil {
+					return nil, err
+				}
+				if b.Len() > 0 {
+					parts = append(parts, stringPart{literal: b.String()})
+					b.Reset()
+				}
+				parts = append(parts, stringPart{interpolation: src, isExpr: true})
+			default:
+				return nil, l.errorf(`invalid escape sequence \%c`, e)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.pos += size
+		}
+	}
+}
+
+func (l *lexer) hex4() (rune, error) {
+	if l.pos+4 > len(l.src) {
+		return 0, l.errorf(`invalid unicode escape`)
+	}
+	n, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 16)
+	if err != nil {
+		return 0, l.errorf(`invalid unicode escape`)
+	}
+	l.pos += 4
+
+	return rune(n), nil
+}
+
+func (l *lexer) unicodeEscape() (rune, error) {
+	r, err := l.hex4()
+	if err != nil {
+		return 0, err
+	}
+	if utf16.IsSurrogate(r) && strings.HasPrefix(l.src[l.pos:], `\u`) {
+		l.pos += 2
+		r2, err := l.hex4()
+		if err != nil {
+			return 0, err
+		}
+		return utf16.DecodeRune(r, r2), nil
+	}
+
+	return r, nil
+}
+
+// interpolation returns the source text of an interpolation up to the matching ')'.
+func (l *lexer) interpolation() (string, error) {
+	begin := l.pos
+	depth := 1
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '(':
+			depth++
+			l.pos++
+		case ')':
+			depth--
+			l.pos++
+			if depth == 0 {
+				return l.src[begin : l.pos-1], nil
+			}
+		case '"':
+			if _, err := l.string(); err != nil {
+				return "", err
+			}
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			l.pos++
+		}
+	}
+
+	return "", l.errorf(`unterminated string interpolation`)
+}
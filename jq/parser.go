@@ -0,0 +1,840 @@
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, `unexpected token %s`, describe(t))
+	}
+
+	return n, nil
+}
+
+// parseDefs parses a sequence of function definitions such as the builtin definitions.
+func parseDefs(src string) ([]*funcDef, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	defs := []*funcDef{}
+	for p.isKeyword("def") {
+		def, err := p.parseFuncDef()
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, `unexpected token %s`, describe(t))
+	}
+
+	return defs, nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenField:
+		return "." + t.text
+	case tokenVariable:
+		return "$" + t.text
+	case tokenFormat:
+		return "@" + t.text
+	case tokenString:
+		return "string"
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf(`syntax error at %d: %s`, t.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) isPunct(texts ...string) bool {
+	t := p.peek()
+	if t.kind != tokenPunct {
+		return false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) isKeyword(text string) bool {
+	t := p.peek()
+	return t.kind == tokenKeyword && t.text == text
+}
+
+func (p *parser) expectPunct(text string) error {
+	if !p.isPunct(text) {
+		t := p.peek()
+		return p.errorf(t, `%q expected but got %s`, text, describe(t))
+	}
+	p.advance()
+
+	return nil
+}
+
+func (p *parser) expectKeyword(text string) error {
+	if !p.isKeyword(text) {
+		t := p.peek()
+		return p.errorf(t, `%q expected but got %s`, text, describe(t))
+	}
+	p.advance()
+
+	return nil
+}
+
+func (p *parser) expectVariable() (string, error) {
+	t := p.peek()
+	if t.kind != tokenVariable {
+		return "", p.errorf(t, `variable expected but got %s`, describe(t))
+	}
+	p.advance()
+
+	return t.text, nil
+}
+
+// parsePipe parses definitions, bindings and pipes, which have the lowest precedence.
+func (p *parser) parsePipe() (node, error) {
+	if p.isKeyword("def") {
+		def, err := p.parseFuncDef()
+		if err != nil {
+			return nil, err
+		}
+		rest, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return &funcDefNode{def: def, rest: rest}, nil
+	}
+
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("as") {
+		p.advance()
+		name, err := p.expectVariable()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("|"); err != nil {
+			return nil, err
+		}
+		body, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return &bindNode{source: left, name: name, body: body}, nil
+	}
+
+	if p.isPunct("|") {
+		p.advance()
+		right, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return &pipeNode{left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseFuncDef() (*funcDef, error) {
+	if err := p.expectKeyword("def"); err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenKeyword {
+		return nil, p.errorf(t, `function name expected but got %s`, describe(t))
+	}
+	p.advance()
+
+	def := &funcDef{name: t.text}
+	if p.isPunct("(") {
+		p.advance()
+		for {
+			t := p.advance()
+			switch t.kind {
+			case tokenIdent:
+				def.params = append(def.params, t.text)
+			case tokenVariable:
+				def.params = append(def.params, "$"+t.text)
+			default:
+				return nil, p.errorf(t, `parameter expected but got %s`, describe(t))
+			}
+			if !p.isPunct(";") {
+				break
+			}
+			p.advance()
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+
+	body, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	def.body = body
+	if err := p.expectPunct(";"); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	left, err := p.parseAlternate()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct(",") {
+		p.advance()
+		right, err := p.parseAlternate()
+		if err != nil {
+			return nil, err
+		}
+		left = &commaNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAlternate() (node, error) {
+	left, err := p.parseAssign()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("//") {
+		p.advance()
+		right, err := p.parseAlternate()
+		if err != nil {
+			return nil, err
+		}
+		return &alternateNode{left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAssign() (node, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("=", "|=", "+=", "-=", "*=", "/=", "%=", "//=") {
+		op := p.advance().text
+		right, err := p.parseAlternate()
+		if err != nil {
+			return nil, err
+		}
+		return &assignNode{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.advance()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("==", "!=", "<", "<=", ">", ">=") {
+		op := p.advance().text
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if p.isPunct("==", "!=", "<", "<=", ">", ">=") {
+			return nil, p.errorf(p.peek(), `comparison operators are non-associative`)
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("+", "-") {
+		op := p.advance().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("*", "/", "%") {
+		op := p.advance().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isPunct("-") {
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+// parsePostfix parses a term followed by suffixes such as .foo, [e], [], [e:e] and ?.
+func (p *parser) parsePostfix() (node, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch t := p.peek(); {
+		case t.kind == tokenField:
+			p.advance()
+			term = &indexNode{target: term, index: &literalNode{value: jsonvalue.String(t.text)}}
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].kind == tokenString:
+			p.advance()
+			index, err := p.parseString("")
+			if err != nil {
+				return nil, err
+			}
+			term = &indexNode{target: term, index: index}
+		case t.kind == tokenPunct && t.text == "." && p.tokens[p.pos+1].kind == tokenPunct && p.tokens[p.pos+1].text == "[":
+			p.advance()
+		case t.kind == tokenPunct && t.text == "[":
+			term, err = p.parseBracketSuffix(term)
+			if err != nil {
+				return nil, err
+			}
+		case t.kind == tokenPunct && t.text == "?":
+			p.advance()
+			term = &tryNode{body: term}
+		default:
+			return term, nil
+		}
+	}
+}
+
+func (p *parser) parseBracketSuffix(target node) (node, error) {
+	if err := p.expectPunct("["); err != nil {
+		return nil, err
+	}
+	if p.isPunct("]") {
+		p.advance()
+		return &iterateNode{target: target}, nil
+	}
+	if p.isPunct(":") {
+		p.advance()
+		to, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+		return &sliceNode{target: target, to: to}, nil
+	}
+
+	index, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct(":") {
+		p.advance()
+		var to node
+		if !p.isPunct("]") {
+			to, err = p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+		return &sliceNode{target: target, from: index, to: to}, nil
+	}
+	if err := p.expectPunct("]"); err != nil {
+		return nil, err
+	}
+
+	return &indexNode{target: target, index: index}, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenField:
+		p.advance()
+		return &indexNode{target: &identityNode{}, index: &literalNode{value: jsonvalue.String(t.text)}}, nil
+	case tokenVariable:
+		p.advance()
+		if t.text == "__loc__" {
+			return &literalNode{value: jsonvalue.Object(jsonvalue.Props{"file": jsonvalue.String("<stdin>"), "line": jsonvalue.Number(1)})}, nil
+		}
+		return &variableNode{name: t.text}, nil
+	case tokenNumber:
+		p.advance()
+		return &literalNode{value: jsonvalue.Number(json.Number(t.text))}, nil
+	case tokenString:
+		return p.parseString("")
+	case tokenFormat:
+		p.advance()
+		if p.peek().kind == tokenString {
+			return p.parseString(t.text)
+		}
+		return &formatNode{name: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "null":
+			p.advance()
+			return &literalNode{value: jsonvalue.Null()}, nil
+		case "true", "false":
+			p.advance()
+			return &literalNode{value: jsonvalue.Boolean(t.text == "true")}, nil
+		}
+		return p.parseCall()
+	case tokenKeyword:
+		switch t.text {
+		case "if":
+			return p.parseIf()
+		case "try":
+			return p.parseTry()
+		case "reduce":
+			return p.parseReduce()
+		case "foreach":
+			return p.parseForeach()
+		}
+	case tokenPunct:
+		switch t.text {
+		case ".":
+			p.advance()
+			switch next := p.peek(); {
+			case next.kind == tokenString:
+				index, err := p.parseString("")
+				if err != nil {
+					return nil, err
+				}
+				return &indexNode{target: &identityNode{}, index: index}, nil
+			case next.kind == tokenPunct && next.text == "[":
+				return p.parseBracketSuffix(&identityNode{})
+			}
+			return &identityNode{}, nil
+		case "..":
+			p.advance()
+			return &callNode{name: "recurse"}, nil
+		case "(":
+			p.advance()
+			body, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			return body, nil
+		case "[":
+			p.advance()
+			if p.isPunct("]") {
+				p.advance()
+				return &arrayNode{}, nil
+			}
+			body, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct("]"); err != nil {
+				return nil, err
+			}
+			return &arrayNode{body: body}, nil
+		case "{":
+			return p.parseObject()
+		}
+	}
+
+	return nil, p.errorf(t, `unexpected token %s`, describe(t))
+}
+
+func (p *parser) parseString(format string) (node, error) {
+	t := p.advance()
+	if t.kind != tokenString {
+		return nil, p.errorf(t, `string expected but got %s`, describe(t))
+	}
+
+	parts := []node{}
+	for _, part := range t.parts {
+		if !part.isExpr {
+			parts = append(parts, &textNode{text: part.literal})
+			continue
+		}
+		n, err := parse(part.interpolation)
+		if err != nil {
+			return nil, fmt.Errorf(`fail to parse string interpolation: %w`, err)
+		}
+		parts = append(parts, n)
+	}
+	if format == "" && len(parts) == 1 {
+		if t, ok := parts[0].(*textNode); ok {
+			return &literalNode{value: jsonvalue.String(t.text)}, nil
+		}
+	}
+
+	return &stringNode{format: format, parts: parts}, nil
+}
+
+func (p *parser) parseCall() (node, error) {
+	t := p.advance()
+	call := &callNode{name: t.text}
+	if !p.isPunct("(") {
+		return call, nil
+	}
+
+	p.advance()
+	for {
+		arg, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if !p.isPunct(";") {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+func (p *parser) parseIf() (node, error) {
+	p.advance()
+	cond, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &ifNode{cond: cond, then: then}
+	switch {
+	case p.isKeyword("elif"):
+		els, err := p.parseIf()
+		if err != nil {
+			return nil, err
+		}
+		n.els = els
+		return n, nil
+	case p.isKeyword("else"):
+		p.advance()
+		els, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		n.els = els
+	}
+	if err := p.expectKeyword("end"); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseTry() (node, error) {
+	p.advance()
+	body, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &tryNode{body: body}
+	if p.isKeyword("catch") {
+		p.advance()
+		n.catch, err = p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseReduceHead() (node, string, error) {
+	p.advance()
+	source, err := p.parsePostfix()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := p.expectKeyword("as"); err != nil {
+		return nil, "", err
+	}
+	name, err := p.expectVariable()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := p.expectPunct("("); err != nil {
+		return nil, "", err
+	}
+
+	return source, name, nil
+}
+
+func (p *parser) parseReduce() (node, error) {
+	source, name, err := p.parseReduceHead()
+	if err != nil {
+		return nil, err
+	}
+	init, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(";"); err != nil {
+		return nil, err
+	}
+	update, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+
+	return &reduceNode{source: source, name: name, init: init, update: update}, nil
+}
+
+func (p *parser) parseForeach() (node, error) {
+	source, name, err := p.parseReduceHead()
+	if err != nil {
+		return nil, err
+	}
+	init, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(";"); err != nil {
+		return nil, err
+	}
+	update, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &foreachNode{source: source, name: name, init: init, update: update}
+	if p.isPunct(";") {
+		p.advance()
+		n.extract, err = p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseObject() (node, error) {
+	p.advance()
+	n := &objectNode{}
+	for !p.isPunct("}") {
+		entry, err := p.parseObjectEntry()
+		if err != nil {
+			return nil, err
+		}
+		n.entries = append(n.entries, entry)
+		if !p.isPunct(",") {
+			break
+		}
+		p.advance()
+	}
+	if err := p.expectPunct("}"); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseObjectEntry() (objectEntry, error) {
+	var entry objectEntry
+	switch t := p.peek(); {
+	case t.kind == tokenIdent || t.kind == tokenKeyword:
+		p.advance()
+		entry.key = &literalNode{value: jsonvalue.String(t.text)}
+	case t.kind == tokenVariable:
+		p.advance()
+		entry.key = &literalNode{value: jsonvalue.String(t.text)}
+		entry.value = &variableNode{name: t.text}
+		return entry, nil
+	case t.kind == tokenNumber:
+		p.advance()
+		entry.key = &literalNode{value: jsonvalue.String(t.text)}
+	case t.kind == tokenString || t.kind == tokenFormat:
+		format := ""
+		if t.kind == tokenFormat {
+			p.advance()
+			format = t.text
+		}
+		key, err := p.parseString(format)
+		if err != nil {
+			return entry, err
+		}
+		entry.key = key
+	case t.kind == tokenPunct && t.text == "(":
+		p.advance()
+		key, err := p.parsePipe()
+		if err != nil {
+			return entry, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return entry, err
+		}
+		entry.key = key
+	default:
+		return entry, p.errorf(t, `object key expected but got %s`, describe(t))
+	}
+
+	if !p.isPunct(":") {
+		if _, ok := entry.key.(*literalNode); !ok {
+			if _, ok := entry.key.(*stringNode); !ok {
+				return entry, p.errorf(p.peek(), `":" expected after computed object key`)
+			}
+		}
+		return entry, nil
+	}
+
+	p.advance()
+	value, err := p.parseObjectValue()
+	if err != nil {
+		return entry, err
+	}
+	entry.value = value
+
+	return entry, nil
+}
+
+// parseObjectValue parses a value of object construction, which consists of terms joined by pipes.
+func (p *parser) parseObjectValue() (node, error) {
+	left, err := p.parseAlternate()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("|") {
+		p.advance()
+		right, err := p.parseObjectValue()
+		if err != nil {
+			return nil, err
+		}
+		return &pipeNode{left: left, right: right}, nil
+	}
+
+	return left, nil
+}
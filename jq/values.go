@@ -0,0 +1,537 @@
+package jq
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func toFloat(v jsonvalue.Value) float64 {
+	// a number out of the range of float64 is parsed as ±Inf with an error, which is used as is
+	f, _ := strconv.ParseFloat(string(v.NumberGet()), 64)
+
+	return f
+}
+
+// numberValue returns a JSON number of f, in which NaN is null and infinities are the largest finite numbers as jq does.
+func numberValue(f float64) jsonvalue.Value {
+	switch {
+	case math.IsNaN(f):
+		return jsonvalue.Null()
+	case math.IsInf(f, 1):
+		f = math.MaxFloat64
+	case math.IsInf(f, -1):
+		f = -math.MaxFloat64
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e17 {
+		return jsonvalue.Number(json.Number(strconv.FormatFloat(f, 'f', -1, 64)))
+	}
+
+	return jsonvalue.Number(json.Number(strconv.FormatFloat(f, 'g', -1, 64)))
+}
+
+func typeName(v jsonvalue.Value) string {
+	switch v.Type() {
+	case jsonvalue.TypeNull:
+		return "null"
+	case jsonvalue.TypeBoolean:
+		return "boolean"
+	case jsonvalue.TypeNumber:
+		return "number"
+	case jsonvalue.TypeString:
+		return "string"
+	case jsonvalue.TypeArray:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func toJSON(v jsonvalue.Value) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "<invalid>"
+	}
+
+	return string(b)
+}
+
+// describeValue returns the type and the JSON text of v for error messages.
+func describeValue(v jsonvalue.Value) string {
+	s := toJSON(v)
+	if len(s) > 30 {
+		s = s[:27] + "..."
+	}
+
+	return typeName(v) + " (" + s + ")"
+}
+
+func toText(v jsonvalue.Value) string {
+	if v.Type() == jsonvalue.TypeString {
+		return v.StringGet()
+	}
+
+	return toJSON(v)
+}
+
+func sortedKeys(v jsonvalue.Value) []string {
+	keys := v.ObjectKeys()
+	sort.Strings(keys)
+
+	return keys
+}
+
+func compare(a, b jsonvalue.Value) int {
+	return jsonvalue.Compare(a, b)
+}
+
+func binaryOp(op string, l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	switch op {
+	case "==":
+		return jsonvalue.Boolean(compare(l, r) == 0), nil
+	case "!=":
+		return jsonvalue.Boolean(compare(l, r) != 0), nil
+	case "<":
+		return jsonvalue.Boolean(compare(l, r) < 0), nil
+	case "<=":
+		return jsonvalue.Boolean(compare(l, r) <= 0), nil
+	case ">":
+		return jsonvalue.Boolean(compare(l, r) > 0), nil
+	case ">=":
+		return jsonvalue.Boolean(compare(l, r) >= 0), nil
+	case "+":
+		return add(l, r)
+	case "-":
+		return subtract(l, r)
+	case "*":
+		return multiply(l, r)
+	case "/":
+		return divide(l, r)
+	case "%":
+		return modulo(l, r)
+	default:
+		return nil, errorf(`unknown operator %s`, op)
+	}
+}
+
+func bothType(l, r jsonvalue.Value, t jsonvalue.Type) bool {
+	return l.Type() == t && r.Type() == t
+}
+
+func add(l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	switch {
+	case l.Type() == jsonvalue.TypeNull:
+		return r, nil
+	case r.Type() == jsonvalue.TypeNull:
+		return l, nil
+	case bothType(l, r, jsonvalue.TypeNumber):
+		return numberValue(toFloat(l) + toFloat(r)), nil
+	case bothType(l, r, jsonvalue.TypeString):
+		return jsonvalue.String(l.StringGet() + r.StringGet()), nil
+	case bothType(l, r, jsonvalue.TypeArray):
+		return jsonvalue.Array(append(elements(l), elements(r)...)...), nil
+	case bothType(l, r, jsonvalue.TypeObject):
+		props := members(l)
+		for k, v := range r.ObjectAll() {
+			props[k] = v
+		}
+		return jsonvalue.Object(props), nil
+	default:
+		return nil, errorf(`%s and %s cannot be added`, describeValue(l), describeValue(r))
+	}
+}
+
+func subtract(l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	switch {
+	case bothType(l, r, jsonvalue.TypeNumber):
+		return numberValue(toFloat(l) - toFloat(r)), nil
+	case bothType(l, r, jsonvalue.TypeArray):
+		a := jsonvalue.Array()
+		for _, x := range l.ArrayAll() {
+			found := false
+			for _, y := range r.ArrayAll() {
+				if compare(x, y) == 0 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				a.ArrayAddElm(x)
+			}
+		}
+		return a, nil
+	default:
+		return nil, errorf(`%s and %s cannot be subtracted`, describeValue(l), describeValue(r))
+	}
+}
+
+func multiply(l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	switch {
+	case bothType(l, r, jsonvalue.TypeNumber):
+		return numberValue(toFloat(l) * toFloat(r)), nil
+	case l.Type() == jsonvalue.TypeString && r.Type() == jsonvalue.TypeNumber:
+		return repeat(l.StringGet(), toFloat(r))
+	case l.Type() == jsonvalue.TypeNumber && r.Type() == jsonvalue.TypeString:
+		return repeat(r.StringGet(), toFloat(l))
+	case bothType(l, r, jsonvalue.TypeObject):
+		return deepMerge(l, r), nil
+	default:
+		return nil, errorf(`%s and %s cannot be multiplied`, describeValue(l), describeValue(r))
+	}
+}
+
+// maxRepeatLength limits the length in bytes of a string repeated by multiplication.
+const maxRepeatLength = 1 << 26
+
+func repeat(s string, n float64) (jsonvalue.Value, error) {
+	if math.IsNaN(n) || n <= 0 {
+		return jsonvalue.Null(), nil
+	}
+	if n > maxRepeatLength || float64(len(s))*math.Ceil(n) > maxRepeatLength {
+		return nil, errorf(`repeat string result too long`)
+	}
+
+	return jsonvalue.String(strings.Repeat(s, int(math.Ceil(n)))), nil
+}
+
+func deepMerge(l, r jsonvalue.Value) jsonvalue.Value {
+	props := members(l)
+	for k, v := range r.ObjectAll() {
+		if old, ok := props[k]; ok && bothType(old, v, jsonvalue.TypeObject) {
+			props[k] = deepMerge(old, v)
+		} else {
+			props[k] = v
+		}
+	}
+
+	return jsonvalue.Object(props)
+}
+
+func divide(l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	switch {
+	case bothType(l, r, jsonvalue.TypeNumber):
+		if toFloat(r) == 0 {
+			return nil, errorf(`%s and %s cannot be divided because the divisor is zero`, describeValue(l), describeValue(r))
+		}
+		return numberValue(toFloat(l) / toFloat(r)), nil
+	case bothType(l, r, jsonvalue.TypeString):
+		return split(l.StringGet(), r.StringGet()), nil
+	default:
+		return nil, errorf(`%s and %s cannot be divided`, describeValue(l), describeValue(r))
+	}
+}
+
+func modulo(l, r jsonvalue.Value) (jsonvalue.Value, error) {
+	if !bothType(l, r, jsonvalue.TypeNumber) {
+		return nil, errorf(`%s and %s cannot be divided`, describeValue(l), describeValue(r))
+	}
+	x, y := int64(toFloat(l)), int64(toFloat(r))
+	if y == 0 {
+		return nil, errorf(`%s and %s cannot be divided because the divisor is zero`, describeValue(l), describeValue(r))
+	}
+	if y < 0 {
+		y = -y
+	}
+
+	return numberValue(float64(x % y)), nil
+}
+
+func split(s, sep string) jsonvalue.Value {
+	a := jsonvalue.Array()
+	if s == "" {
+		return a
+	}
+	for _, part := range strings.Split(s, sep) {
+		a.ArrayAddElm(jsonvalue.String(part))
+	}
+
+	return a
+}
+
+// elements returns the elements of a JSON array v in a new slice.
+func elements(v jsonvalue.Value) []jsonvalue.Value {
+	elms := make([]jsonvalue.Value, 0, v.ArrayLen())
+	for _, elm := range v.ArrayAll() {
+		elms = append(elms, elm)
+	}
+
+	return elms
+}
+
+// members returns the members of a JSON object v in a new map.
+func members(v jsonvalue.Value) jsonvalue.Props {
+	props := jsonvalue.Props{}
+	for k, elm := range v.ObjectAll() {
+		props[k] = elm
+	}
+
+	return props
+}
+
+// pathValue converts a path into a JSON array of strings and numbers.
+func pathValue(p jsonvalue.Path) jsonvalue.Value {
+	a := jsonvalue.Array()
+	for _, k := range p {
+		a.ArrayAddElm(keyValue(k))
+	}
+
+	return a
+}
+
+// valuePath converts a JSON array of strings and numbers into a path.
+func valuePath(v jsonvalue.Value) (jsonvalue.Path, error) {
+	if v.Type() != jsonvalue.TypeArray {
+		return nil, errorf(`path must be specified as an array but got %s`, describeValue(v))
+	}
+
+	p := jsonvalue.Path{}
+	for _, k := range v.ArrayAll() {
+		switch k.Type() {
+		case jsonvalue.TypeString:
+			p = append(p, jsonvalue.KeyName(k.StringGet()))
+		case jsonvalue.TypeNumber:
+			if toFloat(k) > maxArrayIndex {
+				return nil, errorf(`array index too large`)
+			}
+			i := int(toFloat(k))
+			if i < 0 {
+				return nil, errorf(`out of bounds negative array index`)
+			}
+			p = append(p, jsonvalue.KeyIndex(i))
+		default:
+			return nil, errorf(`path must be specified as an array of strings and numbers but got %s`, describeValue(k))
+		}
+	}
+
+	return p, nil
+}
+
+func keyValue(k jsonvalue.Key) jsonvalue.Value {
+	if k.IsIndex() {
+		return jsonvalue.Number(k.Int())
+	}
+
+	return jsonvalue.String(k.String())
+}
+
+// getPath returns the JSON value at p in v, or null if it does not exist.
+func getPath(v jsonvalue.Value, p jsonvalue.Path) (jsonvalue.Value, error) {
+	for _, k := range p {
+		if v.Type() == jsonvalue.TypeNull {
+			return v, nil
+		}
+		o, err := indexValue(pv{value: v}, keyValue(k))
+		if err != nil {
+			return nil, err
+		}
+		v = o.value
+	}
+
+	return v, nil
+}
+
+// maxArrayIndex limits the index at which a JSON value is set, since the array is padded with null up to the index.
+const maxArrayIndex = 1<<20 - 1
+
+// setPath returns a copy of v in which the JSON value at p is replaced with x.
+// The JSON values not on p are shared with v.
+func setPath(v jsonvalue.Value, p jsonvalue.Path, x jsonvalue.Value) (jsonvalue.Value, error) {
+	if len(p) == 0 {
+		return x, nil
+	}
+
+	k := p[0]
+	if k.IsIndex() {
+		var elms []jsonvalue.Value
+		switch v.Type() {
+		case jsonvalue.TypeNull:
+		case jsonvalue.TypeArray:
+			elms = elements(v)
+		default:
+			return nil, errorf(`cannot index %s with number`, typeName(v))
+		}
+		if k.Int() > maxArrayIndex {
+			return nil, errorf(`array index too large`)
+		}
+		for len(elms) <= k.Int() {
+			elms = append(elms, jsonvalue.Null())
+		}
+		child, err := setPath(elms[k.Int()], p[1:], x)
+		if err != nil {
+			return nil, err
+		}
+		elms[k.Int()] = child
+		return jsonvalue.Array(elms...), nil
+	}
+
+	props := jsonvalue.Props{}
+	switch v.Type() {
+	case jsonvalue.TypeNull:
+	case jsonvalue.TypeObject:
+		props = members(v)
+	default:
+		return nil, errorf(`cannot index %s with "%s"`, typeName(v), k.String())
+	}
+	child, ok := props[k.String()]
+	if !ok {
+		child = jsonvalue.Null()
+	}
+	child, err := setPath(child, p[1:], x)
+	if err != nil {
+		return nil, err
+	}
+	props[k.String()] = child
+
+	return jsonvalue.Object(props), nil
+}
+
+// deletePath returns a copy of v without the JSON value at p.
+func deletePath(v jsonvalue.Value, p jsonvalue.Path) (jsonvalue.Value, error) {
+	if len(p) == 0 {
+		return jsonvalue.Null(), nil
+	}
+	if v.Type() == jsonvalue.TypeNull {
+		return v, nil
+	}
+
+	k := p[0]
+	child, err := indexValue(pv{value: v}, keyValue(k))
+	if err != nil {
+		return nil, err
+	}
+	if len(p) > 1 {
+		if child.value.Type() == jsonvalue.TypeNull {
+			return v, nil
+		}
+		updated, err := deletePath(child.value, p[1:])
+		if err != nil {
+			return nil, err
+		}
+		return setPath(v, jsonvalue.Path{k}, updated)
+	}
+
+	if k.IsIndex() {
+		elms := elements(v)
+		if k.Int() >= len(elms) {
+			return v, nil
+		}
+		return jsonvalue.Array(append(elms[:k.Int()], elms[k.Int()+1:]...)...), nil
+	}
+
+	props := members(v)
+	delete(props, k.String())
+
+	return jsonvalue.Object(props), nil
+}
+
+// deletePaths returns a copy of v without the JSON values at paths, deleting the later paths first so that the indices are not shifted.
+func deletePaths(v jsonvalue.Value, paths []jsonvalue.Path) (jsonvalue.Value, error) {
+	sorted := append([]jsonvalue.Path{}, paths...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Compare(sorted[j]) > 0 })
+
+	for _, p := range sorted {
+		var err error
+		if v, err = deletePath(v, p); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func applyFormat(name string, v jsonvalue.Value) (string, error) {
+	switch name {
+	case "text":
+		return toText(v), nil
+	case "json":
+		return toJSON(v), nil
+	case "html":
+		return strings.NewReplacer("<", "&lt;", ">", "&gt;", "&", "&amp;", "'", "&#39;", `"`, "&quot;").Replace(toText(v)), nil
+	case "uri":
+		var b strings.Builder
+		for _, c := range []byte(toText(v)) {
+			if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || strings.IndexByte("-_.~", c) >= 0 {
+				b.WriteByte(c)
+			} else {
+				b.WriteString("%" + strings.ToUpper(strconv.FormatUint(uint64(c)|0x100, 16)[1:]))
+			}
+		}
+		return b.String(), nil
+	case "csv", "tsv":
+		if v.Type() != jsonvalue.TypeArray {
+			return "", errorf(`%s cannot be %s-formatted, only an array can be`, describeValue(v), name)
+		}
+		fields := []string{}
+		for _, elm := range v.ArrayAll() {
+			switch elm.Type() {
+			case jsonvalue.TypeNull:
+				fields = append(fields, "")
+			case jsonvalue.TypeBoolean, jsonvalue.TypeNumber:
+				fields = append(fields, toJSON(elm))
+			case jsonvalue.TypeString:
+				if name == "csv" {
+					fields = append(fields, `"`+strings.ReplaceAll(elm.StringGet(), `"`, `""`)+`"`)
+				} else {
+					fields = append(fields, strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(elm.StringGet()))
+				}
+			default:
+				return "", errorf(`%s is not valid in a %s row`, describeValue(elm), name)
+			}
+		}
+		if name == "csv" {
+			return strings.Join(fields, ","), nil
+		}
+		return strings.Join(fields, "\t"), nil
+	case "sh":
+		quote := func(v jsonvalue.Value) (string, error) {
+			switch v.Type() {
+			case jsonvalue.TypeString:
+				return "'" + strings.ReplaceAll(v.StringGet(), "'", `'\''`) + "'", nil
+			case jsonvalue.TypeArray, jsonvalue.TypeObject:
+				return "", errorf(`%s can not be escaped for shell`, describeValue(v))
+			default:
+				return toJSON(v), nil
+			}
+		}
+		if v.Type() != jsonvalue.TypeArray {
+			return quote(v)
+		}
+		words := []string{}
+		for _, elm := range v.ArrayAll() {
+			w, err := quote(elm)
+			if err != nil {
+				return "", err
+			}
+			words = append(words, w)
+		}
+		return strings.Join(words, " "), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(toText(v))), nil
+	case "base64d":
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(toText(v), "="))
+		if err != nil {
+			return "", errorf(`%s is not valid base64 data`, describeValue(v))
+		}
+		if !utf8.Valid(b) {
+			return strings.ToValidUTF8(string(b), "�"), nil
+		}
+		return string(b), nil
+	default:
+		return "", errorf(`%s is not a valid format`, name)
+	}
+}
+
+func isFormat(name string) bool {
+	switch name {
+	case "text", "json", "html", "uri", "csv", "tsv", "sh", "base64", "base64d":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,634 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+// JSON5Comment holds the comments attached to a JSON value.
+// Each comment is kept as written including the delimiters, e.g. "// comment" or "/* comment */".
+type JSON5Comment struct {
+	// Leading holds the comments preceding the JSON value, or the member name for an object member.
+	Leading []string
+	// Trailing holds the comments following the JSON value on the same line.
+	Trailing []string
+	// Inner holds the comments before the closing bracket of a JSON object or array.
+	Inner []string
+}
+
+// JSON5Comments maps the JSON Pointers of Paths to the comments attached to the JSON values at the Paths.
+type JSON5Comments map[string]JSON5Comment
+
+// FromJSON5 decodes a JSON5 or JSONC text into a JSON value, discarding the comments.
+// In addition to JSON, comments, trailing commas, unquoted member names, single-quoted strings, escaped line breaks in strings,
+// hexadecimal numbers, numbers with a leading or trailing decimal point or a plus sign are accepted.
+// Infinity and NaN are rejected because they cannot be represented in JSON.
+func FromJSON5(b []byte) (Value, error) {
+	v, _, err := decodeJSON5(b, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// FromJSON5WithComments decodes a JSON5 or JSONC text into a JSON value like FromJSON5, and returns the comments attached to the JSON values.
+// The comments before the root JSON value or a member or an element are attached to it as leading comments,
+// the comments following it on the same line are attached as trailing comments,
+// and the comments before the closing bracket are attached to the JSON object or array as inner comments.
+// The comments at the end of the text are attached to the root JSON value as trailing comments.
+func FromJSON5WithComments(b []byte) (Value, JSON5Comments, error) {
+	return decodeJSON5(b, true)
+}
+
+func decodeJSON5(b []byte, withComments bool) (Value, JSON5Comments, error) {
+	if !utf8.Valid(b) {
+		return nil, nil, fmt.Errorf(`fail to parse JSON5: input is not valid UTF-8`)
+	}
+
+	p := &json5Parser{src: []rune(string(b))}
+	if withComments {
+		p.comments = JSON5Comments{}
+	}
+	v, err := p.parseDocument()
+	if err != nil {
+		return nil, nil, fmt.Errorf(`fail to parse JSON5: %w`, err)
+	}
+
+	return v, p.comments, nil
+}
+
+// ToJSON5 encodes a JSON value v into an indented JSON text with the comments, which can be decoded by FromJSON5WithComments.
+// Object members are sorted by their keys, and the comments for Paths not existing in v are ignored.
+// The output is valid JSON if comments is empty.
+func ToJSON5(v Value, comments JSON5Comments) ([]byte, error) {
+	var b bytes.Buffer
+	c := comments[Path{}.Pointer()]
+	writeJSON5Comments(&b, c.Leading, 0)
+	if err := writeJSON5Value(&b, Path{}, v, comments, 0); err != nil {
+		return nil, fmt.Errorf(`fail to encode JSON5: %w`, err)
+	}
+	writeJSON5Trailing(&b, c.Trailing, 0)
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+func writeJSON5Comments(b *bytes.Buffer, comments []string, indent int) {
+	for _, comment := range comments {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString(comment)
+		b.WriteString("\n")
+	}
+}
+
+// writeJSON5Trailing writes comments on the current line, breaking the line after each line comment except the last one.
+func writeJSON5Trailing(b *bytes.Buffer, comments []string, indent int) {
+	for i, comment := range comments {
+		if i > 0 && strings.HasPrefix(comments[i-1], "//") {
+			b.WriteString("\n" + strings.Repeat(" ", indent))
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(comment)
+	}
+}
+
+func writeJSON5Value(b *bytes.Buffer, path Path, v Value, comments JSON5Comments, indent int) error {
+	inner := comments[path.Pointer()].Inner
+	switch v.Type() {
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		if len(keys) == 0 && len(inner) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{\n")
+		for i, key := range keys {
+			childPath := path.Append(KeyName(key))
+			c := comments[childPath.Pointer()]
+			writeJSON5Comments(b, c.Leading, indent+2)
+			b.WriteString(strings.Repeat(" ", indent+2))
+			writeJSON5String(b, key)
+			b.WriteString(": ")
+			if err := writeJSON5Value(b, childPath, v.ObjectGetElm(key), comments, indent+2); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				b.WriteString(",")
+			}
+			writeJSON5Trailing(b, c.Trailing, indent+2)
+			b.WriteString("\n")
+		}
+		writeJSON5Comments(b, inner, indent+2)
+		b.WriteString(strings.Repeat(" ", indent) + "}")
+	case TypeArray:
+		if v.ArrayLen() == 0 && len(inner) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, elm := range v.ArrayAll() {
+			childPath := path.Append(KeyIndex(i))
+			c := comments[childPath.Pointer()]
+			writeJSON5Comments(b, c.Leading, indent+2)
+			b.WriteString(strings.Repeat(" ", indent+2))
+			if err := writeJSON5Value(b, childPath, elm, comments, indent+2); err != nil {
+				return err
+			}
+			if i < v.ArrayLen()-1 {
+				b.WriteString(",")
+			}
+			writeJSON5Trailing(b, c.Trailing, indent+2)
+			b.WriteString("\n")
+		}
+		writeJSON5Comments(b, inner, indent+2)
+		b.WriteString(strings.Repeat(" ", indent) + "]")
+	case TypeString:
+		writeJSON5String(b, v.StringGet())
+	default:
+		s, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(s)
+	}
+
+	return nil
+}
+
+func writeJSON5String(b *bytes.Buffer, s string) {
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	_ = e.Encode(s)
+	b.Truncate(b.Len() - 1)
+}
+
+// json5MaxNestingDepth bounds the nesting of JSON5 objects and arrays.
+const json5MaxNestingDepth = 1000
+
+// json5Parser is a recursive descent parser of JSON5 texts.
+type json5Parser struct {
+	src  []rune
+	pos  int
+	line int
+	// comments is nil if the comments are not collected.
+	comments JSON5Comments
+	// pending holds the comments not attached yet.
+	pending []string
+}
+
+func (p *json5Parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *json5Parser) peekAt(offset int) rune {
+	if i := p.pos + offset; i < len(p.src) {
+		return p.src[i]
+	}
+
+	return -1
+}
+
+func (p *json5Parser) peek() rune {
+	return p.peekAt(0)
+}
+
+func (p *json5Parser) advance() {
+	if isJSON5LineTerminator(p.src[p.pos]) && !(p.src[p.pos] == '\r' && p.peekAt(1) == '\n') {
+		p.line++
+	}
+	p.pos++
+}
+
+func (p *json5Parser) errorf(format string, args ...any) error {
+	return fmt.Errorf(`line %d: %w`, p.line+1, fmt.Errorf(format, args...))
+}
+
+func isJSON5LineTerminator(r rune) bool {
+	return r == '\n' || r == '\r' || r == '\u2028' || r == '\u2029'
+}
+
+func isJSON5Space(r rune) bool {
+	return r == '\t' || r == '\v' || r == '\f' || r == ' ' || r == '\u00A0' || r == '\uFEFF' || unicode.Is(unicode.Zs, r)
+}
+
+// skipSpace skips white spaces and comments, which are appended to the pending comments.
+// If sameLine is true, it stops at a line terminator and returns the comments on the line instead of appending them.
+func (p *json5Parser) skipSpace(sameLine bool) ([]string, error) {
+	found := []string{}
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case isJSON5Space(r):
+			p.advance()
+		case isJSON5LineTerminator(r):
+			if sameLine {
+				return found, nil
+			}
+			p.advance()
+		case r == '/' && p.peekAt(1) == '/':
+			start := p.pos
+			for !p.eof() && !isJSON5LineTerminator(p.peek()) {
+				p.advance()
+			}
+			found = append(found, string(p.src[start:p.pos]))
+		case r == '/' && p.peekAt(1) == '*':
+			start := p.pos
+			p.advance()
+			p.advance()
+			for !p.eof() && !(p.peek() == '*' && p.peekAt(1) == '/') {
+				p.advance()
+			}
+			if p.eof() {
+				return nil, p.errorf(`unterminated comment`)
+			}
+			p.advance()
+			p.advance()
+			found = append(found, string(p.src[start:p.pos]))
+		default:
+			if !sameLine {
+				p.pending = append(p.pending, found...)
+				return nil, nil
+			}
+			return found, nil
+		}
+	}
+	if !sameLine {
+		p.pending = append(p.pending, found...)
+		return nil, nil
+	}
+
+	return found, nil
+}
+
+// attach attaches comments to the JSON value at the Path.
+func (p *json5Parser) attach(path Path, update func(c *JSON5Comment)) {
+	if p.comments == nil {
+		return
+	}
+	c := p.comments[path.Pointer()]
+	update(&c)
+	if len(c.Leading) > 0 || len(c.Trailing) > 0 || len(c.Inner) > 0 {
+		p.comments[path.Pointer()] = c
+	}
+}
+
+// takePending returns the pending comments and clears them.
+func (p *json5Parser) takePending() []string {
+	pending := p.pending
+	p.pending = nil
+
+	return pending
+}
+
+func (p *json5Parser) parseDocument() (Value, error) {
+	if _, err := p.skipSpace(false); err != nil {
+		return nil, err
+	}
+	root := Path{}
+	leading := p.takePending()
+	p.attach(root, func(c *JSON5Comment) { c.Leading = append(c.Leading, leading...) })
+
+	v, err := p.parseValue(root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.skipSpace(false); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf(`unexpected character %q after JSON value`, p.peek())
+	}
+	trailing := p.takePending()
+	p.attach(root, func(c *JSON5Comment) { c.Trailing = append(c.Trailing, trailing...) })
+
+	return v, nil
+}
+
+// childPath returns the Path of a member or an element, which is nil if the comments are not collected.
+func (p *json5Parser) childPath(path Path, key Key) Path {
+	if p.comments == nil {
+		return nil
+	}
+
+	return path.Append(key)
+}
+
+func (p *json5Parser) parseValue(path Path, depth int) (Value, error) {
+	switch r := p.peek(); {
+	case p.eof():
+		return nil, p.errorf(`unexpected end of input`)
+	case (r == '{' || r == '[') && depth > json5MaxNestingDepth:
+		return nil, p.errorf(`JSON5 objects and arrays are nested too deeply`)
+	case r == '{':
+		return p.parseObject(path, depth)
+	case r == '[':
+		return p.parseArray(path, depth)
+	case r == '"' || r == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return String(s), nil
+	case r == '+' || r == '-' || r == '.' || ('0' <= r && r <= '9'):
+		return p.parseNumber()
+	case isJSON5IdentifierStart(r):
+		start := p.pos
+		name := p.parseIdentifier()
+		switch name {
+		case "null":
+			return Null(), nil
+		case "true":
+			return Boolean(true), nil
+		case "false":
+			return Boolean(false), nil
+		case "Infinity", "NaN":
+			return nil, p.errorf(`%s cannot be represented in JSON`, name)
+		default:
+			p.pos = start
+			return nil, p.errorf(`unexpected identifier %q`, name)
+		}
+	default:
+		return nil, p.errorf(`unexpected character %q`, r)
+	}
+}
+
+// parseEntries parses the members or the elements of a container between the brackets,
+// calling parseEntry for each of them with its index.
+func (p *json5Parser) parseEntries(path Path, closing rune, parseEntry func(i int) (Path, error)) error {
+	p.advance()
+	for i := 0; ; i++ {
+		if _, err := p.skipSpace(false); err != nil {
+			return err
+		}
+		if p.peek() == closing {
+			inner := p.takePending()
+			p.attach(path, func(c *JSON5Comment) { c.Inner = append(c.Inner, inner...) })
+			p.advance()
+			return nil
+		}
+		if p.eof() {
+			return p.errorf(`%q expected but got end of input`, closing)
+		}
+
+		childPath, err := parseEntry(i)
+		if err != nil {
+			return err
+		}
+
+		trailing, err := p.skipSpace(true)
+		if err != nil {
+			return err
+		}
+		switch p.peek() {
+		case ',':
+			p.advance()
+			more, err := p.skipSpace(true)
+			if err != nil {
+				return err
+			}
+			trailing = append(trailing, more...)
+		case closing:
+		default:
+			if _, err := p.skipSpace(false); err != nil {
+				return err
+			}
+			if p.peek() != ',' && p.peek() != closing {
+				return p.errorf(`',' or %q expected but got %q`, closing, p.peek())
+			}
+			if p.peek() == ',' {
+				p.advance()
+			}
+		}
+		p.attach(childPath, func(c *JSON5Comment) { c.Trailing = append(c.Trailing, trailing...) })
+	}
+}
+
+func (p *json5Parser) parseObject(path Path, depth int) (Value, error) {
+	obj := Object()
+	err := p.parseEntries(path, '}', func(int) (Path, error) {
+		leading := p.takePending()
+		var key string
+		switch r := p.peek(); {
+		case r == '"' || r == '\'':
+			s, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case isJSON5IdentifierStart(r):
+			key = p.parseIdentifier()
+		default:
+			return nil, p.errorf(`member name expected but got %q`, r)
+		}
+		childPath := p.childPath(path, KeyName(key))
+		if obj.ObjectHasElm(key) {
+			return nil, p.errorf(`duplicate member name %q`, key)
+		}
+
+		if _, err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		if p.peek() != ':' {
+			return nil, p.errorf(`':' expected after member name %q`, key)
+		}
+		p.advance()
+		if _, err := p.skipSpace(false); err != nil {
+			return nil, err
+		}
+		leading = append(leading, p.takePending()...)
+		p.attach(childPath, func(c *JSON5Comment) { c.Leading = append(c.Leading, leading...) })
+
+		val, err := p.parseValue(childPath, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		obj.ObjectSetElm(key, val)
+		return childPath, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (p *json5Parser) parseArray(path Path, depth int) (Value, error) {
+	arr := Array()
+	err := p.parseEntries(path, ']', func(i int) (Path, error) {
+		childPath := p.childPath(path, KeyIndex(i))
+		leading := p.takePending()
+		p.attach(childPath, func(c *JSON5Comment) { c.Leading = append(c.Leading, leading...) })
+
+		val, err := p.parseValue(childPath, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr.ArrayAddElm(val)
+		return childPath, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return arr, nil
+}
+
+func isJSON5IdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+func isJSON5IdentifierPart(r rune) bool {
+	return isJSON5IdentifierStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D'
+}
+
+func (p *json5Parser) parseIdentifier() string {
+	start := p.pos
+	for !p.eof() && isJSON5IdentifierPart(p.peek()) {
+		p.advance()
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+var json5Escapes = map[rune]string{'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v", '\'': "'", '"': "\"", '\\': "\\"}
+
+func (p *json5Parser) parseString() (string, error) {
+	quote := p.peek()
+	p.advance()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(`unterminated string`)
+		}
+		r := p.peek()
+		switch {
+		case r == quote:
+			p.advance()
+			return b.String(), nil
+		case r == '\n' || r == '\r':
+			return "", p.errorf(`unescaped line break in string`)
+		case r == '\\':
+			s, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		default:
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+func (p *json5Parser) parseEscape() (string, error) {
+	p.advance()
+	r := p.peek()
+	if s, ok := json5Escapes[r]; ok {
+		p.advance()
+		return s, nil
+	}
+
+	switch {
+	case p.eof():
+		return "", p.errorf(`unterminated string`)
+	case isJSON5LineTerminator(r):
+		p.advance()
+		if r == '\r' && p.peek() == '\n' {
+			p.advance()
+		}
+		return "", nil
+	case r == '0' && !('0' <= p.peekAt(1) && p.peekAt(1) <= '9'):
+		p.advance()
+		return "\x00", nil
+	case '0' <= r && r <= '9':
+		return "", p.errorf(`invalid escape sequence \%c`, r)
+	case r == 'x' || r == 'u':
+		p.advance()
+		code, err := p.parseHex(map[rune]int{'x': 2, 'u': 4}[r])
+		if err != nil {
+			return "", err
+		}
+		if r == 'u' && 0xd800 <= code && code < 0xdc00 && p.peek() == '\\' && p.peekAt(1) == 'u' {
+			start, line := p.pos, p.line
+			p.advance()
+			p.advance()
+			if low, err := p.parseHex(4); err == nil && 0xdc00 <= low && low < 0xe000 {
+				return string(rune(0x10000 + (code-0xd800)<<10 + (low - 0xdc00))), nil
+			}
+			p.pos, p.line = start, line
+		}
+		return string(rune(code)), nil
+	default:
+		p.advance()
+		return string(r), nil
+	}
+}
+
+func (p *json5Parser) parseHex(digits int) (int, error) {
+	if p.pos+digits > len(p.src) {
+		return 0, p.errorf(`invalid escape sequence`)
+	}
+	s := string(p.src[p.pos : p.pos+digits])
+	code, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, p.errorf(`invalid escape sequence: %q is not a hexadecimal number`, s)
+	}
+	p.pos += digits
+
+	return int(code), nil
+}
+
+var (
+	json5HexPattern     = regexp.MustCompile(`^[+-]?0[xX][0-9a-fA-F]+$`)
+	json5DecimalPattern = regexp.MustCompile(`^([+-]?)(0|[1-9][0-9]*)?(?:\.([0-9]*))?([eE][+-]?[0-9]+)?$`)
+)
+
+func (p *json5Parser) parseNumber() (Value, error) {
+	start := p.pos
+	for !p.eof() && (isJSON5IdentifierPart(p.peek()) || strings.ContainsRune("+-.", p.peek())) {
+		p.advance()
+	}
+	s := string(p.src[start:p.pos])
+
+	if sign := strings.TrimLeft(s, "+-"); sign == "Infinity" || sign == "NaN" {
+		return nil, p.errorf(`%s cannot be represented in JSON`, s)
+	}
+	if json5HexPattern.MatchString(s) {
+		digits := strings.TrimLeft(s, "+-")[2:]
+		n, _ := new(big.Int).SetString(digits, 16)
+		if strings.HasPrefix(s, "-") {
+			n.Neg(n)
+		}
+		return Number(json.Number(n.String())), nil
+	}
+
+	m := json5DecimalPattern.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return nil, p.errorf(`invalid number %q`, s)
+	}
+	sign, integer, fraction, exponent := m[1], m[2], m[3], m[4]
+	if sign == "+" {
+		sign = ""
+	}
+	if integer == "" {
+		integer = "0"
+	}
+	if fraction != "" {
+		fraction = "." + fraction
+	}
+
+	return Number(json.Number(sign + integer + fraction + exponent)), nil
+}
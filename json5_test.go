@@ -0,0 +1,150 @@
+package jsonvalue_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestFromJSON5(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "json", in: `{"a":[1,"x",true,false,null]}`, want: `{"a":[1,"x",true,false,null]}`},
+		{name: "comments", in: "// head\n{/* a */\"a\": 1, // one\n/* b\n */ \"b\": 2}\n// tail", want: `{"a":1,"b":2}`},
+		{name: "trailing commas", in: `{"a":[1,2,],"b":{"c":3,},}`, want: `{"a":[1,2],"b":{"c":3}}`},
+		{name: "unquoted keys", in: `{a: 1, $b_2: 2, ünï: 3, null: 4}`, want: `{"a":1,"$b_2":2,"ünï":3,"null":4}`},
+		{name: "single-quoted strings", in: `{'a': 'it\'s "x"'}`, want: `{"a":"it's \"x\""}`},
+		{name: "escapes", in: `['\x41é😀\v\0\q']`, want: `["Aé😀\u000b\u0000q"]`},
+		{name: "line continuation", in: "'a\\\nb\\\r\nc'", want: `"abc"`},
+		{name: "hexadecimal numbers", in: `[0x1F, -0XfF, +0x0, 0xFFFFFFFFFFFFFFFFFF]`, want: `[31,-255,0,4722366482869645213695]`},
+		{name: "decimal numbers", in: `[+1, .5, -.5, 5., 1.5e3, -0, 2E-2]`, want: `[1,0.5,-0.5,5,1500,0,0.02]`},
+		{name: "white spaces", in: "\uFEFF{\u00A0a\u2028:\v1\f}\u2029", want: `{"a":1}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.FromJSON5([]byte(testCase.in))
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestFromJSON5_Error(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                 "",
+		"infinity":              `[Infinity]`,
+		"negative infinity":     `[-Infinity]`,
+		"nan":                   `{a: NaN}`,
+		"leading zero":          `[01]`,
+		"missing comma":         `[1 2]`,
+		"double comma":          `[1,,2]`,
+		"duplicate key":         `{a: 1, 'a': 2}`,
+		"unterminated comment":  `[1] /* x`,
+		"unterminated string":   `'abc`,
+		"line break in string":  "'a\nb'",
+		"octal escape":          `'\01'`,
+		"bad unicode escape":    `'\u12'`,
+		"unknown identifier":    `[undefined]`,
+		"numeric key":           `{1: 2}`,
+		"trailing content":      `{} {}`,
+		"missing colon":         `{a 1}`,
+		"unterminated object":   `{a: 1,`,
+		"invalid number":        `[1.2.3]`,
+		"sign only":             `[-]`,
+		"invalid utf8":          "'\xff'",
+		"comma only in object":  `{,}`,
+		"comma only in array":   `[,]`,
+		"hexadecimal fraction":  `[0x1.5]`,
+		"exponent without base": `[e5]`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.FromJSON5([]byte(in))
+			IsNotNil(t, err)
+		})
+	}
+	t.Run("deeply nested", func(t *testing.T) {
+		_, err := jsonvalue.FromJSON5([]byte(strings.Repeat("[", 1002) + strings.Repeat("]", 1002)))
+		IsNotNil(t, err)
+		_, _, err = jsonvalue.FromJSON5WithComments([]byte(strings.Repeat("{a:", 1002) + strings.Repeat("}", 1002)))
+		IsNotNil(t, err)
+
+		_, err = jsonvalue.FromJSON5([]byte(strings.Repeat("[", 1001) + strings.Repeat("]", 1001)))
+		equal(t, err, nil)
+	})
+}
+
+func TestFromJSON5WithComments(t *testing.T) {
+	in := `// config
+{
+  // the name
+  name: 'app', // inline
+  ports: [
+    80, /* http */
+    // https
+    443,
+    // more ports
+  ],
+  /* nested */ db: {host: 'x'},
+} // end
+// tail
+`
+	v, comments, err := jsonvalue.FromJSON5WithComments([]byte(in))
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(v, mustUnmarshal(t, `{"name":"app","ports":[80,443],"db":{"host":"x"}}`)), true)
+	want := jsonvalue.JSON5Comments{
+		"":         {Leading: []string{"// config"}, Trailing: []string{"// end", "// tail"}},
+		"/name":    {Leading: []string{"// the name"}, Trailing: []string{"// inline"}},
+		"/ports/0": {Trailing: []string{"/* http */"}},
+		"/ports/1": {Leading: []string{"// https"}},
+		"/ports":   {Inner: []string{"// more ports"}},
+		"/db":      {Leading: []string{"/* nested */"}},
+	}
+	equal(t, reflect.DeepEqual(comments, want), true)
+}
+
+func TestToJSON5(t *testing.T) {
+	t.Run("without comments", func(t *testing.T) {
+		got, err := jsonvalue.ToJSON5(mustUnmarshal(t, `{"b":[1,{}],"a":"<&>","c":[]}`), nil)
+		equal(t, err, nil)
+		equal(t, string(got), "{\n  \"a\": \"<&>\",\n  \"b\": [\n    1,\n    {}\n  ],\n  \"c\": []\n}\n")
+	})
+	t.Run("round trip after editing", func(t *testing.T) {
+		in := "// config\n{\n  // the name\n  name: 'app', // inline\n  ports: [80 /* http */, 443],\n  // removed\n  old: 1,\n  empty: {\n    // nothing\n  },\n} // end\n// tail\n"
+		v, comments, err := jsonvalue.FromJSON5WithComments([]byte(in))
+		equal(t, err, nil)
+		v.ObjectSetElm("name", jsonvalue.String("server"))
+		v.ObjectDelElm("old")
+		v.ObjectSetElm("added", jsonvalue.Boolean(true))
+
+		got, err := jsonvalue.ToJSON5(v, comments)
+		equal(t, err, nil)
+		want := `// config
+{
+  "added": true,
+  "empty": {
+    // nothing
+  },
+  // the name
+  "name": "server", // inline
+  "ports": [
+    80, /* http */
+    443
+  ]
+} // end
+// tail
+`
+		equal(t, string(got), want)
+
+		decoded, decodedComments, err := jsonvalue.FromJSON5WithComments(got)
+		equal(t, err, nil)
+		equal(t, jsonvalue.Equal(decoded, v), true)
+		equal(t, reflect.DeepEqual(decodedComments["/name"], comments["/name"]), true)
+	})
+}
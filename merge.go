@@ -0,0 +1,281 @@
+package jsonvalue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// MergeArrayStrategy determines how Merge combines two JSON arrays.
+type MergeArrayStrategy int
+
+const (
+	// MergeArrayReplace replaces the JSON array in dst with the JSON array in src.
+	MergeArrayReplace MergeArrayStrategy = iota
+	// MergeArrayAppend appends the elements of the JSON array in src to the JSON array in dst.
+	MergeArrayAppend
+	// MergeArrayByIndex merges the elements at the same index and appends the remaining elements in src.
+	MergeArrayByIndex
+	// MergeArrayByKey merges the JSON object elements having the equal member specified by MergeStrategy.ArrayKey and appends the other elements in src.
+	MergeArrayByKey
+)
+
+// MergeConflictStrategy determines how Merge resolves JSON values of different types, neither of which is null.
+type MergeConflictStrategy int
+
+const (
+	// MergeConflictError makes Merge fail.
+	MergeConflictError MergeConflictStrategy = iota
+	// MergeConflictPreferSrc takes the JSON value in src.
+	MergeConflictPreferSrc
+	// MergeConflictPreferDst takes the JSON value in dst.
+	MergeConflictPreferDst
+	// MergeConflictCallback takes the JSON value returned by MergeStrategy.Resolve.
+	MergeConflictCallback
+)
+
+// MergeNullStrategy determines how Merge treats null in src.
+type MergeNullStrategy int
+
+const (
+	// MergeNullReplace replaces the JSON value in dst with null.
+	MergeNullReplace MergeNullStrategy = iota
+	// MergeNullIgnore keeps the JSON value in dst.
+	MergeNullIgnore
+	// MergeNullDelete removes the JSON value in dst from its parent as JSON Merge Patch does.
+	MergeNullDelete
+)
+
+// MergeStrategy is a set of the behaviors of Merge at a Path.
+type MergeStrategy struct {
+	// Array determines how JSON arrays are combined.
+	Array MergeArrayStrategy
+	// ArrayKey is the member name identifying JSON object elements for MergeArrayByKey.
+	ArrayKey string
+	// Conflict determines how JSON values of different types are resolved.
+	Conflict MergeConflictStrategy
+	// Resolve is called with the conflicting JSON values for MergeConflictCallback.
+	// If Resolve returns DeleteValue, the JSON value is removed from its parent, and Resolve must not return nil without an error.
+	Resolve func(path Path, dst Value, src Value) (Value, error)
+	// Null determines how null in src is treated.
+	Null MergeNullStrategy
+}
+
+// MergeRule applies a MergeStrategy to the Paths matching a PathPattern.
+type MergeRule struct {
+	Pattern  PathPattern
+	Strategy MergeStrategy
+}
+
+// MergeOptions configures Merge.
+// The Strategy of the first Rule whose Pattern matches a Path is applied at the Path, and Default is applied if no Rule matches.
+type MergeOptions struct {
+	Default MergeStrategy
+	Rules   []MergeRule
+}
+
+func (o MergeOptions) strategy(path Path) MergeStrategy {
+	for _, rule := range o.Rules {
+		if rule.Pattern.Match(path) {
+			return rule.Strategy
+		}
+	}
+
+	return o.Default
+}
+
+// Merge returns a new JSON value obtained by deeply merging a JSON value src into a JSON value dst.
+// JSON objects are merged member by member, JSON arrays are combined according to MergeStrategy.Array,
+// and the other JSON values in dst are replaced with the JSON values in src.
+// If either of dst and src is null, src is taken according to MergeStrategy.Null instead of being treated as a conflict.
+// If the root JSON value is removed, Merge returns Null().
+// The result is a deep copy and dst and src are not modified.
+func Merge(dst Value, src Value, opts MergeOptions) (Value, error) {
+	merged, err := mergeImpl(Path{}, dst, src, opts)
+	if errors.Is(err, DeleteValue) {
+		return Null(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`fail to merge Values: %w`, err)
+	}
+
+	return merged, nil
+}
+
+// mergeImpl merges src into dst at the Path, where dst is nil if it does not exist.
+// DeleteValue is returned if the JSON value at the Path should be removed.
+func mergeImpl(path Path, dst Value, src Value, opts MergeOptions) (Value, error) {
+	s := opts.strategy(path)
+	if src.Type() == TypeNull {
+		switch s.Null {
+		case MergeNullReplace:
+			return Null(), nil
+		case MergeNullIgnore:
+			if dst == nil {
+				return nil, DeleteValue
+			}
+			return dst.Clone(), nil
+		case MergeNullDelete:
+			return nil, DeleteValue
+		default:
+			return assert.Unexpected2[Value, error](`invalid MergeNullStrategy: %v`, s.Null)
+		}
+	}
+	if dst == nil || dst.Type() == TypeNull {
+		if src.Type() == TypeObject {
+			return mergeObject(path, Object(), src, opts)
+		}
+		return src.Clone(), nil
+	}
+
+	switch {
+	case dst.Type() == TypeObject && src.Type() == TypeObject:
+		return mergeObject(path, dst, src, opts)
+	case dst.Type() == TypeArray && src.Type() == TypeArray:
+		return mergeArray(path, dst, src, s, opts)
+	case dst.Type() == src.Type():
+		return src.Clone(), nil
+	}
+
+	switch s.Conflict {
+	case MergeConflictError:
+		return nil, fmt.Errorf(`type mismatch between %v and %v at %q`, dst.Type(), src.Type(), path.Pointer())
+	case MergeConflictPreferSrc:
+		return mergeImpl(path, nil, src, opts)
+	case MergeConflictPreferDst:
+		return dst.Clone(), nil
+	case MergeConflictCallback:
+		assert.Params(s.Resolve != nil, "Resolve must be set for MergeConflictCallback")
+		resolved, err := s.Resolve(path, dst.Clone(), src.Clone())
+		if err != nil {
+			return nil, err
+		}
+		if resolved == nil {
+			return nil, fmt.Errorf(`resolved JSON value at %q must not be nil`, path.Pointer())
+		}
+		return resolved, nil
+	default:
+		return assert.Unexpected2[Value, error](`invalid MergeConflictStrategy: %v`, s.Conflict)
+	}
+}
+
+func mergeObject(path Path, dst Value, src Value, opts MergeOptions) (Value, error) {
+	merged := dst.Clone()
+	keys := src.ObjectKeys()
+	slices.Sort(keys)
+	for _, key := range keys {
+		var d Value
+		if merged.ObjectHasElm(key) {
+			d = merged.ObjectGetElm(key)
+		}
+		m, err := mergeImpl(path.Append(KeyName(key)), d, src.ObjectGetElm(key), opts)
+		if errors.Is(err, DeleteValue) {
+			merged.ObjectDelElm(key)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged.ObjectSetElm(key, m)
+	}
+
+	return merged, nil
+}
+
+func mergeArray(path Path, dst Value, src Value, s MergeStrategy, opts MergeOptions) (Value, error) {
+	elms := []Value{}
+	for _, elm := range dst.ArrayAll() {
+		elms = append(elms, elm.Clone())
+	}
+	// appendSrc appends the element of src at the index i to elms.
+	appendSrc := func(i int) error {
+		m, err := mergeImpl(path.Append(KeyIndex(len(elms))), nil, src.ArrayGetElm(i), opts)
+		if errors.Is(err, DeleteValue) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		elms = append(elms, m)
+		return nil
+	}
+	// mergeAt merges the element of src at the index i into elms[j], which is set to nil if it should be removed.
+	mergeAt := func(j int, i int) error {
+		m, err := mergeImpl(path.Append(KeyIndex(j)), elms[j], src.ArrayGetElm(i), opts)
+		if errors.Is(err, DeleteValue) {
+			elms[j] = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		elms[j] = m
+		return nil
+	}
+
+	switch s.Array {
+	case MergeArrayReplace:
+		elms = []Value{}
+		for i := 0; i < src.ArrayLen(); i++ {
+			if err := appendSrc(i); err != nil {
+				return nil, err
+			}
+		}
+	case MergeArrayAppend:
+		for i := 0; i < src.ArrayLen(); i++ {
+			if err := appendSrc(i); err != nil {
+				return nil, err
+			}
+		}
+	case MergeArrayByIndex:
+		n := len(elms)
+		for i := 0; i < src.ArrayLen(); i++ {
+			var err error
+			if i < n {
+				err = mergeAt(i, i)
+			} else {
+				err = appendSrc(i)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	case MergeArrayByKey:
+		n := len(elms)
+		for i := 0; i < src.ArrayLen(); i++ {
+			j := slices.IndexFunc(elms[:n], func(elm Value) bool {
+				return elm != nil && sameArrayKey(elm, src.ArrayGetElm(i), s.ArrayKey)
+			})
+			var err error
+			if j >= 0 {
+				err = mergeAt(j, i)
+			} else {
+				err = appendSrc(i)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return assert.Unexpected2[Value, error](`invalid MergeArrayStrategy: %v`, s.Array)
+	}
+
+	merged := Array()
+	for _, elm := range elms {
+		if elm != nil {
+			merged.ArrayAddElm(elm)
+		}
+	}
+
+	return merged, nil
+}
+
+func sameArrayKey(a Value, b Value, key string) bool {
+	if a.Type() != TypeObject || b.Type() != TypeObject || !a.ObjectHasElm(key) || !b.ObjectHasElm(key) {
+		return false
+	}
+
+	return Equal(a.ObjectGetElm(key), b.ObjectGetElm(key))
+}
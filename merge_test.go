@@ -0,0 +1,162 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestMerge(t *testing.T) {
+	type testCase struct {
+		name string
+		dst  string
+		src  string
+		opts jsonvalue.MergeOptions
+		want string
+	}
+	testCases := []testCase{
+		{
+			name: "objects",
+			dst:  `{"a":1,"b":{"x":1,"y":2}}`,
+			src:  `{"b":{"y":3,"z":4},"c":5}`,
+			want: `{"a":1,"b":{"x":1,"y":3,"z":4},"c":5}`,
+		},
+		{
+			name: "array replace",
+			dst:  `{"a":[1,2,3]}`,
+			src:  `{"a":[4]}`,
+			want: `{"a":[4]}`,
+		},
+		{
+			name: "array append",
+			dst:  `{"a":[1,2]}`,
+			src:  `{"a":[2,3]}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayAppend}},
+			want: `{"a":[1,2,2,3]}`,
+		},
+		{
+			name: "array by index",
+			dst:  `[{"a":1},{"b":2}]`,
+			src:  `[{"c":3},{"b":4},5]`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayByIndex}},
+			want: `[{"a":1,"c":3},{"b":4},5]`,
+		},
+		{
+			name: "array by key",
+			dst:  `[{"id":1,"v":"a"},{"id":2,"v":"b"},"x"]`,
+			src:  `[{"id":2,"v":"c","w":true},{"id":3},"x"]`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayByKey, ArrayKey: "id"}},
+			want: `[{"id":1,"v":"a"},{"id":2,"v":"c","w":true},"x",{"id":3},"x"]`,
+		},
+		{
+			name: "null replace",
+			dst:  `{"a":1}`,
+			src:  `{"a":null,"b":null}`,
+			want: `{"a":null,"b":null}`,
+		},
+		{
+			name: "null ignore",
+			dst:  `{"a":1}`,
+			src:  `{"a":null,"b":null}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Null: jsonvalue.MergeNullIgnore}},
+			want: `{"a":1}`,
+		},
+		{
+			name: "null delete",
+			dst:  `{"a":1,"b":{"c":2}}`,
+			src:  `{"a":null,"b":{"c":null,"d":{"e":null}}}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Null: jsonvalue.MergeNullDelete}},
+			want: `{"b":{"d":{}}}`,
+		},
+		{
+			name: "null delete in array",
+			dst:  `[1,2,3]`,
+			src:  `[null,4]`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayByIndex, Null: jsonvalue.MergeNullDelete}},
+			want: `[4,3]`,
+		},
+		{
+			name: "null dst",
+			dst:  `{"a":null}`,
+			src:  `{"a":[1]}`,
+			want: `{"a":[1]}`,
+		},
+		{
+			name: "root deleted",
+			dst:  `{"a":1}`,
+			src:  `null`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Null: jsonvalue.MergeNullDelete}},
+			want: `null`,
+		},
+		{
+			name: "conflict prefer src",
+			dst:  `{"a":{"x":1}}`,
+			src:  `{"a":[1]}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Conflict: jsonvalue.MergeConflictPreferSrc}},
+			want: `{"a":[1]}`,
+		},
+		{
+			name: "conflict prefer dst",
+			dst:  `{"a":{"x":1}}`,
+			src:  `{"a":[1]}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{Conflict: jsonvalue.MergeConflictPreferDst}},
+			want: `{"a":{"x":1}}`,
+		},
+		{
+			name: "conflict callback",
+			dst:  `{"a":"1","b":true}`,
+			src:  `{"a":2,"b":"x"}`,
+			opts: jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{
+				Conflict: jsonvalue.MergeConflictCallback,
+				Resolve: func(path jsonvalue.Path, dst jsonvalue.Value, src jsonvalue.Value) (jsonvalue.Value, error) {
+					if path.Pointer() == "/b" {
+						return nil, jsonvalue.DeleteValue
+					}
+					return jsonvalue.Array(dst, src), nil
+				},
+			}},
+			want: `{"a":["1",2]}`,
+		},
+		{
+			name: "rules",
+			dst:  `{"servers":[{"name":"a","port":1}],"tags":["x"],"env":{"A":"1"}}`,
+			src:  `{"servers":[{"name":"a","port":2},{"name":"b"}],"tags":["y"],"env":{"A":null}}`,
+			opts: jsonvalue.MergeOptions{
+				Default: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayAppend},
+				Rules: []jsonvalue.MergeRule{
+					{Pattern: jsonvalue.MustParsePathPattern("/servers"), Strategy: jsonvalue.MergeStrategy{Array: jsonvalue.MergeArrayByKey, ArrayKey: "name"}},
+					{Pattern: jsonvalue.MustParsePathPattern("/env/*"), Strategy: jsonvalue.MergeStrategy{Null: jsonvalue.MergeNullDelete}},
+				},
+			},
+			want: `{"servers":[{"name":"a","port":2},{"name":"b"}],"tags":["x","y"],"env":{}}`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			dst := mustUnmarshal(t, testCase.dst)
+			src := mustUnmarshal(t, testCase.src)
+
+			got, err := jsonvalue.Merge(dst, src, testCase.opts)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+			equal(t, jsonvalue.Equal(dst, mustUnmarshal(t, testCase.dst)), true)
+			equal(t, jsonvalue.Equal(src, mustUnmarshal(t, testCase.src)), true)
+		})
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	_, err := jsonvalue.Merge(mustUnmarshal(t, `{"a":{"b":1}}`), mustUnmarshal(t, `{"a":{"b":"1"}}`), jsonvalue.MergeOptions{})
+	IsNotNil(t, err)
+
+	resolveNil := jsonvalue.MergeOptions{Default: jsonvalue.MergeStrategy{
+		Conflict: jsonvalue.MergeConflictCallback,
+		Resolve: func(path jsonvalue.Path, dst jsonvalue.Value, src jsonvalue.Value) (jsonvalue.Value, error) {
+			return nil, nil
+		},
+	}}
+	_, err = jsonvalue.Merge(mustUnmarshal(t, `{"a":1}`), mustUnmarshal(t, `{"a":"1"}`), resolveNil)
+	IsNotNil(t, err)
+	_, err = jsonvalue.Merge(mustUnmarshal(t, `[1]`), mustUnmarshal(t, `{"a":"1"}`), resolveNil)
+	IsNotNil(t, err)
+}
@@ -0,0 +1,400 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	msgpackTimestampType   = -1
+	msgpackMaxNestingDepth = 1000
+)
+
+// MsgpackExtension decodes the data of a MessagePack extension type into a JSON value.
+type MsgpackExtension func(data []byte) (Value, error)
+
+// MsgpackOptions configures UnmarshalMsgpack.
+type MsgpackOptions struct {
+	// Bin decodes binary data into a JSON value.
+	// If Bin is nil, binary data is decoded into a string in base64url encoding without padding.
+	Bin func(data []byte) (Value, error)
+	// Extensions maps extension types to the MsgpackExtensions decoding them.
+	// The timestamp extension type -1 is decoded into a string in RFC 3339 format unless it is registered.
+	Extensions map[int8]MsgpackExtension
+}
+
+// MarshalMsgpack encodes a JSON value v into MessagePack.
+// Numbers written as integers are encoded as the shortest uint family for non-negative ones or the shortest int family for negative ones,
+// and the other numbers are encoded as float 32 if it preserves the value or float 64 otherwise.
+// Strings are encoded as the str family and object members are sorted by their keys.
+// Integers beyond the range of int 64 and uint 64 are rejected with errors.
+func MarshalMsgpack(v Value) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalMsgpack(&b, v); err != nil {
+		return nil, fmt.Errorf(`fail to marshal Value into MessagePack: %w`, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeMsgpackHead writes the header of a str, an array or a map of the length n.
+// The fix format with the prefix fix is used if n is less than fixLimit,
+// and otherwise the 8-bit format head8 if it exists, the 16-bit format head16 or the 32-bit format following head16 is used.
+func writeMsgpackHead(b *bytes.Buffer, n int, fix byte, fixLimit int, head8 byte, head16 byte) {
+	switch {
+	case n < fixLimit:
+		b.WriteByte(fix | byte(n))
+	case head8 != 0 && n <= math.MaxUint8:
+		b.Write([]byte{head8, byte(n)})
+	case n <= math.MaxUint16:
+		b.Write(binary.BigEndian.AppendUint16([]byte{head16}, uint16(n)))
+	default:
+		b.Write(binary.BigEndian.AppendUint32([]byte{head16 + 1}, uint32(n)))
+	}
+}
+
+func marshalMsgpack(b *bytes.Buffer, v Value) error {
+	switch v.Type() {
+	case TypeNull:
+		b.WriteByte(0xc0)
+	case TypeBoolean:
+		if v.BooleanGet() {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case TypeNumber:
+		return marshalMsgpackNumber(b, v.NumberGet())
+	case TypeString:
+		writeMsgpackHead(b, len(v.StringGet()), 0xa0, 32, 0xd9, 0xda)
+		b.WriteString(v.StringGet())
+	case TypeArray:
+		writeMsgpackHead(b, v.ArrayLen(), 0x90, 16, 0, 0xdc)
+		for _, elm := range v.ArrayAll() {
+			if err := marshalMsgpack(b, elm); err != nil {
+				return err
+			}
+		}
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		writeMsgpackHead(b, len(keys), 0x80, 16, 0, 0xde)
+		for _, key := range keys {
+			writeMsgpackHead(b, len(key), 0xa0, 32, 0xd9, 0xda)
+			b.WriteString(key)
+			if err := marshalMsgpack(b, v.ObjectGetElm(key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func marshalMsgpackNumber(b *bytes.Buffer, n json.Number) error {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			switch {
+			case u < 0x80:
+				b.WriteByte(byte(u))
+			case u <= math.MaxUint8:
+				b.Write([]byte{0xcc, byte(u)})
+			case u <= math.MaxUint16:
+				b.Write(binary.BigEndian.AppendUint16([]byte{0xcd}, uint16(u)))
+			case u <= math.MaxUint32:
+				b.Write(binary.BigEndian.AppendUint32([]byte{0xce}, uint32(u)))
+			default:
+				b.Write(binary.BigEndian.AppendUint64([]byte{0xcf}, u))
+			}
+			return nil
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			switch {
+			case i >= -32:
+				b.WriteByte(byte(int8(i)))
+			case i >= math.MinInt8:
+				b.Write([]byte{0xd0, byte(int8(i))})
+			case i >= math.MinInt16:
+				b.Write(binary.BigEndian.AppendUint16([]byte{0xd1}, uint16(int16(i))))
+			case i >= math.MinInt32:
+				b.Write(binary.BigEndian.AppendUint32([]byte{0xd2}, uint32(int32(i))))
+			default:
+				b.Write(binary.BigEndian.AppendUint64([]byte{0xd3}, uint64(i)))
+			}
+			return nil
+		}
+		return fmt.Errorf(`integer %s is out of the range of MessagePack integers`, s)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf(`number %s is not representable in IEEE 754 double precision: %w`, s, err)
+	}
+	if float64(float32(f)) == f {
+		b.Write(binary.BigEndian.AppendUint32([]byte{0xca}, math.Float32bits(float32(f))))
+	} else {
+		b.Write(binary.BigEndian.AppendUint64([]byte{0xcb}, math.Float64bits(f)))
+	}
+
+	return nil
+}
+
+// UnmarshalMsgpack decodes a MessagePack object into a JSON value.
+// Binary data and extension types are decoded according to opts, and unregistered extension types are rejected with errors.
+// Map keys must be strings, and duplicate keys, invalid UTF-8 in strings, NaN, infinities and trailing data are rejected with errors.
+func UnmarshalMsgpack(b []byte, opts MsgpackOptions) (Value, error) {
+	d := &msgpackDecoder{src: b, opts: opts}
+	v, err := d.decode(0)
+	if err == nil && d.pos < len(d.src) {
+		err = fmt.Errorf(`unexpected data after MessagePack object at offset %d`, d.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`fail to unmarshal MessagePack into Value: %w`, err)
+	}
+
+	return v, nil
+}
+
+type msgpackDecoder struct {
+	src  []byte
+	pos  int
+	opts MsgpackOptions
+}
+
+func (d *msgpackDecoder) read(n uint64) ([]byte, error) {
+	if uint64(len(d.src)-d.pos) < n {
+		return nil, fmt.Errorf(`unexpected end of MessagePack data`)
+	}
+	b := d.src[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return b, nil
+}
+
+// readUint reads a big-endian unsigned integer of size bytes.
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.read(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (Value, error) {
+	if depth > msgpackMaxNestingDepth {
+		return nil, fmt.Errorf(`MessagePack objects are nested too deeply`)
+	}
+	start := d.pos
+	head, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := head[0]; {
+	case c <= 0x7f:
+		return Number(int(c)), nil
+	case c >= 0xe0:
+		return Number(int(int8(c))), nil
+	case c&0xf0 == 0x80:
+		return d.decodeMap(depth, uint64(c&0x0f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(depth, uint64(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return d.decodeStr(start, uint64(c&0x1f))
+	case c == 0xc0:
+		return Null(), nil
+	case c == 0xc2:
+		return Boolean(false), nil
+	case c == 0xc3:
+		return Boolean(true), nil
+	case 0xc4 <= c && c <= 0xc6:
+		n, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.read(n)
+		if err != nil {
+			return nil, err
+		}
+		if d.opts.Bin == nil {
+			return String(base64.RawURLEncoding.EncodeToString(data)), nil
+		}
+		return d.opts.Bin(bytes.Clone(data))
+	case 0xc7 <= c && c <= 0xc9:
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(start, n)
+	case c == 0xca:
+		u, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeFloat(start, float64(math.Float32frombits(uint32(u))))
+	case c == 0xcb:
+		u, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeFloat(start, math.Float64frombits(u))
+	case 0xcc <= c && c <= 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return Number(u), nil
+	case 0xd0 <= c && c <= 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extends the integer of size bytes.
+		shift := 64 - 8*size
+		return Number(int64(u<<shift) >> shift), nil
+	case 0xd4 <= c && c <= 0xd8:
+		return d.decodeExt(start, 1<<(c-0xd4))
+	case 0xd9 <= c && c <= 0xdb:
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeStr(start, n)
+	case c == 0xdc || c == 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(depth, n)
+	case c == 0xde || c == 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(depth, n)
+	default:
+		return nil, fmt.Errorf(`unused format 0x%02x at offset %d`, c, start)
+	}
+}
+
+func (d *msgpackDecoder) decodeStr(start int, n uint64) (Value, error) {
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf(`str at offset %d is not valid UTF-8`, start)
+	}
+
+	return String(string(data)), nil
+}
+
+func (d *msgpackDecoder) decodeArray(depth int, n uint64) (Value, error) {
+	arr := Array()
+	for i := uint64(0); i < n; i++ {
+		elm, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr.ArrayAddElm(elm)
+	}
+
+	return arr, nil
+}
+
+func (d *msgpackDecoder) decodeMap(depth int, n uint64) (Value, error) {
+	obj := Object()
+	for i := uint64(0); i < n; i++ {
+		keyPos := d.pos
+		if keyPos < len(d.src) {
+			if c := d.src[keyPos]; c&0xe0 != 0xa0 && (c < 0xd9 || 0xdb < c) {
+				return nil, fmt.Errorf(`map key at offset %d must be a str`, keyPos)
+			}
+		}
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if obj.ObjectHasElm(key.StringGet()) {
+			return nil, fmt.Errorf(`duplicate map key %q at offset %d`, key.StringGet(), keyPos)
+		}
+		val, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		obj.ObjectSetElm(key.StringGet(), val)
+	}
+
+	return obj, nil
+}
+
+func (d *msgpackDecoder) decodeExt(start int, n uint64) (Value, error) {
+	typ, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext, ok := d.opts.Extensions[int8(typ[0])]; ok {
+		return ext(bytes.Clone(data))
+	}
+	if int8(typ[0]) == msgpackTimestampType {
+		t, err := decodeMsgpackTimestamp(data)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid timestamp at offset %d: %w`, start, err)
+		}
+		return String(t.Format(time.RFC3339Nano)), nil
+	}
+
+	return nil, fmt.Errorf(`unregistered extension type %d at offset %d`, int8(typ[0]), start)
+}
+
+func decodeMsgpackTimestamp(data []byte) (time.Time, error) {
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		nsec, sec := u>>34, u&(1<<34-1)
+		if nsec >= 1e9 {
+			return time.Time{}, fmt.Errorf(`nanoseconds %d out of range`, nsec)
+		}
+		return time.Unix(int64(sec), int64(nsec)).UTC(), nil
+	case 12:
+		nsec, sec := binary.BigEndian.Uint32(data), int64(binary.BigEndian.Uint64(data[4:]))
+		if nsec >= 1e9 {
+			return time.Time{}, fmt.Errorf(`nanoseconds %d out of range`, nsec)
+		}
+		return time.Unix(sec, int64(nsec)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf(`invalid data length %d`, len(data))
+	}
+}
+
+func (d *msgpackDecoder) decodeFloat(start int, f float64) (Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf(`%v at offset %d cannot be represented in JSON`, f, start)
+	}
+
+	return Number(json.Number(formatCanonicalNumber(f))), nil
+}
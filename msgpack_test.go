@@ -0,0 +1,165 @@
+package jsonvalue_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestMarshalMsgpack(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "positive fixint", in: `127`, want: "7f"},
+		{name: "uint 8", in: `128`, want: "cc80"},
+		{name: "uint 16", in: `256`, want: "cd0100"},
+		{name: "uint 32", in: `65536`, want: "ce00010000"},
+		{name: "uint 64", in: `18446744073709551615`, want: "cfffffffffffffffff"},
+		{name: "negative fixint", in: `-32`, want: "e0"},
+		{name: "int 8", in: `-33`, want: "d0df"},
+		{name: "int 16", in: `-129`, want: "d1ff7f"},
+		{name: "int 32", in: `-32769`, want: "d2ffff7fff"},
+		{name: "int 64", in: `-9223372036854775808`, want: "d38000000000000000"},
+		{name: "float 32", in: `1.5`, want: "ca3fc00000"},
+		{name: "float 32 integer", in: `1.0`, want: "ca3f800000"},
+		{name: "float 64", in: `0.1`, want: "cb3fb999999999999a"},
+		{name: "literals", in: `[null,false,true]`, want: "93c0c2c3"},
+		{name: "fixstr", in: `"abc"`, want: "a3616263"},
+		{name: "str 8", in: `"` + strings.Repeat("a", 32) + `"`, want: "d920" + strings.Repeat("61", 32)},
+		{name: "fixmap", in: `{"b":1,"a":[]}`, want: "82a16190a16201"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.MarshalMsgpack(mustUnmarshal(t, testCase.in))
+			equal(t, err, nil)
+			equal(t, hex.EncodeToString(got), testCase.want)
+		})
+	}
+	t.Run("array 16", func(t *testing.T) {
+		got, err := jsonvalue.MarshalMsgpack(mustUnmarshal(t, "["+strings.Repeat("0,", 15)+"0]"))
+		equal(t, err, nil)
+		equal(t, hex.EncodeToString(got), "dc0010"+strings.Repeat("00", 16))
+	})
+}
+
+func TestMarshalMsgpack_Error(t *testing.T) {
+	testCases := map[string]string{
+		"too large integer": `18446744073709551616`,
+		"too small integer": `-9223372036854775809`,
+		"too large float":   `1e400`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.MarshalMsgpack(mustUnmarshal(t, in))
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestUnmarshalMsgpack(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "integers", in: "9a 7f e0 cc80 cd0100 ce00010000 cfffffffffffffffff d0df d1ff7f d2ffff7fff d38000000000000000", want: `[127,-32,128,256,65536,18446744073709551615,-33,-129,-32769,-9223372036854775808]`},
+		{name: "floats", in: "92 ca3dcccccd cb3fb999999999999a", want: `[0.10000000149011612,0.1]`},
+		{name: "literals", in: "93 c0 c2 c3", want: `[null,false,true]`},
+		{name: "strings", in: "93 a3616263 d903616263 da0003616263", want: `["abc","abc","abc"]`},
+		{name: "binaries", in: "93 c40401020304 c5000101 c60000000100", want: `["AQIDBA","AQ","AA"]`},
+		{name: "collections", in: "82 a161 dc0002 01 02 a162 de0001 a163 dd00000000", want: `{"a":[1,2],"b":{"c":[]}}`},
+		{name: "timestamp 32", in: "d6ff 00000000", want: `"1970-01-01T00:00:00Z"`},
+		{name: "timestamp 64", in: "d7ff 00000004 00000001", want: `"1970-01-01T00:00:01.000000001Z"`},
+		{name: "timestamp 96", in: "c70cff 00000000 ffffffffffffffff", want: `"1969-12-31T23:59:59Z"`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(strings.ReplaceAll(testCase.in, " ", ""))
+			got, err := jsonvalue.UnmarshalMsgpack(b, jsonvalue.MsgpackOptions{})
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestUnmarshalMsgpack_Options(t *testing.T) {
+	opts := jsonvalue.MsgpackOptions{
+		Bin: func(data []byte) (jsonvalue.Value, error) {
+			return jsonvalue.String(hex.EncodeToString(data)), nil
+		},
+		Extensions: map[int8]jsonvalue.MsgpackExtension{
+			1: func(data []byte) (jsonvalue.Value, error) {
+				return jsonvalue.Object(jsonvalue.Props{"point": jsonvalue.Array(jsonvalue.Number(int(data[0])), jsonvalue.Number(int(data[1])))}), nil
+			},
+			-1: func(data []byte) (jsonvalue.Value, error) {
+				return jsonvalue.Number(len(data)), nil
+			},
+			2: func(data []byte) (jsonvalue.Value, error) {
+				return nil, fmt.Errorf("bad extension")
+			},
+		},
+	}
+	b, _ := hex.DecodeString("93" + "c4020a0b" + "d5010304" + "d6ff00000000")
+	got, err := jsonvalue.UnmarshalMsgpack(b, opts)
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, mustUnmarshal(t, `["0a0b",{"point":[3,4]},4]`)), true)
+
+	_, err = jsonvalue.UnmarshalMsgpack([]byte{0xd4, 0x02, 0x00}, opts)
+	IsNotNil(t, err)
+}
+
+func TestUnmarshalMsgpack_Error(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                  "",
+		"unused format":          "c1",
+		"truncated integer":      "cd01",
+		"truncated str":          "a3 6162",
+		"truncated array":        "92 01",
+		"trailing data":          "01 02",
+		"invalid utf8":           "a1 ff",
+		"non-str key":            "81 01 02",
+		"bin key":                "81 c40161 01",
+		"duplicate key":          "82 a161 01 a161 02",
+		"nan":                    "ca 7fc00000",
+		"infinity":               "cb 7ff0000000000000",
+		"unregistered extension": "d4 05 00",
+		"invalid timestamp size": "d5ff 0000",
+		"invalid timestamp nsec": "c70cff ffffffff 0000000000000000",
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, _ := hex.DecodeString(strings.ReplaceAll(in, " ", ""))
+			_, err := jsonvalue.UnmarshalMsgpack(b, jsonvalue.MsgpackOptions{})
+			IsNotNil(t, err)
+		})
+	}
+	t.Run("deeply nested", func(t *testing.T) {
+		_, err := jsonvalue.UnmarshalMsgpack(bytes.Repeat([]byte{0x91}, 2000), jsonvalue.MsgpackOptions{})
+		IsNotNil(t, err)
+	})
+}
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	in := mustUnmarshal(t, `{"name":"x","values":[0,-1,255,-200,1.5,0.1,1e300,18446744073709551615],"nested":{"ok":true,"none":null}}`)
+	b, err := jsonvalue.MarshalMsgpack(in)
+	equal(t, err, nil)
+	got, err := jsonvalue.UnmarshalMsgpack(b, jsonvalue.MsgpackOptions{})
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+
+	// The numbers encoded in single precision without their shortest forms.
+	in = mustUnmarshal(t, `[1.100000023841858,3.4028234663852886e38,-1.401298464324817e-45]`)
+	b, err = jsonvalue.MarshalMsgpack(in)
+	equal(t, err, nil)
+	got, err = jsonvalue.UnmarshalMsgpack(b, jsonvalue.MsgpackOptions{})
+	equal(t, err, nil)
+	equal(t, jsonvalue.Equal(got, in), true)
+}
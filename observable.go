@@ -0,0 +1,500 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"iter"
+	"sync"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// Op represents a kind of change on a JSON value.
+type Op int
+
+const (
+	// OpSet represents that an object member or an array element is added or replaced by ObjectSetElm or ArraySetElm.
+	OpSet Op = iota
+	// OpAdd represents that an array element is inserted by ArrayAddElm, ArrayInsertElm, ArraySplice or ArrayPrepend, shifting the following elements.
+	OpAdd
+	// OpDelete represents that an object member is deleted by ObjectDelElm, or an array element is deleted by ArrayDelElm or ArraySplice, shifting the following elements.
+	OpDelete
+	// OpAssign represents that a JSON value is replaced in place by Assign, UnmarshalJSON, ArraySort or ArrayStableSort.
+	OpAssign
+)
+
+// String provides a representation in string.
+func (o Op) String() string {
+	switch o {
+	case OpSet:
+		return `set`
+	case OpAdd:
+		return `add`
+	case OpDelete:
+		return `delete`
+	case OpAssign:
+		return `assign`
+	default:
+		panic("invalid Op")
+	}
+}
+
+// ChangeEvent represents a change on a JSON value included in an Observable.
+type ChangeEvent struct {
+	// Op is the kind of the change.
+	Op Op
+	// Path is the location of the changed JSON value from the root of the Observable.
+	Path Path
+	// Old is the JSON value before the change, or nil if it did not exist.
+	Old Value
+	// New is the JSON value after the change, or nil if it was deleted.
+	New Value
+}
+
+// Observable is a Value which notifies subscribers of changes on itself and the JSON values included in it.
+// Changes made through the Observable and the JSON values obtained by its ObjectGetElm and ArrayGetElm are notified,
+// while changes made directly to the underlying JSON values are not.
+// The Path of a JSON value obtained from an Observable follows the shifts and the sorting of array elements made through the Observable,
+// and changes on a JSON value which is no longer included in the Observable, e.g. after it is deleted or replaced, are not notified.
+type Observable interface {
+	Value
+	// Subscribe registers fn to be called after each change on the JSON value at the prefix relative to this JSON value, on its ancestors, or on its descendants.
+	// Subscribing to a JSON value which is no longer included in the Observable has no effect.
+	// Calling the returned function cancels the subscription.
+	// fn must not modify Old and New of the ChangeEvent.
+	Subscribe(prefix Path, fn func(e ChangeEvent)) (unsubscribe func())
+}
+
+// Observe returns an Observable wrapping a JSON value v.
+// v must not be modified directly while it is used through the Observable.
+func Observe(v Value) Observable {
+	assert.Params(v != nil, "Value must not be nil")
+
+	return newObservable(unwrapObservable(v))
+}
+
+type observer struct {
+	mu            sync.Mutex
+	nextID        int
+	subscriptions []subscription
+}
+
+type subscription struct {
+	id     int
+	prefix Path
+	fn     func(e ChangeEvent)
+}
+
+func (o *observer) subscribe(prefix Path, fn func(e ChangeEvent)) func() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	id := o.nextID
+	o.nextID++
+	o.subscriptions = append(o.subscriptions, subscription{id: id, prefix: prefix, fn: fn})
+
+	return func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+
+		for i, s := range o.subscriptions {
+			if s.id == id {
+				o.subscriptions = append(o.subscriptions[:i:i], o.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (o *observer) targets(path Path) []subscription {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	targets := []subscription{}
+	for _, s := range o.subscriptions {
+		if path.HasPrefix(s.prefix) || s.prefix.HasPrefix(path) {
+			targets = append(targets, s)
+		}
+	}
+
+	return targets
+}
+
+func (o *observer) notify(targets []subscription, e ChangeEvent) {
+	for _, s := range targets {
+		s.fn(e)
+	}
+}
+
+// observable implements Observable
+type observable struct {
+	observer *observer
+	node     *observableNode
+	v        Value
+}
+
+// observableNode is the position of a JSON value in the root of an Observable, which is shared by the observables obtained for the position.
+// The keys of the nodes are updated when array elements are shifted through the Observable,
+// and a node is detached when its JSON value is deleted or replaced.
+type observableNode struct {
+	// parent is nil for the root.
+	parent   *observableNode
+	key      Key
+	detached bool
+	// children are the nodes of the positions in this JSON value obtained so far.
+	children map[Key]*observableNode
+}
+
+func newObservable(v Value) *observable {
+	return &observable{observer: &observer{}, node: &observableNode{}, v: v}
+}
+
+func unwrapObservable(v Value) Value {
+	if o, ok := v.(*observable); ok {
+		return o.v
+	}
+
+	return v
+}
+
+func (o *observable) child(key Key, v Value) Value {
+	n := o.node.children[key]
+	if n == nil {
+		if o.node.children == nil {
+			o.node.children = map[Key]*observableNode{}
+		}
+		n = &observableNode{parent: o.node, key: key}
+		o.node.children[key] = n
+	}
+
+	return &observable{observer: o.observer, node: n, v: v}
+}
+
+// detach detaches the node at the key, whose JSON value is deleted or replaced.
+func (n *observableNode) detach(key Key) {
+	if c, ok := n.children[key]; ok {
+		c.detached = true
+		delete(n.children, key)
+	}
+}
+
+// detachAll detaches the nodes of all the positions in this JSON value, whose content is replaced.
+func (n *observableNode) detachAll() {
+	for _, c := range n.children {
+		c.detached = true
+	}
+	n.children = nil
+}
+
+// reindex updates the indices of the nodes of array elements by index, detaching the nodes for which index returns a negative value.
+func (n *observableNode) reindex(index func(i int) int) {
+	children := map[Key]*observableNode{}
+	for key, c := range n.children {
+		if !key.IsIndex() {
+			children[key] = c
+			continue
+		}
+		i := index(key.Int())
+		if i < 0 {
+			c.detached = true
+			continue
+		}
+		c.key = KeyIndex(i)
+		children[c.key] = c
+	}
+	n.children = children
+}
+
+// path returns the current Path of this JSON value from the root.
+// If this JSON value is no longer included in the root, path returns false.
+func (o *observable) path() (Path, bool) {
+	depth := 0
+	for n := o.node; n.parent != nil; n = n.parent {
+		if n.detached {
+			return nil, false
+		}
+		depth++
+	}
+
+	path := make(Path, depth)
+	for n := o.node; n.parent != nil; n = n.parent {
+		depth--
+		path[depth] = n.key
+	}
+
+	return path, true
+}
+
+// targets returns the current Path of the changed JSON value at the keys relative to this JSON value and the subscriptions to be notified.
+// If this JSON value is no longer included in the root, no subscriptions are returned.
+func (o *observable) targets(keys ...Key) (Path, []subscription) {
+	path, ok := o.path()
+	if !ok {
+		return nil, nil
+	}
+	for _, key := range keys {
+		path = path.Append(key)
+	}
+
+	return path, o.observer.targets(path)
+}
+
+func (o *observable) Subscribe(prefix Path, fn func(e ChangeEvent)) func() {
+	assert.Params(fn != nil, "fn must not be nil")
+
+	path, ok := o.path()
+	if !ok {
+		return func() {}
+	}
+
+	return o.observer.subscribe(path.Join(prefix), fn)
+}
+
+func (o *observable) MarshalJSON() ([]byte, error) {
+	return o.v.MarshalJSON()
+}
+
+func (o *observable) UnmarshalJSON(b []byte) error {
+	v := Null()
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+
+	o.Assign(v)
+
+	return nil
+}
+
+func (o *observable) Type() Type {
+	return o.v.Type()
+}
+
+func (o *observable) Assign(v Value) {
+	v = unwrapObservable(v)
+	switch v.Type() {
+	case TypeArray:
+		elms := make([]Value, v.ArrayLen())
+		for i := range elms {
+			elms[i] = unwrapObservable(v.ArrayGetElm(i))
+		}
+		v = Array(elms...)
+	case TypeObject:
+		props := Props{}
+		for _, key := range v.ObjectKeys() {
+			props[key] = unwrapObservable(v.ObjectGetElm(key))
+		}
+		v = Object(props)
+	}
+	path, targets := o.targets()
+
+	var old Value
+	if len(targets) > 0 {
+		old = o.v.Clone()
+	}
+	o.v.Assign(v)
+	o.node.detachAll()
+
+	o.observer.notify(targets, ChangeEvent{Op: OpAssign, Path: path, Old: old, New: o.v})
+}
+
+func (o *observable) Clone() Value {
+	return o.v.Clone()
+}
+
+func (o *observable) NumberGet() json.Number {
+	return o.v.NumberGet()
+}
+
+func (o *observable) StringGet() string {
+	return o.v.StringGet()
+}
+
+func (o *observable) BooleanGet() bool {
+	return o.v.BooleanGet()
+}
+
+func (o *observable) ObjectKeys() []string {
+	return o.v.ObjectKeys()
+}
+
+func (o *observable) ObjectHasElm(key string) bool {
+	return o.v.ObjectHasElm(key)
+}
+
+func (o *observable) ObjectGetElm(key string) Value {
+	return o.child(KeyName(key), o.v.ObjectGetElm(key))
+}
+
+func (o *observable) ObjectSetElm(key string, v Value) {
+	assert.Params(v != nil, "Value must be not nil")
+
+	v = unwrapObservable(v)
+	var old Value
+	if o.v.ObjectHasElm(key) {
+		old = o.v.ObjectGetElm(key)
+	}
+	o.v.ObjectSetElm(key, v)
+	o.node.detach(KeyName(key))
+
+	path, targets := o.targets(KeyName(key))
+	o.observer.notify(targets, ChangeEvent{Op: OpSet, Path: path, Old: old, New: v})
+}
+
+func (o *observable) ObjectDelElm(key string) {
+	if !o.v.ObjectHasElm(key) {
+		o.v.ObjectDelElm(key)
+		return
+	}
+
+	old := o.v.ObjectGetElm(key)
+	o.v.ObjectDelElm(key)
+	o.node.detach(KeyName(key))
+
+	path, targets := o.targets(KeyName(key))
+	o.observer.notify(targets, ChangeEvent{Op: OpDelete, Path: path, Old: old})
+}
+
+func (o *observable) ObjectAll() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		for key, v := range o.v.ObjectAll() {
+			if !yield(key, o.child(KeyName(key), v)) {
+				return
+			}
+		}
+	}
+}
+
+func (o *observable) ObjectLen() int {
+	return o.v.ObjectLen()
+}
+
+func (o *observable) ArrayGetElm(index int) Value {
+	return o.child(KeyIndex(index), o.v.ArrayGetElm(index))
+}
+
+func (o *observable) ArrayAll() iter.Seq2[int, Value] {
+	return func(yield func(int, Value) bool) {
+		for i, v := range o.v.ArrayAll() {
+			if !yield(i, o.child(KeyIndex(i), v)) {
+				return
+			}
+		}
+	}
+}
+
+func (o *observable) ArraySetElm(index int, v Value) {
+	v = unwrapObservable(v)
+	old := o.v.ArrayGetElm(index)
+	o.v.ArraySetElm(index, v)
+	o.node.detach(KeyIndex(index))
+
+	path, targets := o.targets(KeyIndex(index))
+	o.observer.notify(targets, ChangeEvent{Op: OpSet, Path: path, Old: old, New: v})
+}
+
+func (o *observable) ArrayAddElm(vs ...Value) {
+	for _, v := range vs {
+		v = unwrapObservable(v)
+		index := o.v.ArrayLen()
+		o.v.ArrayAddElm(v)
+
+		path, targets := o.targets(KeyIndex(index))
+		o.observer.notify(targets, ChangeEvent{Op: OpAdd, Path: path, New: v})
+	}
+}
+
+func (o *observable) ArrayLen() int {
+	return o.v.ArrayLen()
+}
+
+func (o *observable) ArraySlice(begin int, endExclusive int) Value {
+	return o.v.ArraySlice(begin, endExclusive)
+}
+
+func (o *observable) ArrayInsertElm(index int, vs ...Value) {
+	assert.Params(0 <= index && index <= o.v.ArrayLen(), "index must be in [0, %d]", o.v.ArrayLen())
+
+	for i, v := range vs {
+		v = unwrapObservable(v)
+		o.v.ArrayInsertElm(index+i, v)
+		o.node.reindex(func(j int) int {
+			if j >= index+i {
+				return j + 1
+			}
+			return j
+		})
+
+		path, targets := o.targets(KeyIndex(index + i))
+		o.observer.notify(targets, ChangeEvent{Op: OpAdd, Path: path, New: v})
+	}
+}
+
+func (o *observable) ArrayDelElm(index int) {
+	old := o.v.ArrayGetElm(index)
+	o.v.ArrayDelElm(index)
+	o.node.reindex(func(j int) int {
+		switch {
+		case j == index:
+			return -1
+		case j > index:
+			return j - 1
+		default:
+			return j
+		}
+	})
+
+	path, targets := o.targets(KeyIndex(index))
+	o.observer.notify(targets, ChangeEvent{Op: OpDelete, Path: path, Old: old})
+}
+
+func (o *observable) ArraySplice(begin int, endExclusive int, vs ...Value) Value {
+	removed := o.v.ArraySlice(begin, endExclusive)
+	for i := begin; i < endExclusive; i++ {
+		o.ArrayDelElm(begin)
+	}
+	o.ArrayInsertElm(begin, vs...)
+
+	return removed
+}
+
+func (o *observable) ArrayPrepend(vs ...Value) {
+	o.ArrayInsertElm(0, vs...)
+}
+
+func (o *observable) ArraySort(cmp func(a, b Value) int) {
+	o.sort(cmp)
+}
+
+func (o *observable) ArrayStableSort(cmp func(a, b Value) int) {
+	o.sort(cmp)
+}
+
+// sort sorts the elements stably, moving the nodes of the elements to their new indices.
+func (o *observable) sort(cmp func(a, b Value) int) {
+	path, targets := o.targets()
+
+	var old Value
+	if len(targets) > 0 {
+		old = o.v.Clone()
+	}
+	elms := make([]Value, o.v.ArrayLen())
+	order := make([]int, len(elms))
+	for i := range elms {
+		elms[i] = o.v.ArrayGetElm(i)
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) bool { return cmp(elms[a], elms[b]) < 0 })
+	moved := make([]int, len(elms))
+	for j, i := range order {
+		o.v.ArraySetElm(j, elms[i])
+		moved[i] = j
+	}
+	o.node.reindex(func(i int) int {
+		if i >= len(moved) {
+			return -1
+		}
+		return moved[i]
+	})
+
+	o.observer.notify(targets, ChangeEvent{Op: OpAssign, Path: path, Old: old, New: o.v})
+}
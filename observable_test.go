@@ -0,0 +1,320 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestOp_String(t *testing.T) {
+	equal(t, jsonvalue.OpSet.String(), "set")
+	equal(t, jsonvalue.OpAdd.String(), "add")
+	equal(t, jsonvalue.OpDelete.String(), "delete")
+	equal(t, jsonvalue.OpAssign.String(), "assign")
+}
+
+func newObservableExample() jsonvalue.Observable {
+	return jsonvalue.Observe(jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Object(jsonvalue.Props{
+			"x": jsonvalue.Number(1),
+		}),
+		"b": jsonvalue.Array(jsonvalue.Number(1)),
+	}))
+}
+
+func collectEvents(o jsonvalue.Observable, prefix jsonvalue.Path) *[]jsonvalue.ChangeEvent {
+	events := &[]jsonvalue.ChangeEvent{}
+	o.Subscribe(prefix, func(e jsonvalue.ChangeEvent) {
+		*events = append(*events, e)
+	})
+	return events
+}
+
+func TestObservable_ObjectSetElm(t *testing.T) {
+	t.Run("replace", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		o.ObjectGetElm("a").ObjectSetElm("x", jsonvalue.Number(2))
+
+		equal(t, len(*events), 1)
+		e := (*events)[0]
+		equal(t, e.Op, jsonvalue.OpSet)
+		equal(t, e.Path.Equals(jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("x")}), true)
+		equal(t, e.Old.NumberGet().String(), "1")
+		equal(t, e.New.NumberGet().String(), "2")
+		equal(t, o.ObjectGetElm("a").ObjectGetElm("x").NumberGet().String(), "2")
+	})
+	t.Run("add", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		o.ObjectSetElm("c", jsonvalue.Null())
+
+		equal(t, len(*events), 1)
+		e := (*events)[0]
+		equal(t, e.Op, jsonvalue.OpSet)
+		equal(t, e.Old, nil)
+		equal(t, e.New.Type(), jsonvalue.TypeNull)
+	})
+}
+
+func TestObservable_ObjectDelElm(t *testing.T) {
+	t.Run("exists", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		o.ObjectDelElm("a")
+
+		equal(t, len(*events), 1)
+		e := (*events)[0]
+		equal(t, e.Op, jsonvalue.OpDelete)
+		equal(t, e.Path.Equals(jsonvalue.Path{jsonvalue.KeyName("a")}), true)
+		equal(t, e.Old.Type(), jsonvalue.TypeObject)
+		equal(t, e.New, nil)
+	})
+	t.Run("not exists", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		o.ObjectDelElm("z")
+
+		equal(t, len(*events), 0)
+	})
+}
+
+func TestObservable_ArraySetElm(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	o.ObjectGetElm("b").ArraySetElm(0, jsonvalue.Number(2))
+
+	equal(t, len(*events), 1)
+	e := (*events)[0]
+	equal(t, e.Op, jsonvalue.OpSet)
+	equal(t, e.Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)}), true)
+	equal(t, e.Old.NumberGet().String(), "1")
+	equal(t, e.New.NumberGet().String(), "2")
+}
+
+func TestObservable_ArrayAddElm(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	o.ObjectGetElm("b").ArrayAddElm(jsonvalue.Number(2), jsonvalue.Number(3))
+
+	equal(t, len(*events), 2)
+	equal(t, (*events)[0].Op, jsonvalue.OpAdd)
+	equal(t, (*events)[0].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(1)}), true)
+	equal(t, (*events)[1].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(2)}), true)
+	equal(t, o.ObjectGetElm("b").ArrayLen(), 3)
+}
+
+func TestObservable_Assign(t *testing.T) {
+	t.Run("assign", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		o.ObjectGetElm("a").Assign(jsonvalue.String("abc"))
+
+		equal(t, len(*events), 1)
+		e := (*events)[0]
+		equal(t, e.Op, jsonvalue.OpAssign)
+		equal(t, e.Path.Equals(jsonvalue.Path{jsonvalue.KeyName("a")}), true)
+		equal(t, e.Old.Type(), jsonvalue.TypeObject)
+		equal(t, e.New.StringGet(), "abc")
+		equal(t, o.ObjectGetElm("a").StringGet(), "abc")
+	})
+	t.Run("unmarshal", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{})
+		err := o.UnmarshalJSON([]byte(`[1,2]`))
+
+		equal(t, err, nil)
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Op, jsonvalue.OpAssign)
+		equal(t, o.ArrayLen(), 2)
+	})
+}
+
+func TestObservable_Subscribe(t *testing.T) {
+	t.Run("prefix", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{jsonvalue.KeyName("a")})
+		o.ObjectGetElm("a").ObjectSetElm("y", jsonvalue.Null())
+		o.ObjectGetElm("b").ArrayAddElm(jsonvalue.Null())
+
+		equal(t, len(*events), 1)
+	})
+	t.Run("ancestor", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o, jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("x")})
+		o.ObjectDelElm("a")
+
+		equal(t, len(*events), 1)
+	})
+	t.Run("relative", func(t *testing.T) {
+		o := newObservableExample()
+		events := collectEvents(o.ObjectGetElm("a").(jsonvalue.Observable), jsonvalue.Path{jsonvalue.KeyName("x")})
+		o.ObjectGetElm("a").ObjectSetElm("x", jsonvalue.Null())
+		o.ObjectGetElm("a").ObjectSetElm("y", jsonvalue.Null())
+
+		equal(t, len(*events), 1)
+	})
+	t.Run("unsubscribe", func(t *testing.T) {
+		o := newObservableExample()
+		count := 0
+		unsubscribe := o.Subscribe(jsonvalue.Path{}, func(e jsonvalue.ChangeEvent) {
+			count++
+		})
+		o.ObjectSetElm("c", jsonvalue.Null())
+		unsubscribe()
+		o.ObjectSetElm("d", jsonvalue.Null())
+
+		equal(t, count, 1)
+	})
+}
+
+func TestObservable_Clone(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	c := o.Clone()
+	c.ObjectSetElm("c", jsonvalue.Null())
+
+	equal(t, len(*events), 0)
+	equal(t, o.ObjectHasElm("c"), false)
+}
+
+func TestObservable_ArrayInsertElm(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	o.ObjectGetElm("b").ArrayInsertElm(0, jsonvalue.Number(2), jsonvalue.Number(3))
+
+	equal(t, len(*events), 2)
+	equal(t, (*events)[0].Op, jsonvalue.OpAdd)
+	equal(t, (*events)[0].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)}), true)
+	equal(t, (*events)[1].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(1)}), true)
+	equal(t, mustMarshal(t, o.ObjectGetElm("b")), `[2,3,1]`)
+}
+
+func TestObservable_ArrayDelElm(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	o.ObjectGetElm("b").ArrayDelElm(0)
+
+	equal(t, len(*events), 1)
+	e := (*events)[0]
+	equal(t, e.Op, jsonvalue.OpDelete)
+	equal(t, e.Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)}), true)
+	equal(t, e.Old.NumberGet().String(), "1")
+	equal(t, o.ObjectGetElm("b").ArrayLen(), 0)
+}
+
+func TestObservable_ArraySplice(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	removed := o.ObjectGetElm("b").ArraySplice(0, 1, jsonvalue.Number(2), jsonvalue.Number(3))
+
+	equal(t, len(*events), 3)
+	equal(t, (*events)[0].Op, jsonvalue.OpDelete)
+	equal(t, (*events)[1].Op, jsonvalue.OpAdd)
+	equal(t, (*events)[2].Op, jsonvalue.OpAdd)
+	equal(t, mustMarshal(t, removed), `[1]`)
+	equal(t, mustMarshal(t, o.ObjectGetElm("b")), `[2,3]`)
+}
+
+func TestObservable_ArraySort(t *testing.T) {
+	o := jsonvalue.Observe(mustUnmarshal(t, `[3,1,2]`))
+	events := collectEvents(o, jsonvalue.Path{})
+	o.ArraySort(jsonvalue.Compare)
+
+	equal(t, len(*events), 1)
+	e := (*events)[0]
+	equal(t, e.Op, jsonvalue.OpAssign)
+	equal(t, mustMarshal(t, e.Old), `[3,1,2]`)
+	equal(t, mustMarshal(t, o), `[1,2,3]`)
+}
+
+func TestObservable_ObjectAll(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	for k, v := range o.ObjectAll() {
+		if k == "a" {
+			v.ObjectSetElm("y", jsonvalue.Null())
+		}
+	}
+
+	equal(t, len(*events), 1)
+	equal(t, (*events)[0].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("y")}), true)
+}
+
+func TestObservable_ArrayAll(t *testing.T) {
+	o := newObservableExample()
+	events := collectEvents(o, jsonvalue.Path{})
+	for i, v := range o.ObjectGetElm("b").ArrayAll() {
+		v.Assign(jsonvalue.Number(i + 10))
+	}
+
+	equal(t, len(*events), 1)
+	equal(t, (*events)[0].Path.Equals(jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)}), true)
+	equal(t, mustMarshal(t, o.ObjectGetElm("b")), `[10]`)
+}
+
+func TestObservable_ChildAcrossShift(t *testing.T) {
+	t.Run("delete", func(t *testing.T) {
+		o := jsonvalue.Observe(mustUnmarshal(t, `{"b":[{"x":1},{"x":2},{"x":3}]}`))
+		b := o.ObjectGetElm("b")
+		c := b.ArrayGetElm(1)
+		b.ArrayDelElm(0)
+		events := collectEvents(o, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyIndex(0)})
+		c.ObjectSetElm("x", jsonvalue.Number(99))
+
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Path.Pointer(), "/b/0/x")
+		equal(t, mustMarshal(t, o), `{"b":[{"x":99},{"x":3}]}`)
+	})
+	t.Run("insert", func(t *testing.T) {
+		o := jsonvalue.Observe(mustUnmarshal(t, `{"b":[{"x":1},{"x":2}]}`))
+		b := o.ObjectGetElm("b")
+		c := b.ArrayGetElm(1)
+		b.ArrayPrepend(jsonvalue.Null(), jsonvalue.Null())
+		events := collectEvents(o, jsonvalue.Path{})
+		c.ObjectSetElm("x", jsonvalue.Number(99))
+
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Path.Pointer(), "/b/3/x")
+	})
+	t.Run("detached", func(t *testing.T) {
+		o := jsonvalue.Observe(mustUnmarshal(t, `{"b":[{"x":1},{"x":2}]}`))
+		b := o.ObjectGetElm("b")
+		c := b.ArrayGetElm(1)
+		d := c.ObjectGetElm("x")
+		b.ArrayDelElm(1)
+		events := collectEvents(o, jsonvalue.Path{})
+		c.ObjectSetElm("x", jsonvalue.Number(99))
+		d.Assign(jsonvalue.Number(100))
+		o.ObjectSetElm("b", jsonvalue.Array())
+		b.ArrayAddElm(jsonvalue.Null())
+
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Path.Pointer(), "/b")
+		equal(t, mustMarshal(t, o), `{"b":[]}`)
+	})
+	t.Run("same value", func(t *testing.T) {
+		o := jsonvalue.Observe(jsonvalue.Array())
+		a := jsonvalue.Array()
+		o.ArrayAddElm(a, a)
+		c := o.ArrayGetElm(1)
+		events := collectEvents(o, jsonvalue.Path{})
+		c.ArrayAddElm(jsonvalue.Null())
+
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Path.Pointer(), "/1/0")
+	})
+	t.Run("sort", func(t *testing.T) {
+		o := jsonvalue.Observe(mustUnmarshal(t, `[{"x":3},{"x":1},{"x":2}]`))
+		c := o.ArrayGetElm(0)
+		o.ArraySort(func(a, b jsonvalue.Value) int {
+			return jsonvalue.Compare(a.ObjectGetElm("x"), b.ObjectGetElm("x"))
+		})
+		events := collectEvents(o, jsonvalue.Path{})
+		c.ObjectSetElm("x", jsonvalue.Number(99))
+
+		equal(t, len(*events), 1)
+		equal(t, (*events)[0].Path.Pointer(), "/2/x")
+		equal(t, mustMarshal(t, o), `[{"x":1},{"x":2},{"x":99}]`)
+	})
+}
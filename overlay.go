@@ -0,0 +1,260 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OverlayOptions configures Overlay.
+type OverlayOptions struct {
+	// EnvPrefix selects the environment variables such as "APP_", which is removed from their names.
+	// If EnvPrefix is empty, no environment variables are overlaid.
+	EnvPrefix string
+	// EnvSeparator separates the keys in the names of environment variables. If EnvSeparator is empty, "__" is used.
+	EnvSeparator string
+	// Schema is an optional JSON Schema determining the types of the overlaid JSON values which do not exist in the base JSON value.
+	Schema Value
+}
+
+func (o OverlayOptions) envSeparator() string {
+	if o.EnvSeparator == "" {
+		return "__"
+	}
+
+	return o.EnvSeparator
+}
+
+// Overlay returns a new JSON value obtained by overlaying environment variables and then assignments onto a JSON value base.
+// environ is a list of environment variables in the form "KEY=value" such as the result of os.Environ,
+// and the name of each variable is split by the separator into the keys of a Path, e.g. APP_DB__HOST is overlaid at /db/host.
+// Keys are matched case-insensitively with the existing member names and the properties in the schema, and lower-cased otherwise.
+// assignments is a list in the form "path=value" such as the arguments of --set flags, where the path is in PathNotationBracketed, e.g. a.b[0]=v.
+// Keys consisting of digits in the names of environment variables and the bracketed indices in assignments are array indices.
+// Each value is coerced into the type of the existing JSON value at the Path, or else the type in the schema, and is a JSON string otherwise.
+// Values for JSON numbers, null, objects and arrays are written as JSON texts, and values for JSON booleans are parsed by strconv.ParseBool.
+// The missing JSON objects and arrays along the Paths are created.
+// environ is ignored if opts.EnvPrefix is empty.
+func Overlay(base Value, environ []string, assignments []string, opts OverlayOptions) (Value, error) {
+	o := overlay{root: base.Clone(), schema: opts.Schema}
+	if opts.Schema != nil {
+		o.validator = &schemaValidator{root: opts.Schema}
+	}
+
+	sep := opts.envSeparator()
+	if opts.EnvPrefix == "" {
+		environ = nil
+	}
+	for _, env := range environ {
+		name, val, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(name, opts.EnvPrefix) {
+			continue
+		}
+		path := Path{}
+		for _, segment := range strings.Split(strings.TrimPrefix(name, opts.EnvPrefix), sep) {
+			if isDigits(segment) {
+				i, err := strconv.Atoi(segment)
+				if err != nil {
+					return nil, fmt.Errorf(`fail to overlay environment variable %s: %w`, name, err)
+				}
+				path = path.Append(KeyIndex(i))
+				continue
+			}
+			key, err := o.matchName(path, segment)
+			if err != nil {
+				return nil, fmt.Errorf(`fail to overlay environment variable %s: %w`, name, err)
+			}
+			path = path.Append(KeyName(key))
+		}
+		if err := o.set(path, val); err != nil {
+			return nil, fmt.Errorf(`fail to overlay environment variable %s: %w`, name, err)
+		}
+	}
+
+	for _, assignment := range assignments {
+		s, val, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return nil, fmt.Errorf(`fail to overlay assignment %q: "=" is missing`, assignment)
+		}
+		path, err := PathNotationBracketed.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf(`fail to overlay assignment %q: %w`, assignment, err)
+		}
+		if err := o.set(path, val); err != nil {
+			return nil, fmt.Errorf(`fail to overlay assignment %q: %w`, assignment, err)
+		}
+	}
+
+	return o.root, nil
+}
+
+type overlay struct {
+	root      Value
+	schema    Value
+	validator *schemaValidator
+}
+
+func (o *overlay) set(path Path, s string) error {
+	val, err := o.coerce(path, s)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		o.root = val
+		return nil
+	}
+
+	return insertAt(o.root, path, val, true)
+}
+
+func (o *overlay) matchName(path Path, segment string) (string, error) {
+	if v, ok := Find(o.root, path); ok && v.Type() == TypeObject {
+		for key := range v.ObjectAll() {
+			if strings.EqualFold(key, segment) {
+				return key, nil
+			}
+		}
+	}
+	schema, ok, err := o.schemaAt(path)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		if properties, ok := schemaKeyword(schema, "properties"); ok && properties.Type() == TypeObject {
+			for key := range properties.ObjectAll() {
+				if strings.EqualFold(key, segment) {
+					return key, nil
+				}
+			}
+		}
+	}
+
+	return strings.ToLower(segment), nil
+}
+
+func (o *overlay) coerce(path Path, s string) (Value, error) {
+	if existing, ok := Find(o.root, path); ok && existing.Type() != TypeNull {
+		return parseOverlayValue(s, existing.Type())
+	}
+
+	schema, ok, err := o.schemaAt(path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return String(s), nil
+	}
+	typ, ok := schemaKeyword(schema, "type")
+	if !ok {
+		return String(s), nil
+	}
+	types := []string{}
+	switch typ.Type() {
+	case TypeString:
+		types = append(types, typ.StringGet())
+	case TypeArray:
+		for _, t := range typ.ArrayAll() {
+			if t.Type() != TypeString {
+				return nil, fmt.Errorf(`type in schema must be a string or an array of strings`)
+			}
+			types = append(types, t.StringGet())
+		}
+	default:
+		return nil, fmt.Errorf(`type in schema must be a string or an array of strings`)
+	}
+	for _, t := range types {
+		var want Type
+		switch t {
+		case "null":
+			want = TypeNull
+		case "boolean":
+			want = TypeBoolean
+		case "number", "integer":
+			want = TypeNumber
+		case "string":
+			want = TypeString
+		case "array":
+			want = TypeArray
+		case "object":
+			want = TypeObject
+		default:
+			return nil, fmt.Errorf(`unknown type %q in schema`, t)
+		}
+		v, err := parseOverlayValue(s, want)
+		if err != nil {
+			continue
+		}
+		if ok, _ := schemaTypeMatches(v, t); ok {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf(`value %q does not match type %s in schema`, s, typ)
+}
+
+// schemaAt returns the subschema applied to the JSON value at the Path, following properties, additionalProperties, prefixItems, items and $ref.
+func (o *overlay) schemaAt(path Path) (Value, bool, error) {
+	if o.schema == nil {
+		return nil, false, nil
+	}
+
+	schema := o.schema
+	for i := 0; ; i++ {
+		for depth := 0; schema.Type() == TypeObject && schema.ObjectHasElm("$ref"); depth++ {
+			if depth >= maxSchemaRefDepth {
+				return nil, false, fmt.Errorf(`$ref in schema is nested too deeply`)
+			}
+			target, err := o.validator.resolve(schema.ObjectGetElm("$ref"))
+			if err != nil {
+				return nil, false, err
+			}
+			schema = target
+		}
+		if schema.Type() != TypeObject {
+			return nil, false, nil
+		}
+		if i == len(path) {
+			return schema, true, nil
+		}
+
+		key := path[i]
+		var next Value
+		if key.IsIndex() {
+			if prefixItems, ok := schemaKeyword(schema, "prefixItems"); ok && prefixItems.Type() == TypeArray && key.Int() < prefixItems.ArrayLen() {
+				next = prefixItems.ArrayGetElm(key.Int())
+			} else if items, ok := schemaKeyword(schema, "items"); ok {
+				next = items
+			}
+		} else {
+			if properties, ok := schemaKeyword(schema, "properties"); ok && properties.Type() == TypeObject && properties.ObjectHasElm(key.String()) {
+				next = properties.ObjectGetElm(key.String())
+			} else if additional, ok := schemaKeyword(schema, "additionalProperties"); ok {
+				next = additional
+			}
+		}
+		if next == nil {
+			return nil, false, nil
+		}
+		schema = next
+	}
+}
+
+func parseOverlayValue(s string, typ Type) (Value, error) {
+	switch typ {
+	case TypeString:
+		return String(s), nil
+	case TypeBoolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf(`value %q is not a boolean`, s)
+		}
+		return Boolean(b), nil
+	default:
+		v := Null()
+		if err := json.Unmarshal([]byte(s), v); err != nil || v.Type() != typ {
+			return nil, fmt.Errorf(`value %q is not a JSON %v`, s, typ)
+		}
+		return v, nil
+	}
+}
@@ -0,0 +1,105 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestOverlay(t *testing.T) {
+	base := `{"db":{"host":"localhost","port":5432,"tls":false,"maxConns":10},"tags":["a","b"],"extra":null}`
+	schema := `{
+		"type": "object",
+		"properties": {
+			"db": {"$ref": "#/$defs/db"},
+			"retries": {"type": "integer"},
+			"ratio": {"type": ["null", "number"]},
+			"servers": {"type": "array", "items": {"type": "object", "properties": {"weight": {"type": "number"}}}},
+			"labels": {"type": "object", "additionalProperties": {"type": "boolean"}}
+		},
+		"$defs": {"db": {"type": "object", "properties": {"timeout": {"type": "number"}}}}
+	}`
+	type testCase struct {
+		name        string
+		environ     []string
+		assignments []string
+		schema      string
+		want        string
+	}
+	testCases := []testCase{
+		{
+			name:    "environment variables",
+			environ: []string{"APP_DB__HOST=db.example.com", "APP_DB__PORT=6543", "APP_DB__TLS=1", "APP_DB__MAXCONNS=20", "HOME=/root", "APP_TAGS__1=c"},
+			want:    `{"db":{"host":"db.example.com","port":6543,"tls":true,"maxConns":20},"tags":["a","c"],"extra":null}`,
+		},
+		{
+			name:    "new keys are lower-cased strings",
+			environ: []string{"APP_LOG__LEVEL=debug", "APP_LIST__1=x", "APP_EXTRA=1"},
+			want:    `{"db":{"host":"localhost","port":5432,"tls":false,"maxConns":10},"tags":["a","b"],"extra":"1","log":{"level":"debug"},"list":[null,"x"]}`,
+		},
+		{
+			name:        "assignments override environment variables",
+			environ:     []string{"APP_DB__PORT=6543"},
+			assignments: []string{"db.port=7654", "tags[2]=c", `db["read only"]=yes`, "db.tls=true", "tags=[\"x\"]"},
+			want:        `{"db":{"host":"localhost","port":7654,"tls":true,"maxConns":10,"read only":"yes"},"tags":["x"],"extra":null}`,
+		},
+		{
+			name:        "schema",
+			environ:     []string{"APP_RETRIES=3", "APP_DB__TIMEOUT=1.5", "APP_LABELS__BETA=false"},
+			assignments: []string{"ratio=null", "servers[0].weight=0.5", "servers[0].name=s"},
+			schema:      schema,
+			want:        `{"db":{"host":"localhost","port":5432,"tls":false,"maxConns":10,"timeout":1.5},"tags":["a","b"],"extra":null,"retries":3,"ratio":null,"labels":{"beta":false},"servers":[{"weight":0.5,"name":"s"}]}`,
+		},
+		{
+			name:        "root",
+			assignments: []string{`={"a":1}`},
+			want:        `{"a":1}`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			opts := jsonvalue.OverlayOptions{EnvPrefix: "APP_"}
+			if testCase.schema != "" {
+				opts.Schema = mustUnmarshal(t, testCase.schema)
+			}
+			v := mustUnmarshal(t, base)
+			got, err := jsonvalue.Overlay(v, testCase.environ, testCase.assignments, opts)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+			equal(t, jsonvalue.Equal(v, mustUnmarshal(t, base)), true)
+		})
+	}
+	t.Run("empty prefix", func(t *testing.T) {
+		got, err := jsonvalue.Overlay(mustUnmarshal(t, base), []string{"HOME=/root", "DB__PORT=1", "TAGS__99999999999=x"}, []string{"db.port=7654"}, jsonvalue.OverlayOptions{})
+		equal(t, err, nil)
+		equal(t, jsonvalue.Equal(got, mustUnmarshal(t, `{"db":{"host":"localhost","port":7654,"tls":false,"maxConns":10},"tags":["a","b"],"extra":null}`)), true)
+	})
+}
+
+func TestOverlay_Error(t *testing.T) {
+	base := `{"db":{"port":5432,"tls":false},"tags":["a"]}`
+	schema := `{"properties":{"retries":{"type":"integer"}}}`
+	type testCase struct {
+		name        string
+		environ     []string
+		assignments []string
+	}
+	testCases := []testCase{
+		{name: "number", environ: []string{"APP_DB__PORT=x"}},
+		{name: "boolean", assignments: []string{"db.tls=yes"}},
+		{name: "schema type", environ: []string{"APP_RETRIES=1.5"}},
+		{name: "not a container", assignments: []string{"db.port.x=1"}},
+		{name: "object and array", assignments: []string{"tags.x=1"}},
+		{name: "missing equal", assignments: []string{"db.port"}},
+		{name: "invalid path", assignments: []string{"db..port=1"}},
+		{name: "too large index", assignments: []string{"tags[99999999999]=1"}},
+		{name: "too large index in environment", environ: []string{"APP_TAGS__99999999999=1"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			opts := jsonvalue.OverlayOptions{EnvPrefix: "APP_", Schema: mustUnmarshal(t, schema)}
+			_, err := jsonvalue.Overlay(mustUnmarshal(t, base), testCase.environ, testCase.assignments, opts)
+			IsNotNil(t, err)
+		})
+	}
+}
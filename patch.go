@@ -0,0 +1,239 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// PatchOp represents a kind of operation of JSON Patch (RFC 6902).
+type PatchOp string
+
+const (
+	// PatchOpAdd adds a JSON value to an object or inserts it into an array.
+	PatchOpAdd PatchOp = "add"
+	// PatchOpRemove removes the JSON value at the target location.
+	PatchOpRemove PatchOp = "remove"
+	// PatchOpReplace replaces the JSON value at the target location.
+	PatchOpReplace PatchOp = "replace"
+	// PatchOpMove removes the JSON value at the from location and adds it to the target location.
+	PatchOpMove PatchOp = "move"
+	// PatchOpCopy copies the JSON value at the from location to the target location.
+	PatchOpCopy PatchOp = "copy"
+	// PatchOpTest tests that the JSON value at the target location is equal to the specified JSON value.
+	PatchOpTest PatchOp = "test"
+)
+
+// PatchOperation represents an operation of JSON Patch (RFC 6902).
+type PatchOperation struct {
+	// Op is the kind of the operation.
+	Op PatchOp
+	// Path is a JSON Pointer to the target location.
+	Path string
+	// From is a JSON Pointer to the source location for PatchOpMove and PatchOpCopy.
+	From string
+	// Value is the JSON value for PatchOpAdd, PatchOpReplace and PatchOpTest.
+	Value Value
+}
+
+// Patch represents a JSON Patch (RFC 6902) document.
+type Patch []PatchOperation
+
+type patchOperationJSON struct {
+	Op    PatchOp         `json:"op"`
+	Path  string          `json:"path"`
+	From  *string         `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	j := patchOperationJSON{Op: o.Op, Path: o.Path}
+	switch o.Op {
+	case PatchOpMove, PatchOpCopy:
+		from := o.From
+		j.From = &from
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		assert.Params(o.Value != nil, "Value must not be nil for %v", o.Op)
+		b, err := o.Value.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf(`fail to marshal PatchOperation: %w`, err)
+		}
+		j.Value = b
+	}
+
+	return json.Marshal(j)
+}
+
+func (o *PatchOperation) UnmarshalJSON(b []byte) error {
+	decoder := json.NewDecoder(bytes.NewBuffer(b))
+	decoder.UseNumber()
+
+	var j patchOperationJSON
+	if err := decoder.Decode(&j); err != nil {
+		return fmt.Errorf(`fail to unmarshal PatchOperation: %w`, err)
+	}
+
+	op := PatchOperation{Op: j.Op, Path: j.Path}
+	switch j.Op {
+	case PatchOpMove, PatchOpCopy:
+		if j.From == nil {
+			return fmt.Errorf(`fail to unmarshal PatchOperation: "from" is required for %q`, j.Op)
+		}
+		op.From = *j.From
+	case PatchOpAdd, PatchOpReplace, PatchOpTest:
+		if j.Value == nil {
+			return fmt.Errorf(`fail to unmarshal PatchOperation: "value" is required for %q`, j.Op)
+		}
+		op.Value = Null()
+		if err := op.Value.UnmarshalJSON(j.Value); err != nil {
+			return fmt.Errorf(`fail to unmarshal PatchOperation: %w`, err)
+		}
+	case PatchOpRemove:
+	default:
+		return fmt.Errorf(`fail to unmarshal PatchOperation: unknown op %q`, j.Op)
+	}
+	*o = op
+
+	return nil
+}
+
+// Apply applies the operations of the Patch to a JSON value v in order.
+// If an operation fails, Apply returns an error immediately and the operations before it remain applied.
+func (p Patch) Apply(v Value) error {
+	for i, op := range p {
+		if err := op.Apply(v); err != nil {
+			return fmt.Errorf(`fail to apply patch operation %d: %w`, i, err)
+		}
+	}
+
+	return nil
+}
+
+// Apply applies the operation to a JSON value v.
+func (o PatchOperation) Apply(v Value) error {
+	path, err := ParsePointer(v, o.Path)
+	if err != nil {
+		return err
+	}
+
+	switch o.Op {
+	case PatchOpAdd:
+		assert.Params(o.Value != nil, "Value must not be nil for %v", o.Op)
+		return patchAdd(v, path, o.Value.Clone())
+	case PatchOpRemove:
+		_, err := patchRemove(v, path)
+		return err
+	case PatchOpReplace:
+		assert.Params(o.Value != nil, "Value must not be nil for %v", o.Op)
+		return patchReplace(v, path, o.Value.Clone())
+	case PatchOpMove:
+		from, err := ParsePointer(v, o.From)
+		if err != nil {
+			return err
+		}
+		if path.HasPrefix(from) && !path.Equals(from) {
+			return fmt.Errorf(`%q must not be moved into its child %q`, o.From, o.Path)
+		}
+		moved, err := patchRemove(v, from)
+		if err != nil {
+			return err
+		}
+		path, err = ParsePointer(v, o.Path)
+		if err != nil {
+			return err
+		}
+		return patchAdd(v, path, moved)
+	case PatchOpCopy:
+		from, err := ParsePointer(v, o.From)
+		if err != nil {
+			return err
+		}
+		copied, ok := Find(v, from)
+		if !ok {
+			return fmt.Errorf(`%q not found`, o.From)
+		}
+		return patchAdd(v, path, copied.Clone())
+	case PatchOpTest:
+		assert.Params(o.Value != nil, "Value must not be nil for %v", o.Op)
+		actual, ok := Find(v, path)
+		if !ok {
+			return fmt.Errorf(`%q not found`, o.Path)
+		}
+		if !Equal(actual, o.Value) {
+			return fmt.Errorf(`test failed at %q`, o.Path)
+		}
+		return nil
+	default:
+		return fmt.Errorf(`unknown op %q`, o.Op)
+	}
+}
+
+func patchAdd(root Value, path Path, v Value) error {
+	if path.Len() == 0 {
+		root.Assign(v)
+		return nil
+	}
+
+	parent, ok := Find(root, path.Parent())
+	if !ok {
+		return fmt.Errorf(`%q not found`, path.Parent().Pointer())
+	}
+
+	key := path.Last()
+	switch {
+	case key.IsIndex() && parent.Type() == TypeArray:
+		if key.Int() > parent.ArrayLen() {
+			return fmt.Errorf(`index %d out of range at %q`, key.Int(), path.Parent().Pointer())
+		}
+		parent.ArrayInsertElm(key.Int(), v)
+	case !key.IsIndex() && parent.Type() == TypeObject:
+		parent.ObjectSetElm(key.String(), v)
+	default:
+		return fmt.Errorf(`%q is not a container`, path.Parent().Pointer())
+	}
+
+	return nil
+}
+
+func patchReplace(root Value, path Path, v Value) error {
+	if _, ok := Find(root, path); !ok {
+		return fmt.Errorf(`%q not found`, path.Pointer())
+	}
+	if path.Len() == 0 {
+		root.Assign(v)
+		return nil
+	}
+
+	parent, _ := Find(root, path.Parent())
+	key := path.Last()
+	if key.IsIndex() {
+		parent.ArraySetElm(key.Int(), v)
+	} else {
+		parent.ObjectSetElm(key.String(), v)
+	}
+
+	return nil
+}
+
+func patchRemove(root Value, path Path) (Value, error) {
+	if path.Len() == 0 {
+		return nil, fmt.Errorf(`root must not be removed`)
+	}
+
+	removed, ok := Find(root, path)
+	if !ok {
+		return nil, fmt.Errorf(`%q not found`, path.Pointer())
+	}
+
+	parent, _ := Find(root, path.Parent())
+	key := path.Last()
+	if key.IsIndex() {
+		parent.ArrayDelElm(key.Int())
+	} else {
+		parent.ObjectDelElm(key.String())
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,107 @@
+package jsonvalue_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func mustUnmarshal(t *testing.T, s string) jsonvalue.Value {
+	t.Helper()
+
+	v := jsonvalue.Null()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("fail to unmarshal %s: %v", s, err)
+	}
+	return v
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("fail to marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestPatch_Apply(t *testing.T) {
+	type testCase struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   bool
+	}
+	testCases := []testCase{
+		{name: "add member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2}]`, want: `{"a":1,"b":2}`},
+		{name: "add element", doc: `[1,3]`, patch: `[{"op":"add","path":"/1","value":2}]`, want: `[1,2,3]`},
+		{name: "add element to back", doc: `[1,2]`, patch: `[{"op":"add","path":"/-","value":3}]`, want: `[1,2,3]`},
+		{name: "add root", doc: `{"a":1}`, patch: `[{"op":"add","path":"","value":[]}]`, want: `[]`},
+		{name: "add out of range", doc: `[1]`, patch: `[{"op":"add","path":"/2","value":3}]`, err: true},
+		{name: "add missing parent", doc: `{}`, patch: `[{"op":"add","path":"/a/b","value":3}]`, err: true},
+		{name: "remove member", doc: `{"a":1,"b":2}`, patch: `[{"op":"remove","path":"/a"}]`, want: `{"b":2}`},
+		{name: "remove element", doc: `[1,2,3]`, patch: `[{"op":"remove","path":"/1"}]`, want: `[1,3]`},
+		{name: "remove missing", doc: `{}`, patch: `[{"op":"remove","path":"/a"}]`, err: true},
+		{name: "replace", doc: `{"a":[1,2]}`, patch: `[{"op":"replace","path":"/a/0","value":{"x":null}}]`, want: `{"a":[{"x":null},2]}`},
+		{name: "replace missing", doc: `{}`, patch: `[{"op":"replace","path":"/a","value":1}]`, err: true},
+		{name: "move", doc: `{"a":{"x":1},"b":{}}`, patch: `[{"op":"move","from":"/a/x","path":"/b/y"}]`, want: `{"a":{},"b":{"y":1}}`},
+		{name: "move into child", doc: `{"a":{"x":1}}`, patch: `[{"op":"move","from":"/a","path":"/a/x"}]`, err: true},
+		{name: "copy", doc: `{"a":[1]}`, patch: `[{"op":"copy","from":"/a","path":"/b"}]`, want: `{"a":[1],"b":[1]}`},
+		{name: "test", doc: `{"a":[1.0]}`, patch: `[{"op":"test","path":"/a","value":[1]}]`, want: `{"a":[1.0]}`},
+		{name: "test failed", doc: `{"a":[1]}`, patch: `[{"op":"test","path":"/a","value":[2]}]`, err: true},
+		{name: "escaped", doc: `{"a/b":{"~":1}}`, patch: `[{"op":"remove","path":"/a~1b/~0"}]`, want: `{"a/b":{}}`},
+		{name: "numeric member", doc: `{"0":1}`, patch: `[{"op":"replace","path":"/0","value":2}]`, want: `{"0":2}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			v := mustUnmarshal(t, testCase.doc)
+			var patch jsonvalue.Patch
+			err := json.Unmarshal([]byte(testCase.patch), &patch)
+			equal(t, err, nil)
+
+			err = patch.Apply(v)
+			if testCase.err {
+				IsNotNil(t, err)
+				return
+			}
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(v, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestPatch_MarshalJSON(t *testing.T) {
+	patch := jsonvalue.Patch{
+		{Op: jsonvalue.PatchOpAdd, Path: "/a", Value: jsonvalue.Number(1)},
+		{Op: jsonvalue.PatchOpRemove, Path: "/b"},
+		{Op: jsonvalue.PatchOpMove, From: "", Path: "/c"},
+	}
+	equal(t, mustMarshal(t, patch), `[{"op":"add","path":"/a","value":1},{"op":"remove","path":"/b"},{"op":"move","path":"/c","from":""}]`)
+}
+
+func TestPatch_UnmarshalJSON(t *testing.T) {
+	t.Run("unknown op", func(t *testing.T) {
+		var patch jsonvalue.Patch
+		err := json.Unmarshal([]byte(`[{"op":"xxx","path":""}]`), &patch)
+		IsNotNil(t, err)
+	})
+	t.Run("missing value", func(t *testing.T) {
+		var patch jsonvalue.Patch
+		err := json.Unmarshal([]byte(`[{"op":"add","path":""}]`), &patch)
+		IsNotNil(t, err)
+	})
+	t.Run("missing from", func(t *testing.T) {
+		var patch jsonvalue.Patch
+		err := json.Unmarshal([]byte(`[{"op":"copy","path":""}]`), &patch)
+		IsNotNil(t, err)
+	})
+	t.Run("null value", func(t *testing.T) {
+		var patch jsonvalue.Patch
+		err := json.Unmarshal([]byte(`[{"op":"add","path":"/a","value":null}]`), &patch)
+		equal(t, err, nil)
+		equal(t, patch[0].Value.Type(), jsonvalue.TypeNull)
+	})
+}
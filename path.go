@@ -1,31 +1,64 @@
 package jsonvalue
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
 	"strconv"
+	"strings"
 
 	"github.com/Jumpaku/go-assert"
 	"golang.org/x/exp/slices"
 )
 
 // Key represents a key for a member in a JSON object or an index for an element in a JSON array.
-type Key string
+// The zero value of Key represents the member name "" in a JSON object.
+type Key struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// KeyName creates Key from a member name in a JSON object.
+func KeyName(name string) Key {
+	return Key{name: name}
+}
+
+// KeyIndex creates Key from an index for an element in a JSON array.
+func KeyIndex(index int) Key {
+	assert.Params(index >= 0, "index %v must be non-negative", index)
+
+	return Key{index: index, isIndex: true}
+}
 
 // KeyInt creates Key from JOSN array index.
+//
+// Deprecated: Use KeyIndex instead.
 func KeyInt(index int) Key {
-	return Key(strconv.FormatInt(int64(index), 10))
+	return KeyIndex(index)
+}
+
+// IsIndex returns true if the Key represents an index for an element in a JSON array; otherwise false.
+func (k Key) IsIndex() bool {
+	return k.isIndex
 }
 
-// String returns key for JSON object in string.
+// String returns key for JSON object in string, or index for JSON array in decimal string.
 func (k Key) String() string {
-	return string(k)
+	if k.isIndex {
+		return strconv.FormatInt(int64(k.index), 10)
+	}
+
+	return k.name
 }
 
-// String returns index for JSON array in int.
+// Int returns index for JSON array in int.
 func (k Key) Int() int {
-	elm, err := strconv.ParseInt(k.String(), 10, 64)
-	assert.State(err == nil, "%v cannot be parsed to int: %w", k, err)
+	assert.Params(k.isIndex, "%q must be an index", k.name)
 
-	return int(elm)
+	return k.index
 }
 
 // Path represents a sequence of the Keys.
@@ -63,6 +96,225 @@ func (p Path) Get(index int) Key {
 	return p[index]
 }
 
+// Compare returns an integer comparing two Keys.
+// The result will be 0 if k == other, -1 if k < other, and +1 if k > other.
+// A Key representing an index is less than a Key representing a member name, indices are compared as integers, and member names are compared lexicographically.
+func (k Key) Compare(other Key) int {
+	switch {
+	case k.isIndex && !other.isIndex:
+		return -1
+	case !k.isIndex && other.isIndex:
+		return 1
+	case k.isIndex:
+		return compareOrdered(k.index, other.index)
+	default:
+		return compareOrdered(k.name, other.name)
+	}
+}
+
+func compareOrdered[T int | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Parent returns a new Path without the last key.
+func (p Path) Parent() Path {
+	assert.Params(p.Len() > 0, "Path must not be empty")
+
+	return p.Slice(0, p.Len()-1)
+}
+
+// Last returns the last key.
+func (p Path) Last() Key {
+	assert.Params(p.Len() > 0, "Path must not be empty")
+
+	return p[p.Len()-1]
+}
+
+// HasPrefix returns true if the Path begins with the keys of prefix; otherwise false.
+func (p Path) HasPrefix(prefix Path) bool {
+	return prefix.Len() <= p.Len() && prefix.Equals(p[:prefix.Len()])
+}
+
+// Join creates a new Path concatenating the original Path and others in order.
+func (p Path) Join(others ...Path) Path {
+	joined := append([]Key{}, p...)
+	for _, other := range others {
+		joined = append(joined, other...)
+	}
+
+	return Path(joined)
+}
+
+// RelativeTo returns a new Path relative to base, that is, base.Join(rel) equals the original Path.
+// If the Path does not begin with base, nil and false are returned.
+func (p Path) RelativeTo(base Path) (Path, bool) {
+	if !p.HasPrefix(base) {
+		return nil, false
+	}
+
+	return p.Slice(base.Len(), p.Len()), true
+}
+
+// Compare returns an integer comparing two Paths key by key using Key.Compare.
+// The result will be 0 if p == other, -1 if p < other, and +1 if p > other.
+// If one Path is a prefix of the other, the shorter one is less.
+func (p Path) Compare(other Path) int {
+	for i := 0; i < p.Len() && i < other.Len(); i++ {
+		if c := p[i].Compare(other[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareOrdered(p.Len(), other.Len())
+}
+
+// MarshalText encodes the Path into a JSON array of keys, in which a member name is a JSON string and an index is a JSON number, e.g. ["items",0,"id"].
+func (p Path) MarshalText() ([]byte, error) {
+	keys := make([]any, p.Len())
+	for i, key := range p {
+		if key.IsIndex() {
+			keys[i] = key.Int()
+		} else {
+			keys[i] = key.String()
+		}
+	}
+
+	return json.Marshal(keys)
+}
+
+// UnmarshalText decodes a JSON array of keys encoded by MarshalText into the Path.
+// An error is returned if the text contains data after the JSON array.
+func (p *Path) UnmarshalText(text []byte) error {
+	decoder := json.NewDecoder(bytes.NewBuffer(text))
+	decoder.UseNumber()
+
+	var keys []any
+	if err := decoder.Decode(&keys); err != nil {
+		return fmt.Errorf(`fail to unmarshal text to Path: %w`, err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf(`fail to unmarshal text to Path: unexpected data after JSON array`)
+	}
+
+	path := Path{}
+	for _, key := range keys {
+		switch key := key.(type) {
+		case string:
+			path = append(path, KeyName(key))
+		case json.Number:
+			index, err := strconv.ParseInt(key.String(), 10, 64)
+			if err != nil || index < 0 {
+				return fmt.Errorf(`fail to unmarshal text to Path: invalid index %v`, key)
+			}
+			path = append(path, KeyIndex(int(index)))
+		default:
+			return fmt.Errorf(`fail to unmarshal text to Path: invalid key %v`, key)
+		}
+	}
+	*p = path
+
+	return nil
+}
+
+// String returns the text encoded by MarshalText.
+func (p Path) String() string {
+	b, _ := p.MarshalText()
+
+	return string(b)
+}
+
+// PathKey is a comparable form of a Path, which is the text encoded by MarshalText.
+// Since a Path is a slice, PathKey is used instead as a key of Go maps, which is also written as a member name in JSON.
+// Two Paths are equal if and only if their PathKeys are equal, while the order of PathKeys is different from Path.Compare.
+type PathKey string
+
+// Key returns the PathKey of the Path.
+func (p Path) Key() PathKey {
+	return PathKey(p.String())
+}
+
+// Path returns the Path decoded from the PathKey.
+func (k PathKey) Path() (Path, error) {
+	var p Path
+	if err := p.UnmarshalText([]byte(k)); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Pointer returns a JSON Pointer (RFC 6901) representing the Path, e.g. /items/0/id.
+func (p Path) Pointer() string {
+	var b strings.Builder
+	for _, key := range p {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(key.String()))
+	}
+
+	return b.String()
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// ParsePointer parses a JSON Pointer (RFC 6901) into a Path referring into a JSON value v.
+// A reference token is parsed as an index if it refers to an element of a JSON array in v and as a member name otherwise.
+// The reference token "-" for a JSON array is parsed as the index next to the last element.
+// The referred JSON value does not need to exist.
+func ParsePointer(v Value, pointer string) (Path, error) {
+	if pointer == "" {
+		return Path{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf(`fail to parse JSON Pointer %q: pointer must begin with '/'`, pointer)
+	}
+
+	path := Path{}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(token, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf(`fail to parse JSON Pointer %q: invalid escape sequence in %q`, pointer, token)
+		}
+		token = pointerUnescaper.Replace(token)
+
+		if v == nil || v.Type() != TypeArray {
+			path = append(path, KeyName(token))
+			if v != nil && v.Type() == TypeObject && v.ObjectHasElm(token) {
+				v = v.ObjectGetElm(token)
+			} else {
+				v = nil
+			}
+			continue
+		}
+
+		if token == "-" {
+			path = append(path, KeyIndex(v.ArrayLen()))
+			v = nil
+			continue
+		}
+		index, err := strconv.ParseUint(token, 10, 31)
+		if err != nil || (len(token) > 1 && token[0] == '0') {
+			return nil, fmt.Errorf(`fail to parse JSON Pointer %q: invalid array index %q`, pointer, token)
+		}
+		path = append(path, KeyIndex(int(index)))
+		if int(index) < v.ArrayLen() {
+			v = v.ArrayGetElm(int(index))
+		} else {
+			v = nil
+		}
+	}
+
+	return path, nil
+}
+
 // Walk traverses a JSON value v and calls the visitor function for each the JSON values included in v.
 // If a call of visitor returned an error, Walk immediately returns with the error.
 func Walk(v Value, visitor func(path Path, val Value) error) error {
@@ -77,14 +329,14 @@ func walkImpl(parentKey Path, val Value, walkFunc func(key Path, val Value) erro
 	case TypeObject:
 		for _, key := range val.ObjectKeys() {
 			val := val.ObjectGetElm(key)
-			if err := walkImpl(parentKey.Append(Key(key)), val, walkFunc); err != nil {
+			if err := walkImpl(parentKey.Append(KeyName(key)), val, walkFunc); err != nil {
 				return err
 			}
 		}
 	case TypeArray:
 		for i := 0; i < val.ArrayLen(); i++ {
 			val := val.ArrayGetElm(i)
-			if err := walkImpl(parentKey.Append(Key(strconv.FormatInt(int64(i), 10))), val, walkFunc); err != nil {
+			if err := walkImpl(parentKey.Append(KeyIndex(i)), val, walkFunc); err != nil {
 				return err
 			}
 		}
@@ -92,16 +344,107 @@ func walkImpl(parentKey Path, val Value, walkFunc func(key Path, val Value) erro
 	return nil
 }
 
+// All returns an iterator over the Paths and the JSON values included in a JSON value v in pre-order as Walk visits them.
+// The elements of JSON arrays are visited in order of their indices, while the members of JSON objects are visited in no particular order, which may differ between calls.
+// The JSON values are visited lazily, so that breaking the iteration stops the traversal.
+func All(v Value) iter.Seq2[Path, Value] {
+	return func(yield func(Path, Value) bool) {
+		allImpl(Path{}, v, yield)
+	}
+}
+
+func allImpl(path Path, val Value, yield func(Path, Value) bool) bool {
+	if !yield(path, val) {
+		return false
+	}
+	switch val.Type() {
+	case TypeObject:
+		for key, val := range val.ObjectAll() {
+			if !allImpl(path.Append(KeyName(key)), val, yield) {
+				return false
+			}
+		}
+	case TypeArray:
+		for i, val := range val.ArrayAll() {
+			if !allImpl(path.Append(KeyIndex(i)), val, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // Find finds the JSON value specified by the Path in a JSON value v.
+// A Key created by KeyName only matches a member of a JSON object and a Key created by KeyIndex only matches an element of a JSON array.
 // If the JSON value associated with the Path exists, the found JSON value and true are returned; otherwise nil and false are returned.
 func Find(v Value, path Path) (Value, bool) {
-	var found Value
-	_ = Walk(v, func(p Path, val Value) error {
-		if p.Equals(path) {
-			found = val
+	for _, key := range path {
+		switch {
+		case key.IsIndex() && v.Type() == TypeArray:
+			if key.Int() >= v.ArrayLen() {
+				return nil, false
+			}
+			v = v.ArrayGetElm(key.Int())
+		case !key.IsIndex() && v.Type() == TypeObject:
+			if !v.ObjectHasElm(key.String()) {
+				return nil, false
+			}
+			v = v.ObjectGetElm(key.String())
+		default:
+			return nil, false
 		}
-		return nil
-	})
+	}
+
+	return v, true
+}
+
+// maxInsertIndex is the largest index of a JSON array element insertAt fills up to, which bounds the nulls filled before it.
+const maxInsertIndex = 1<<20 - 1
+
+// insertAt sets a JSON value val at the Path in a JSON object or array root, creating the missing JSON objects and arrays along the Path.
+// Missing elements of JSON arrays before the index are filled with null, and an error is returned if the index is greater than maxInsertIndex.
+// If overwrite is true, a JSON value existing at the Path is replaced with val.
+// An error is returned if a JSON value exists at the Path without overwrite or a JSON value on the Path is not a container of the type the next Key requires.
+func insertAt(root Value, path Path, val Value, overwrite bool) error {
+	parent := root
+	for i, key := range path {
+		var next Value
+		last := i == len(path)-1
+		if last {
+			next = val
+		} else if path[i+1].IsIndex() {
+			next = Array()
+		} else {
+			next = Object()
+		}
+
+		switch {
+		case key.IsIndex() && parent.Type() == TypeArray:
+			if key.Int() > maxInsertIndex {
+				return fmt.Errorf(`index at %q is greater than %d`, path[:i+1].Pointer(), maxInsertIndex)
+			}
+			for parent.ArrayLen() < key.Int() {
+				parent.ArrayAddElm(Null())
+			}
+			if parent.ArrayLen() == key.Int() {
+				parent.ArrayAddElm(next)
+			} else if last && overwrite {
+				parent.ArraySetElm(key.Int(), next)
+			} else if existing := parent.ArrayGetElm(key.Int()); last || existing.Type() != next.Type() {
+				return fmt.Errorf(`JSON value at %q conflicts`, path[:i+1].Pointer())
+			}
+			parent = parent.ArrayGetElm(key.Int())
+		case !key.IsIndex() && parent.Type() == TypeObject:
+			if !parent.ObjectHasElm(key.String()) || last && overwrite {
+				parent.ObjectSetElm(key.String(), next)
+			} else if existing := parent.ObjectGetElm(key.String()); last || existing.Type() != next.Type() {
+				return fmt.Errorf(`JSON value at %q conflicts`, path[:i+1].Pointer())
+			}
+			parent = parent.ObjectGetElm(key.String())
+		default:
+			return fmt.Errorf(`JSON value at %q is not a container for %q`, path[:i].Pointer(), key.String())
+		}
+	}
 
-	return found, found != nil
+	return nil
 }
@@ -1,6 +1,7 @@
 package jsonvalue_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -32,53 +33,273 @@ func IsNotNil(t *testing.T, actual any) {
 }
 
 func TestKey_String(t *testing.T) {
-	v := jsonvalue.Key("abc").String()
-	equal(t, v, "abc")
+	t.Run("name", func(t *testing.T) {
+		v := jsonvalue.KeyName("abc").String()
+		equal(t, v, "abc")
+	})
+	t.Run("index", func(t *testing.T) {
+		v := jsonvalue.KeyIndex(123).String()
+		equal(t, v, "123")
+	})
 }
 
 func TestKey_Integer(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
-		v := jsonvalue.Key("123").Int()
+		v := jsonvalue.KeyIndex(123).Int()
 		equal(t, v, 123)
 	})
 }
 
+func TestKey_IsIndex(t *testing.T) {
+	t.Run("name", func(t *testing.T) {
+		equal(t, jsonvalue.KeyName("123").IsIndex(), false)
+	})
+	t.Run("index", func(t *testing.T) {
+		equal(t, jsonvalue.KeyIndex(123).IsIndex(), true)
+	})
+	t.Run("not equal", func(t *testing.T) {
+		equal(t, jsonvalue.KeyName("123") == jsonvalue.KeyIndex(123), false)
+	})
+}
+
 func TestPath_Equals(t *testing.T) {
 	t.Run("equal", func(t *testing.T) {
-		p := jsonvalue.Path([]jsonvalue.Key{"abc", "123"})
-		equal(t, p.Equals(jsonvalue.Path{"abc", "123"}), true)
+		p := jsonvalue.Path([]jsonvalue.Key{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)})
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}), true)
 	})
 	t.Run("not equal", func(t *testing.T) {
-		p := jsonvalue.Path{"abc", "123"}
-		equal(t, p.Equals(jsonvalue.Path{"abc", "123", "xyz"}), false)
+		p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123), jsonvalue.KeyName("xyz")}), false)
 	})
 }
 
 func TestPath_Get(t *testing.T) {
-	p := jsonvalue.Path{"abc", "123"}
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}
 	k0 := p.Get(0)
-	equal(t, k0, jsonvalue.Key("abc"))
+	equal(t, k0, jsonvalue.KeyName("abc"))
 	k1 := p.Get(1)
-	equal(t, k1, jsonvalue.Key("123"))
+	equal(t, k1, jsonvalue.KeyIndex(123))
 }
 
 func TestPath_Len(t *testing.T) {
-	p := jsonvalue.Path{"abc", "123"}
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}
 	equal(t, p.Len(), 2)
 }
 
 func TestPath_Append(t *testing.T) {
-	p := jsonvalue.Path{"abc", "123"}.Append("xyz")
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}.Append(jsonvalue.KeyName("xyz"))
 	equal(t, p.Len(), 3)
 	k1 := p.Get(2)
-	equal(t, k1, jsonvalue.Key("xyz"))
+	equal(t, k1, jsonvalue.KeyName("xyz"))
 }
 
 func TestPath_Slice(t *testing.T) {
-	p := jsonvalue.Path{"abc", "123", "xyz"}.Slice(1, 2)
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123), jsonvalue.KeyName("xyz")}.Slice(1, 2)
 	equal(t, p.Len(), 1)
 	k1 := p.Get(0)
-	equal(t, k1, jsonvalue.Key("123"))
+	equal(t, k1, jsonvalue.KeyIndex(123))
+}
+
+func TestKey_Compare(t *testing.T) {
+	t.Run("index and index", func(t *testing.T) {
+		equal(t, jsonvalue.KeyIndex(2).Compare(jsonvalue.KeyIndex(10)), -1)
+		equal(t, jsonvalue.KeyIndex(10).Compare(jsonvalue.KeyIndex(2)), 1)
+		equal(t, jsonvalue.KeyIndex(2).Compare(jsonvalue.KeyIndex(2)), 0)
+	})
+	t.Run("name and name", func(t *testing.T) {
+		equal(t, jsonvalue.KeyName("10").Compare(jsonvalue.KeyName("2")), -1)
+		equal(t, jsonvalue.KeyName("b").Compare(jsonvalue.KeyName("a")), 1)
+		equal(t, jsonvalue.KeyName("a").Compare(jsonvalue.KeyName("a")), 0)
+	})
+	t.Run("index and name", func(t *testing.T) {
+		equal(t, jsonvalue.KeyIndex(0).Compare(jsonvalue.KeyName("0")), -1)
+		equal(t, jsonvalue.KeyName("0").Compare(jsonvalue.KeyIndex(0)), 1)
+	})
+}
+
+func TestPath_Parent(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}.Parent()
+	equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("abc")}), true)
+}
+
+func TestPath_Last(t *testing.T) {
+	k := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}.Last()
+	equal(t, k, jsonvalue.KeyIndex(123))
+}
+
+func TestPath_HasPrefix(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}
+	t.Run("empty", func(t *testing.T) {
+		equal(t, p.HasPrefix(jsonvalue.Path{}), true)
+	})
+	t.Run("prefix", func(t *testing.T) {
+		equal(t, p.HasPrefix(jsonvalue.Path{jsonvalue.KeyName("abc")}), true)
+	})
+	t.Run("same", func(t *testing.T) {
+		equal(t, p.HasPrefix(p), true)
+	})
+	t.Run("longer", func(t *testing.T) {
+		equal(t, p.HasPrefix(p.Append(jsonvalue.KeyName("xyz"))), false)
+	})
+	t.Run("different", func(t *testing.T) {
+		equal(t, p.HasPrefix(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyName("123")}), false)
+	})
+}
+
+func TestPath_Join(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("abc")}.Join(
+		jsonvalue.Path{jsonvalue.KeyIndex(123)},
+		jsonvalue.Path{},
+		jsonvalue.Path{jsonvalue.KeyName("xyz")},
+	)
+	equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123), jsonvalue.KeyName("xyz")}), true)
+}
+
+func TestPath_RelativeTo(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123), jsonvalue.KeyName("xyz")}
+	t.Run("ok", func(t *testing.T) {
+		r, ok := p.RelativeTo(jsonvalue.Path{jsonvalue.KeyName("abc")})
+		equal(t, ok, true)
+		equal(t, r.Equals(jsonvalue.Path{jsonvalue.KeyIndex(123), jsonvalue.KeyName("xyz")}), true)
+	})
+	t.Run("not prefix", func(t *testing.T) {
+		_, ok := p.RelativeTo(jsonvalue.Path{jsonvalue.KeyName("xyz")})
+		equal(t, ok, false)
+	})
+}
+
+func TestPath_Compare(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}
+	t.Run("equal", func(t *testing.T) {
+		equal(t, p.Compare(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(123)}), 0)
+	})
+	t.Run("prefix", func(t *testing.T) {
+		equal(t, p.Compare(jsonvalue.Path{jsonvalue.KeyName("abc")}), 1)
+		equal(t, jsonvalue.Path{jsonvalue.KeyName("abc")}.Compare(p), -1)
+	})
+	t.Run("key", func(t *testing.T) {
+		equal(t, p.Compare(jsonvalue.Path{jsonvalue.KeyName("abc"), jsonvalue.KeyIndex(124)}), -1)
+		equal(t, p.Compare(jsonvalue.Path{jsonvalue.KeyName("abb"), jsonvalue.KeyIndex(124)}), 1)
+	})
+}
+
+func TestPath_MarshalText(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("items"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("0")}
+	b, err := p.MarshalText()
+	equal(t, err, nil)
+	equal(t, string(b), `["items",0,"0"]`)
+	equal(t, p.String(), `["items",0,"0"]`)
+}
+
+func TestPath_UnmarshalText(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var p jsonvalue.Path
+		err := p.UnmarshalText([]byte(`["items",0,"0"]`))
+		equal(t, err, nil)
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("items"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("0")}), true)
+	})
+	t.Run("empty", func(t *testing.T) {
+		var p jsonvalue.Path
+		err := p.UnmarshalText([]byte(`[]`))
+		equal(t, err, nil)
+		equal(t, p.Len(), 0)
+	})
+	t.Run("invalid key", func(t *testing.T) {
+		var p jsonvalue.Path
+		err := p.UnmarshalText([]byte(`[true]`))
+		IsNotNil(t, err)
+	})
+	t.Run("invalid index", func(t *testing.T) {
+		var p jsonvalue.Path
+		err := p.UnmarshalText([]byte(`[1.5]`))
+		IsNotNil(t, err)
+	})
+	t.Run("trailing data", func(t *testing.T) {
+		var p jsonvalue.Path
+		IsNotNil(t, p.UnmarshalText([]byte(`["a"] x`)))
+		IsNotNil(t, p.UnmarshalText([]byte(`["a"]["b"]`)))
+		equal(t, p.UnmarshalText([]byte(`["a"] `)), nil)
+	})
+	t.Run("json string", func(t *testing.T) {
+		p := jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyIndex(1)}
+		b, err := json.Marshal(map[string]jsonvalue.Path{"p": p})
+		equal(t, err, nil)
+		equal(t, string(b), `{"p":"[\"a\",1]"}`)
+	})
+}
+
+func TestPath_Key(t *testing.T) {
+	p := jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyIndex(1)}
+	counts := map[jsonvalue.PathKey]int{}
+	counts[p.Key()]++
+	counts[jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyIndex(1)}.Key()]++
+	counts[jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("1")}.Key()]++
+	equal(t, len(counts), 2)
+	equal(t, counts[p.Key()], 2)
+
+	b, err := json.Marshal(map[jsonvalue.PathKey]int{p.Key(): 1})
+	equal(t, err, nil)
+	equal(t, string(b), `{"[\"a\",1]":1}`)
+
+	got, err := p.Key().Path()
+	equal(t, err, nil)
+	equal(t, got.Equals(p), true)
+	_, err = jsonvalue.PathKey(`["a"`).Path()
+	IsNotNil(t, err)
+}
+
+func TestPath_Pointer(t *testing.T) {
+	t.Run("root", func(t *testing.T) {
+		equal(t, jsonvalue.Path{}.Pointer(), "")
+	})
+	t.Run("keys", func(t *testing.T) {
+		p := jsonvalue.Path{jsonvalue.KeyName("a/b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("~"), jsonvalue.KeyName("")}
+		equal(t, p.Pointer(), "/a~1b/0/~0/")
+	})
+}
+
+func TestParsePointer(t *testing.T) {
+	v := jsonvalue.Object(jsonvalue.Props{
+		"a/b": jsonvalue.Array(jsonvalue.Object(jsonvalue.Props{"~": jsonvalue.Null()})),
+		"0":   jsonvalue.Null(),
+	})
+	t.Run("root", func(t *testing.T) {
+		p, err := jsonvalue.ParsePointer(v, "")
+		equal(t, err, nil)
+		equal(t, p.Len(), 0)
+	})
+	t.Run("keys", func(t *testing.T) {
+		p, err := jsonvalue.ParsePointer(v, "/a~1b/0/~0")
+		equal(t, err, nil)
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("a/b"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("~")}), true)
+	})
+	t.Run("numeric member", func(t *testing.T) {
+		p, err := jsonvalue.ParsePointer(v, "/0")
+		equal(t, err, nil)
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("0")}), true)
+	})
+	t.Run("end of array", func(t *testing.T) {
+		p, err := jsonvalue.ParsePointer(v, "/a~1b/-")
+		equal(t, err, nil)
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("a/b"), jsonvalue.KeyIndex(1)}), true)
+	})
+	t.Run("not exist", func(t *testing.T) {
+		p, err := jsonvalue.ParsePointer(v, "/x/0")
+		equal(t, err, nil)
+		equal(t, p.Equals(jsonvalue.Path{jsonvalue.KeyName("x"), jsonvalue.KeyName("0")}), true)
+	})
+	t.Run("no leading slash", func(t *testing.T) {
+		_, err := jsonvalue.ParsePointer(v, "a")
+		IsNotNil(t, err)
+	})
+	t.Run("invalid escape", func(t *testing.T) {
+		_, err := jsonvalue.ParsePointer(v, "/~2")
+		IsNotNil(t, err)
+	})
+	t.Run("invalid index", func(t *testing.T) {
+		_, err := jsonvalue.ParsePointer(v, "/a~1b/01")
+		IsNotNil(t, err)
+	})
 }
 
 func TestWalk(t *testing.T) {
@@ -156,12 +377,62 @@ func TestWalk(t *testing.T) {
 		})
 		equal(t, len(p), 14)
 	})
+	t.Run(`keys`, func(t *testing.T) {
+		v := jsonvalue.Object(jsonvalue.Props{
+			"0": jsonvalue.Array(jsonvalue.Null()),
+		})
+		p := []jsonvalue.Path{}
+		_ = jsonvalue.Walk(v, func(path jsonvalue.Path, val jsonvalue.Value) error {
+			p = append(p, path)
+			return nil
+		})
+		equal(t, len(p), 3)
+		equal(t, p[1].Equals(jsonvalue.Path{jsonvalue.KeyName("0")}), true)
+		equal(t, p[2].Equals(jsonvalue.Path{jsonvalue.KeyName("0"), jsonvalue.KeyIndex(0)}), true)
+	})
 }
+func TestAll(t *testing.T) {
+	v := jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.Null(),
+		"b": jsonvalue.Object(jsonvalue.Props{
+			"x": jsonvalue.Null(),
+			"y": jsonvalue.Array(jsonvalue.Null(), jsonvalue.Null()),
+		}),
+		"0": jsonvalue.Array(jsonvalue.Object(jsonvalue.Props{"w": jsonvalue.Null()})),
+	})
+	t.Run(`same as Walk`, func(t *testing.T) {
+		want := []jsonvalue.Path{}
+		_ = jsonvalue.Walk(v, func(path jsonvalue.Path, val jsonvalue.Value) error {
+			want = append(want, path)
+			return nil
+		})
+		got := []jsonvalue.Path{}
+		for path, val := range jsonvalue.All(v) {
+			found, ok := jsonvalue.Find(v, path)
+			equal(t, ok, true)
+			equal(t, found, val)
+			got = append(got, path)
+		}
+		equal(t, len(got), 10)
+		equal(t, len(got), len(want))
+	})
+	t.Run(`break`, func(t *testing.T) {
+		count := 0
+		for path := range jsonvalue.All(v) {
+			count++
+			if path.Len() == 2 {
+				break
+			}
+		}
+		equal(t, count < 10, true)
+	})
+}
+
 func TestFind(t *testing.T) {
 	t.Run(`not found`, func(t *testing.T) {
 		t.Run(`null`, func(t *testing.T) {
 			v := jsonvalue.Null()
-			_, ok := jsonvalue.Find(v, jsonvalue.Path{"xxx"})
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("xxx")})
 			equal(t, ok, false)
 		})
 		t.Run(`object`, func(t *testing.T) {
@@ -186,7 +457,7 @@ func TestFind(t *testing.T) {
 					),
 				),
 			})
-			_, ok := jsonvalue.Find(v, jsonvalue.Path{"xxx"})
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("xxx")})
 			equal(t, ok, false)
 		})
 		t.Run(`array`, func(t *testing.T) {
@@ -211,9 +482,32 @@ func TestFind(t *testing.T) {
 					),
 				),
 			)
-			_, ok := jsonvalue.Find(v, jsonvalue.Path{"xxx"})
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("xxx")})
+			equal(t, ok, false)
+		})
+		t.Run(`name for array`, func(t *testing.T) {
+			v := jsonvalue.Array(jsonvalue.Null())
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("0")})
+			equal(t, ok, false)
+		})
+		t.Run(`index for object`, func(t *testing.T) {
+			v := jsonvalue.Object(jsonvalue.Props{"0": jsonvalue.Null()})
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(0)})
 			equal(t, ok, false)
 		})
+		t.Run(`index out of range`, func(t *testing.T) {
+			v := jsonvalue.Array(jsonvalue.Null())
+			_, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1)})
+			equal(t, ok, false)
+		})
+	})
+	t.Run(`numeric name`, func(t *testing.T) {
+		v := jsonvalue.Object(jsonvalue.Props{
+			"0": jsonvalue.Array(jsonvalue.Boolean(true)),
+		})
+		a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("0"), jsonvalue.KeyIndex(0)})
+		equal(t, ok, true)
+		equal(t, a.Type(), jsonvalue.TypeBoolean)
 	})
 	t.Run(`null`, func(t *testing.T) {
 		v := jsonvalue.Null()
@@ -249,67 +543,67 @@ func TestFind(t *testing.T) {
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".a", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"a"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("a")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".b", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".b.x", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b", "x"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyName("x")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".b.y", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b", "y"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyName("y")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".b.y.w", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b", "y", "w"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyName("y"), jsonvalue.KeyName("w")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".b.z", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b", "z"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyName("z")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".b.z.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"b", "z", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("b"), jsonvalue.KeyName("z"), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".c", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".c.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".c.1", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c", "1"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(1)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".c.1.w", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c", "1", "w"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(1), jsonvalue.KeyName("w")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".c.2", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c", "2"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(2)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".c.2.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"c", "2", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
@@ -342,67 +636,67 @@ func TestFind(t *testing.T) {
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".1", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".1.x", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1", "x"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("x")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".1.y", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1", "y"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("y")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".1.y.w", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1", "y", "w"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("y"), jsonvalue.KeyName("w")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".1.z", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1", "z"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("z")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".1.z.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"1", "z", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(1), jsonvalue.KeyName("z"), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".2", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".2.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".2.1", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2", "1"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(1)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeObject)
 		})
 		t.Run(".2.1.w", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2", "1", "w"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(1), jsonvalue.KeyName("w")})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
 		t.Run(".2.2", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2", "2"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(2)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeArray)
 		})
 		t.Run(".2.2.0", func(t *testing.T) {
-			a, ok := jsonvalue.Find(v, jsonvalue.Path{"2", "2", "0"})
+			a, ok := jsonvalue.Find(v, jsonvalue.Path{jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(2), jsonvalue.KeyIndex(0)})
 			equal(t, ok, true)
 			equal(t, a.Type(), jsonvalue.TypeNull)
 		})
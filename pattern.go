@@ -0,0 +1,172 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// PathPattern represents a pattern matching Paths.
+//
+// A PathPattern is written as a sequence of segments each of which is prefixed by '/', e.g. /items/*/id.
+// The empty pattern matches only the empty Path representing a root JSON value.
+// A segment ** matches zero or more keys, and any other segment matches exactly one key.
+// In a segment, * matches any sequence of characters, ? matches any single character, and \ escapes the following character.
+// ~0 and ~1 in a segment are replaced with ~ and / respectively, as in JSON Pointer.
+// A segment is matched against the string representation of a Key, so that it matches both a member name and an index.
+type PathPattern struct {
+	text     string
+	segments []patternSegment
+}
+
+type patternSegment struct {
+	anyKeys bool
+	tokens  []patternToken
+}
+
+type patternTokenKind int
+
+const (
+	patternTokenLiteral patternTokenKind = iota
+	patternTokenAnyChar
+	patternTokenAnyString
+)
+
+type patternToken struct {
+	kind patternTokenKind
+	char rune
+}
+
+// ParsePathPattern parses a pattern text to PathPattern.
+func ParsePathPattern(pattern string) (PathPattern, error) {
+	if pattern == "" {
+		return PathPattern{text: pattern}, nil
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return PathPattern{}, fmt.Errorf(`fail to parse path pattern %q: pattern must begin with '/'`, pattern)
+	}
+
+	segments := []patternSegment{}
+	for _, s := range strings.Split(pattern[1:], "/") {
+		segment, err := parsePatternSegment(s)
+		if err != nil {
+			return PathPattern{}, fmt.Errorf(`fail to parse path pattern %q: %w`, pattern, err)
+		}
+		segments = append(segments, segment)
+	}
+
+	return PathPattern{text: pattern, segments: segments}, nil
+}
+
+// MustParsePathPattern parses a pattern text to PathPattern and panics if the pattern is invalid.
+func MustParsePathPattern(pattern string) PathPattern {
+	p, err := ParsePathPattern(pattern)
+	assert.Params(err == nil, "invalid pattern: %w", err)
+
+	return p
+}
+
+func parsePatternSegment(s string) (patternSegment, error) {
+	if s == "**" {
+		return patternSegment{anyKeys: true}, nil
+	}
+
+	tokens := []patternToken{}
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			tokens = append(tokens, patternToken{kind: patternTokenAnyString})
+		case '?':
+			tokens = append(tokens, patternToken{kind: patternTokenAnyChar})
+		case '\\':
+			if i+1 >= len(runes) {
+				return patternSegment{}, fmt.Errorf(`segment %q must not end with '\'`, s)
+			}
+			i++
+			tokens = append(tokens, patternToken{kind: patternTokenLiteral, char: runes[i]})
+		case '~':
+			if i+1 >= len(runes) || (runes[i+1] != '0' && runes[i+1] != '1') {
+				return patternSegment{}, fmt.Errorf(`segment %q contains invalid escape sequence`, s)
+			}
+			i++
+			if runes[i] == '0' {
+				tokens = append(tokens, patternToken{kind: patternTokenLiteral, char: '~'})
+			} else {
+				tokens = append(tokens, patternToken{kind: patternTokenLiteral, char: '/'})
+			}
+		default:
+			tokens = append(tokens, patternToken{kind: patternTokenLiteral, char: r})
+		}
+	}
+
+	return patternSegment{tokens: tokens}, nil
+}
+
+// String returns the pattern text.
+func (p PathPattern) String() string {
+	return p.text
+}
+
+// Match returns true if the PathPattern matches the Path; otherwise false.
+func (p PathPattern) Match(path Path) bool {
+	return matchSegments(p.segments, path)
+}
+
+func matchSegments(segments []patternSegment, path Path) bool {
+	if len(segments) == 0 {
+		return path.Len() == 0
+	}
+
+	if segments[0].anyKeys {
+		for i := 0; i <= path.Len(); i++ {
+			if matchSegments(segments[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return path.Len() > 0 && segments[0].match(path[0].String()) && matchSegments(segments[1:], path[1:])
+}
+
+func (s patternSegment) match(key string) bool {
+	return matchTokens(s.tokens, []rune(key))
+}
+
+func matchTokens(tokens []patternToken, runes []rune) bool {
+	for len(tokens) > 0 {
+		switch token := tokens[0]; token.kind {
+		case patternTokenAnyString:
+			for i := 0; i <= len(runes); i++ {
+				if matchTokens(tokens[1:], runes[i:]) {
+					return true
+				}
+			}
+			return false
+		case patternTokenAnyChar:
+			if len(runes) == 0 {
+				return false
+			}
+		case patternTokenLiteral:
+			if len(runes) == 0 || runes[0] != token.char {
+				return false
+			}
+		}
+		tokens, runes = tokens[1:], runes[1:]
+	}
+
+	return len(runes) == 0
+}
+
+// WalkMatching traverses a JSON value v and calls the visitor function for each the JSON values included in v whose Path matches the pattern.
+// If a call of visitor returned an error, WalkMatching immediately returns with the error.
+func WalkMatching(v Value, pattern PathPattern, visitor func(path Path, val Value) error) error {
+	return Walk(v, func(path Path, val Value) error {
+		if !pattern.Match(path) {
+			return nil
+		}
+		return visitor(path, val)
+	})
+}
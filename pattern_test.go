@@ -0,0 +1,107 @@
+package jsonvalue_test
+
+import (
+	"fmt"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestParsePathPattern(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		for _, s := range []string{``, `/`, `/a`, `/items/*/id`, `/**/password`, `/a~0b~1c`, `/\*`, `/a?c`} {
+			p, err := jsonvalue.ParsePathPattern(s)
+			equal(t, err, nil)
+			equal(t, p.String(), s)
+		}
+	})
+	t.Run("no leading slash", func(t *testing.T) {
+		_, err := jsonvalue.ParsePathPattern(`a/b`)
+		IsNotNil(t, err)
+	})
+	t.Run("invalid escape", func(t *testing.T) {
+		_, err := jsonvalue.ParsePathPattern(`/a~2`)
+		IsNotNil(t, err)
+	})
+	t.Run("trailing backslash", func(t *testing.T) {
+		_, err := jsonvalue.ParsePathPattern(`/a\`)
+		IsNotNil(t, err)
+	})
+}
+
+func TestPathPattern_Match(t *testing.T) {
+	type testCase struct {
+		pattern string
+		path    jsonvalue.Path
+		want    bool
+	}
+	testCases := []testCase{
+		{pattern: ``, path: jsonvalue.Path{}, want: true},
+		{pattern: ``, path: jsonvalue.Path{jsonvalue.KeyName("a")}, want: false},
+		{pattern: `/a`, path: jsonvalue.Path{jsonvalue.KeyName("a")}, want: true},
+		{pattern: `/a`, path: jsonvalue.Path{jsonvalue.KeyName("b")}, want: false},
+		{pattern: `/a`, path: jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("b")}, want: false},
+		{pattern: `/`, path: jsonvalue.Path{jsonvalue.KeyName("")}, want: true},
+		{pattern: `/0`, path: jsonvalue.Path{jsonvalue.KeyIndex(0)}, want: true},
+		{pattern: `/0`, path: jsonvalue.Path{jsonvalue.KeyName("0")}, want: true},
+		{pattern: `/items/*/id`, path: jsonvalue.Path{jsonvalue.KeyName("items"), jsonvalue.KeyIndex(3), jsonvalue.KeyName("id")}, want: true},
+		{pattern: `/items/*/id`, path: jsonvalue.Path{jsonvalue.KeyName("items"), jsonvalue.KeyName("id")}, want: false},
+		{pattern: `/**/password`, path: jsonvalue.Path{jsonvalue.KeyName("password")}, want: true},
+		{pattern: `/**/password`, path: jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyIndex(0), jsonvalue.KeyName("password")}, want: true},
+		{pattern: `/**/password`, path: jsonvalue.Path{jsonvalue.KeyName("password"), jsonvalue.KeyName("x")}, want: false},
+		{pattern: `/**`, path: jsonvalue.Path{}, want: true},
+		{pattern: `/a/**`, path: jsonvalue.Path{jsonvalue.KeyName("a"), jsonvalue.KeyName("b"), jsonvalue.KeyName("c")}, want: true},
+		{pattern: `/*_key`, path: jsonvalue.Path{jsonvalue.KeyName("api_key")}, want: true},
+		{pattern: `/*_key`, path: jsonvalue.Path{jsonvalue.KeyName("api_keys")}, want: false},
+		{pattern: `/a?c`, path: jsonvalue.Path{jsonvalue.KeyName("abc")}, want: true},
+		{pattern: `/a?c`, path: jsonvalue.Path{jsonvalue.KeyName("ac")}, want: false},
+		{pattern: `/\*`, path: jsonvalue.Path{jsonvalue.KeyName("*")}, want: true},
+		{pattern: `/\*`, path: jsonvalue.Path{jsonvalue.KeyName("a")}, want: false},
+		{pattern: `/a~1b~0`, path: jsonvalue.Path{jsonvalue.KeyName("a/b~")}, want: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf(`%s %v`, testCase.pattern, testCase.path), func(t *testing.T) {
+			p := jsonvalue.MustParsePathPattern(testCase.pattern)
+			equal(t, p.Match(testCase.path), testCase.want)
+		})
+	}
+}
+
+func TestWalkMatching(t *testing.T) {
+	v := jsonvalue.Object(jsonvalue.Props{
+		"password": jsonvalue.String("a"),
+		"items": jsonvalue.Array(
+			jsonvalue.Object(jsonvalue.Props{
+				"id":       jsonvalue.Number(1),
+				"password": jsonvalue.String("b"),
+			}),
+			jsonvalue.Object(jsonvalue.Props{
+				"id": jsonvalue.Number(2),
+			}),
+		),
+	})
+	t.Run("ids", func(t *testing.T) {
+		p := []jsonvalue.Path{}
+		err := jsonvalue.WalkMatching(v, jsonvalue.MustParsePathPattern(`/items/*/id`), func(path jsonvalue.Path, val jsonvalue.Value) error {
+			p = append(p, path)
+			return nil
+		})
+		equal(t, err, nil)
+		equal(t, len(p), 2)
+	})
+	t.Run("passwords", func(t *testing.T) {
+		p := []jsonvalue.Path{}
+		err := jsonvalue.WalkMatching(v, jsonvalue.MustParsePathPattern(`/**/password`), func(path jsonvalue.Path, val jsonvalue.Value) error {
+			p = append(p, path)
+			return nil
+		})
+		equal(t, err, nil)
+		equal(t, len(p), 2)
+	})
+	t.Run("error", func(t *testing.T) {
+		err := jsonvalue.WalkMatching(v, jsonvalue.MustParsePathPattern(`/**`), func(path jsonvalue.Path, val jsonvalue.Value) error {
+			return fmt.Errorf("")
+		})
+		IsNotNil(t, err)
+	})
+}
@@ -0,0 +1,511 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+)
+
+// SchemaError represents a violation of a JSON Schema found by ValidateSchema.
+type SchemaError struct {
+	// Path is the location of the violating JSON value.
+	Path Path
+	// Message describes the violation.
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf(`#%s: %s`, e.Path.Pointer(), e.Message)
+}
+
+// ValidateSchema validates a JSON value v against a JSON Schema and returns the violations found.
+// The supported keywords are type, enum, const, properties, patternProperties, additionalProperties, required, minProperties, maxProperties,
+// items, prefixItems, minItems, maxItems, uniqueItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// allOf, anyOf, oneOf, not, if, then, else and $ref referring to the same schema by a JSON Pointer fragment such as "#/$defs/item".
+// The other keywords are ignored.
+// If the schema is malformed, an error is returned.
+func ValidateSchema(v Value, schema Value) ([]SchemaError, error) {
+	s := &schemaValidator{root: schema}
+	violations, err := s.validate(Path{}, v, schema, 0)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to validate Value against schema: %w`, err)
+	}
+
+	return violations, nil
+}
+
+type schemaValidator struct {
+	root Value
+}
+
+// maxSchemaRefDepth limits the depth of nested $ref to detect cyclic references.
+const maxSchemaRefDepth = 64
+
+func schemaKeyword(schema Value, keyword string) (Value, bool) {
+	if !schema.ObjectHasElm(keyword) {
+		return nil, false
+	}
+
+	return schema.ObjectGetElm(keyword), true
+}
+
+func schemaNumber(schema Value, keyword string) (*big.Rat, bool, error) {
+	k, ok := schemaKeyword(schema, keyword)
+	if !ok {
+		return nil, false, nil
+	}
+	if k.Type() != TypeNumber {
+		return nil, false, fmt.Errorf(`%s must be a number`, keyword)
+	}
+	r, ok := new(big.Rat).SetString(k.NumberGet().String())
+	if !ok {
+		return nil, false, fmt.Errorf(`%s must be a number`, keyword)
+	}
+
+	return r, true, nil
+}
+
+func (s *schemaValidator) validate(path Path, v Value, schema Value, depth int) ([]SchemaError, error) {
+	switch schema.Type() {
+	case TypeBoolean:
+		if schema.BooleanGet() {
+			return nil, nil
+		}
+		return []SchemaError{{Path: path, Message: "no value is allowed"}}, nil
+	case TypeObject:
+	default:
+		return nil, fmt.Errorf(`schema at %q must be an object or a boolean`, path.Pointer())
+	}
+
+	violations := []SchemaError{}
+	report := func(format string, args ...any) {
+		violations = append(violations, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	sub := func(path Path, v Value, schema Value) error {
+		found, err := s.validate(path, v, schema, depth)
+		violations = append(violations, found...)
+		return err
+	}
+
+	if ref, ok := schemaKeyword(schema, "$ref"); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		if depth >= maxSchemaRefDepth {
+			return nil, fmt.Errorf(`$ref %s is nested too deeply`, ref.StringGet())
+		}
+		found, err := s.validate(path, v, target, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+	}
+
+	if err := s.validateGeneric(v, schema, report); err != nil {
+		return nil, err
+	}
+	if err := s.validateNumber(v, schema, report); err != nil {
+		return nil, err
+	}
+	if err := s.validateString(v, schema, report); err != nil {
+		return nil, err
+	}
+	if err := s.validateArray(path, v, schema, report, sub); err != nil {
+		return nil, err
+	}
+	if err := s.validateObject(path, v, schema, report, sub); err != nil {
+		return nil, err
+	}
+	if err := s.validateCombination(path, v, schema, depth, report, sub); err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+func (s *schemaValidator) resolve(ref Value) (Value, error) {
+	if ref.Type() != TypeString || !strings.HasPrefix(ref.StringGet(), "#") {
+		return nil, fmt.Errorf(`$ref must be a JSON Pointer fragment in the same schema`)
+	}
+
+	path, err := ParsePointer(s.root, ref.StringGet()[1:])
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse $ref %s: %w`, ref.StringGet(), err)
+	}
+	target, ok := Find(s.root, path)
+	if !ok {
+		return nil, fmt.Errorf(`$ref %s is not found`, ref.StringGet())
+	}
+
+	return target, nil
+}
+
+func schemaTypeMatches(v Value, typ string) (bool, error) {
+	switch typ {
+	case "null":
+		return v.Type() == TypeNull, nil
+	case "boolean":
+		return v.Type() == TypeBoolean, nil
+	case "number":
+		return v.Type() == TypeNumber, nil
+	case "integer":
+		if v.Type() != TypeNumber {
+			return false, nil
+		}
+		r, ok := new(big.Rat).SetString(v.NumberGet().String())
+		return ok && r.IsInt(), nil
+	case "string":
+		return v.Type() == TypeString, nil
+	case "array":
+		return v.Type() == TypeArray, nil
+	case "object":
+		return v.Type() == TypeObject, nil
+	default:
+		return false, fmt.Errorf(`unknown type %q`, typ)
+	}
+}
+
+func (s *schemaValidator) validateGeneric(v Value, schema Value, report func(string, ...any)) error {
+	if typ, ok := schemaKeyword(schema, "type"); ok {
+		types := []string{}
+		switch typ.Type() {
+		case TypeString:
+			types = append(types, typ.StringGet())
+		case TypeArray:
+			for _, t := range typ.ArrayAll() {
+				if t.Type() != TypeString {
+					return fmt.Errorf(`type must be a string or an array of strings`)
+				}
+				types = append(types, t.StringGet())
+			}
+		default:
+			return fmt.Errorf(`type must be a string or an array of strings`)
+		}
+
+		matched := false
+		for _, t := range types {
+			ok, err := schemaTypeMatches(v, t)
+			if err != nil {
+				return err
+			}
+			matched = matched || ok
+		}
+		if !matched {
+			report(`must be of type %s`, strings.Join(types, " or "))
+		}
+	}
+
+	if enum, ok := schemaKeyword(schema, "enum"); ok {
+		if enum.Type() != TypeArray {
+			return fmt.Errorf(`enum must be an array`)
+		}
+		found := false
+		for _, e := range enum.ArrayAll() {
+			found = found || Equal(v, e)
+		}
+		if !found {
+			report(`must be one of the enumerated values`)
+		}
+	}
+
+	if c, ok := schemaKeyword(schema, "const"); ok && !Equal(v, c) {
+		report(`must be equal to the constant`)
+	}
+
+	return nil
+}
+
+func (s *schemaValidator) validateNumber(v Value, schema Value, report func(string, ...any)) error {
+	if v.Type() != TypeNumber {
+		return nil
+	}
+
+	x, ok := new(big.Rat).SetString(v.NumberGet().String())
+	if !ok {
+		return nil
+	}
+
+	bounds := []struct {
+		keyword string
+		violate func(c int) bool
+		message string
+	}{
+		{"minimum", func(c int) bool { return c < 0 }, "must be greater than or equal to %s"},
+		{"maximum", func(c int) bool { return c > 0 }, "must be less than or equal to %s"},
+		{"exclusiveMinimum", func(c int) bool { return c <= 0 }, "must be greater than %s"},
+		{"exclusiveMaximum", func(c int) bool { return c >= 0 }, "must be less than %s"},
+	}
+	for _, b := range bounds {
+		bound, ok, err := schemaNumber(schema, b.keyword)
+		if err != nil {
+			return err
+		}
+		if ok && b.violate(x.Cmp(bound)) {
+			report(b.message, schema.ObjectGetElm(b.keyword).NumberGet())
+		}
+	}
+
+	m, ok, err := schemaNumber(schema, "multipleOf")
+	if err != nil {
+		return err
+	}
+	if ok {
+		if m.Sign() <= 0 {
+			return fmt.Errorf(`multipleOf must be greater than 0`)
+		}
+		if !new(big.Rat).Quo(x, m).IsInt() {
+			report(`must be a multiple of %s`, schema.ObjectGetElm("multipleOf").NumberGet())
+		}
+	}
+
+	return nil
+}
+
+func schemaInt(schema Value, keyword string) (int, bool, error) {
+	r, ok, err := schemaNumber(schema, keyword)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+	if !r.IsInt() || r.Sign() < 0 || !r.Num().IsInt64() {
+		return 0, false, fmt.Errorf(`%s must be a non-negative integer`, keyword)
+	}
+
+	return int(r.Num().Int64()), true, nil
+}
+
+func (s *schemaValidator) validateString(v Value, schema Value, report func(string, ...any)) error {
+	if v.Type() != TypeString {
+		return nil
+	}
+
+	length := utf8.RuneCountInString(v.StringGet())
+	if n, ok, err := schemaInt(schema, "minLength"); err != nil {
+		return err
+	} else if ok && length < n {
+		report(`must be at least %d characters long`, n)
+	}
+	if n, ok, err := schemaInt(schema, "maxLength"); err != nil {
+		return err
+	} else if ok && length > n {
+		report(`must be at most %d characters long`, n)
+	}
+
+	if pattern, ok := schemaKeyword(schema, "pattern"); ok {
+		if pattern.Type() != TypeString {
+			return fmt.Errorf(`pattern must be a string`)
+		}
+		re, err := regexp.Compile(pattern.StringGet())
+		if err != nil {
+			return fmt.Errorf(`pattern %q is not a valid regular expression: %w`, pattern.StringGet(), err)
+		}
+		if !re.MatchString(v.StringGet()) {
+			report(`must match the pattern %q`, pattern.StringGet())
+		}
+	}
+
+	return nil
+}
+
+func (s *schemaValidator) validateArray(path Path, v Value, schema Value, report func(string, ...any), sub func(Path, Value, Value) error) error {
+	if v.Type() != TypeArray {
+		return nil
+	}
+
+	if n, ok, err := schemaInt(schema, "minItems"); err != nil {
+		return err
+	} else if ok && v.ArrayLen() < n {
+		report(`must have at least %d items`, n)
+	}
+	if n, ok, err := schemaInt(schema, "maxItems"); err != nil {
+		return err
+	} else if ok && v.ArrayLen() > n {
+		report(`must have at most %d items`, n)
+	}
+
+	if unique, ok := schemaKeyword(schema, "uniqueItems"); ok && unique.Type() == TypeBoolean && unique.BooleanGet() {
+		if Distinct(v).ArrayLen() != v.ArrayLen() {
+			report(`must not have duplicate items`)
+		}
+	}
+
+	prefixLen := 0
+	if prefixItems, ok := schemaKeyword(schema, "prefixItems"); ok {
+		if prefixItems.Type() != TypeArray {
+			return fmt.Errorf(`prefixItems must be an array`)
+		}
+		prefixLen = prefixItems.ArrayLen()
+		for i := 0; i < prefixLen && i < v.ArrayLen(); i++ {
+			if err := sub(path.Append(KeyIndex(i)), v.ArrayGetElm(i), prefixItems.ArrayGetElm(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if items, ok := schemaKeyword(schema, "items"); ok {
+		for i := prefixLen; i < v.ArrayLen(); i++ {
+			if err := sub(path.Append(KeyIndex(i)), v.ArrayGetElm(i), items); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *schemaValidator) validateObject(path Path, v Value, schema Value, report func(string, ...any), sub func(Path, Value, Value) error) error {
+	if v.Type() != TypeObject {
+		return nil
+	}
+
+	if n, ok, err := schemaInt(schema, "minProperties"); err != nil {
+		return err
+	} else if ok && v.ObjectLen() < n {
+		report(`must have at least %d properties`, n)
+	}
+	if n, ok, err := schemaInt(schema, "maxProperties"); err != nil {
+		return err
+	} else if ok && v.ObjectLen() > n {
+		report(`must have at most %d properties`, n)
+	}
+
+	if required, ok := schemaKeyword(schema, "required"); ok {
+		if required.Type() != TypeArray {
+			return fmt.Errorf(`required must be an array of strings`)
+		}
+		for _, key := range required.ArrayAll() {
+			if key.Type() != TypeString {
+				return fmt.Errorf(`required must be an array of strings`)
+			}
+			if !v.ObjectHasElm(key.StringGet()) {
+				report(`must have the required property %q`, key.StringGet())
+			}
+		}
+	}
+
+	properties, hasProperties := schemaKeyword(schema, "properties")
+	if hasProperties && properties.Type() != TypeObject {
+		return fmt.Errorf(`properties must be an object`)
+	}
+	patternProperties, hasPatternProperties := schemaKeyword(schema, "patternProperties")
+	patterns := []string{}
+	regexps := map[string]*regexp.Regexp{}
+	if hasPatternProperties {
+		if patternProperties.Type() != TypeObject {
+			return fmt.Errorf(`patternProperties must be an object`)
+		}
+		patterns = patternProperties.ObjectKeys()
+		slices.Sort(patterns)
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf(`pattern %q is not a valid regular expression: %w`, pattern, err)
+			}
+			regexps[pattern] = re
+		}
+	}
+	additional, hasAdditional := schemaKeyword(schema, "additionalProperties")
+
+	keys := v.ObjectKeys()
+	slices.Sort(keys)
+	for _, key := range keys {
+		childPath := path.Append(KeyName(key))
+		child := v.ObjectGetElm(key)
+		evaluated := false
+		if hasProperties && properties.ObjectHasElm(key) {
+			evaluated = true
+			if err := sub(childPath, child, properties.ObjectGetElm(key)); err != nil {
+				return err
+			}
+		}
+		for _, pattern := range patterns {
+			if regexps[pattern].MatchString(key) {
+				evaluated = true
+				if err := sub(childPath, child, patternProperties.ObjectGetElm(pattern)); err != nil {
+					return err
+				}
+			}
+		}
+		if !evaluated && hasAdditional {
+			if err := sub(childPath, child, additional); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *schemaValidator) validateCombination(path Path, v Value, schema Value, depth int, report func(string, ...any), sub func(Path, Value, Value) error) error {
+	count := func(keyword string) (int, int, error) {
+		schemas, ok := schemaKeyword(schema, keyword)
+		if !ok {
+			return 0, 0, nil
+		}
+		if schemas.Type() != TypeArray || schemas.ArrayLen() == 0 {
+			return 0, 0, fmt.Errorf(`%s must be a non-empty array`, keyword)
+		}
+		valid := 0
+		for _, sch := range schemas.ArrayAll() {
+			found, err := s.validate(path, v, sch, depth)
+			if err != nil {
+				return 0, 0, err
+			}
+			if len(found) == 0 {
+				valid++
+			}
+		}
+		return valid, schemas.ArrayLen(), nil
+	}
+
+	if schemas, ok := schemaKeyword(schema, "allOf"); ok {
+		if schemas.Type() != TypeArray || schemas.ArrayLen() == 0 {
+			return fmt.Errorf(`allOf must be a non-empty array`)
+		}
+		for _, sch := range schemas.ArrayAll() {
+			if err := sub(path, v, sch); err != nil {
+				return err
+			}
+		}
+	}
+	if valid, n, err := count("anyOf"); err != nil {
+		return err
+	} else if n > 0 && valid == 0 {
+		report(`must match at least one schema in anyOf`)
+	}
+	if valid, n, err := count("oneOf"); err != nil {
+		return err
+	} else if n > 0 && valid != 1 {
+		report(`must match exactly one schema in oneOf but matched %d`, valid)
+	}
+	if not, ok := schemaKeyword(schema, "not"); ok {
+		found, err := s.validate(path, v, not, depth)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			report(`must not match the schema in not`)
+		}
+	}
+	if cond, ok := schemaKeyword(schema, "if"); ok {
+		found, err := s.validate(path, v, cond, depth)
+		if err != nil {
+			return err
+		}
+		branch := "else"
+		if len(found) == 0 {
+			branch = "then"
+		}
+		if sch, ok := schemaKeyword(schema, branch); ok {
+			if err := sub(path, v, sch); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestValidateSchema(t *testing.T) {
+	type testCase struct {
+		name   string
+		schema string
+		in     string
+		want   []string
+	}
+	testCases := []testCase{
+		{name: "true", schema: `true`, in: `1`, want: []string{}},
+		{name: "false", schema: `false`, in: `1`, want: []string{`#: no value is allowed`}},
+		{name: "type", schema: `{"type":"string"}`, in: `1`, want: []string{`#: must be of type string`}},
+		{name: "type integer", schema: `{"type":"integer"}`, in: `2.0`, want: []string{}},
+		{name: "type list", schema: `{"type":["integer","null"]}`, in: `2.5`, want: []string{`#: must be of type integer or null`}},
+		{name: "enum", schema: `{"enum":[1,"a"]}`, in: `1.0`, want: []string{}},
+		{name: "const", schema: `{"const":{"a":1}}`, in: `{"a":2}`, want: []string{`#: must be equal to the constant`}},
+		{name: "number", schema: `{"minimum":1,"exclusiveMaximum":3,"multipleOf":0.5}`, in: `3`, want: []string{`#: must be less than 3`}},
+		{name: "multipleOf", schema: `{"multipleOf":0.1}`, in: `0.35`, want: []string{`#: must be a multiple of 0.1`}},
+		{name: "string", schema: `{"minLength":2,"pattern":"^[a-z]+$"}`, in: `"é"`, want: []string{`#: must be at least 2 characters long`, `#: must match the pattern "^[a-z]+$"`}},
+		{
+			name:   "array",
+			schema: `{"prefixItems":[{"type":"string"}],"items":{"type":"number"},"maxItems":3,"uniqueItems":true}`,
+			in:     `["a",1,"b",1]`,
+			want:   []string{`#: must have at most 3 items`, `#: must not have duplicate items`, `#/2: must be of type number`},
+		},
+		{
+			name:   "object",
+			schema: `{"required":["a","b"],"properties":{"a":{"type":"number"}},"patternProperties":{"^x":{"type":"string"}},"additionalProperties":false}`,
+			in:     `{"a":"1","x1":"ok","y":null}`,
+			want:   []string{`#: must have the required property "b"`, `#/a: must be of type number`, `#/y: no value is allowed`},
+		},
+		{name: "allOf", schema: `{"allOf":[{"minimum":1},{"maximum":0}]}`, in: `2`, want: []string{`#: must be less than or equal to 0`}},
+		{name: "anyOf", schema: `{"anyOf":[{"type":"string"},{"type":"null"}]}`, in: `2`, want: []string{`#: must match at least one schema in anyOf`}},
+		{name: "oneOf", schema: `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, in: `2`, want: []string{`#: must match exactly one schema in oneOf but matched 2`}},
+		{name: "not", schema: `{"not":{"type":"null"}}`, in: `null`, want: []string{`#: must not match the schema in not`}},
+		{name: "if then", schema: `{"if":{"type":"number"},"then":{"minimum":0},"else":{"type":"string"}}`, in: `-1`, want: []string{`#: must be greater than or equal to 0`}},
+		{name: "if else", schema: `{"if":{"type":"number"},"then":{"minimum":0},"else":{"type":"string"}}`, in: `null`, want: []string{`#: must be of type string`}},
+		{
+			name:   "ref",
+			schema: `{"$defs":{"node":{"type":"object","properties":{"next":{"$ref":"#/$defs/node"}},"required":["v"]}},"$ref":"#/$defs/node"}`,
+			in:     `{"v":1,"next":{"next":{"v":2}}}`,
+			want:   []string{`#/next: must have the required property "v"`},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.ValidateSchema(mustUnmarshal(t, testCase.in), mustUnmarshal(t, testCase.schema))
+			equal(t, err, nil)
+
+			messages := []string{}
+			for _, e := range got {
+				messages = append(messages, e.Error())
+			}
+			equal(t, mustMarshal(t, messages), mustMarshal(t, testCase.want))
+		})
+	}
+}
+
+func TestValidateSchema_Error(t *testing.T) {
+	type testCase struct {
+		schema string
+		in     string
+	}
+	testCases := []testCase{
+		{schema: `1`, in: `null`},
+		{schema: `{"type":"text"}`, in: `null`},
+		{schema: `{"minimum":"1"}`, in: `1`},
+		{schema: `{"pattern":"("}`, in: `"a"`},
+		{schema: `{"$ref":"#/missing"}`, in: `null`},
+		{schema: `{"$ref":"#"}`, in: `null`},
+		{schema: `{"multipleOf":0}`, in: `1`},
+		{schema: `{"anyOf":[]}`, in: `null`},
+		{schema: `{"properties":{"a":"b"}}`, in: `{"a":1}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.schema, func(t *testing.T) {
+			_, err := jsonvalue.ValidateSchema(mustUnmarshal(t, testCase.in), mustUnmarshal(t, testCase.schema))
+			IsNotNil(t, err)
+		})
+	}
+}
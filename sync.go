@@ -0,0 +1,82 @@
+package jsonvalue
+
+import (
+	"sync"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// SyncValue holds a JSON value shared by multiple goroutines with copy-on-write semantics.
+// Readers obtain an immutable snapshot that is never affected by later updates, and updates are serialized.
+// The zero value holds a JSON null value and is ready to use.
+// A SyncValue must not be copied after first use.
+type SyncValue struct {
+	readMu  sync.RWMutex
+	writeMu sync.Mutex
+	current Frozen
+}
+
+// NewSyncValue returns a SyncValue holding a deep copy of a JSON value v.
+func NewSyncValue(v Value) *SyncValue {
+	assert.Params(v != nil, "Value must not be nil")
+
+	return &SyncValue{current: Freeze(v)}
+}
+
+// Load returns a consistent snapshot of the held JSON value.
+func (s *SyncValue) Load() Frozen {
+	s.readMu.RLock()
+	defer s.readMu.RUnlock()
+
+	return s.load()
+}
+
+func (s *SyncValue) load() Frozen {
+	if s.current == nil {
+		return Freeze(Null())
+	}
+
+	return s.current
+}
+
+// Store replaces the held JSON value with a deep copy of a JSON value v.
+func (s *SyncValue) Store(v Value) {
+	_ = s.Swap(v)
+}
+
+// Swap replaces the held JSON value with a deep copy of a JSON value v and returns the snapshot of the previous one.
+func (s *SyncValue) Swap(v Value) Frozen {
+	assert.Params(v != nil, "Value must not be nil")
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.swap(Freeze(v))
+}
+
+func (s *SyncValue) swap(f Frozen) Frozen {
+	s.readMu.Lock()
+	defer s.readMu.Unlock()
+
+	old := s.load()
+	s.current = f
+
+	return old
+}
+
+// Update atomically replaces the held JSON value with the result of fn.
+// fn receives a mutable deep copy of the held JSON value, which may be modified and returned.
+// Calls of Update, Store and Swap are serialized, so no update is lost, while readers continue to see the previous snapshot until fn returns.
+func (s *SyncValue) Update(fn func(v Value) Value) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.readMu.RLock()
+	v := s.load().Thaw()
+	s.readMu.RUnlock()
+
+	v = fn(v)
+	assert.State(v != nil, "update function must not return nil Value")
+
+	_ = s.swap(Freeze(v))
+}
@@ -0,0 +1,78 @@
+package jsonvalue_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestSyncValue_Zero(t *testing.T) {
+	var s jsonvalue.SyncValue
+	equal(t, s.Load().Type(), jsonvalue.TypeNull)
+}
+
+func TestSyncValue_Load(t *testing.T) {
+	v := jsonvalue.Object(jsonvalue.Props{"a": jsonvalue.Number(1)})
+	s := jsonvalue.NewSyncValue(v)
+	v.ObjectSetElm("a", jsonvalue.Number(2))
+
+	equal(t, s.Load().ObjectGetElm("a").NumberGet().String(), "1")
+}
+
+func TestSyncValue_Store(t *testing.T) {
+	s := jsonvalue.NewSyncValue(jsonvalue.Null())
+	snapshot := s.Load()
+	s.Store(jsonvalue.Number(1))
+
+	equal(t, s.Load().NumberGet().String(), "1")
+	equal(t, snapshot.Type(), jsonvalue.TypeNull)
+}
+
+func TestSyncValue_Swap(t *testing.T) {
+	s := jsonvalue.NewSyncValue(jsonvalue.String("old"))
+	old := s.Swap(jsonvalue.String("new"))
+
+	equal(t, old.StringGet(), "old")
+	equal(t, s.Load().StringGet(), "new")
+}
+
+func TestSyncValue_Update(t *testing.T) {
+	s := jsonvalue.NewSyncValue(jsonvalue.Object(jsonvalue.Props{"a": jsonvalue.Number(1)}))
+	snapshot := s.Load()
+	s.Update(func(v jsonvalue.Value) jsonvalue.Value {
+		v.ObjectSetElm("b", jsonvalue.Number(2))
+		return v
+	})
+
+	equal(t, s.Load().ObjectLen(), 2)
+	equal(t, snapshot.ObjectLen(), 1)
+}
+
+func TestSyncValue_Concurrent(t *testing.T) {
+	s := jsonvalue.NewSyncValue(jsonvalue.Object(jsonvalue.Props{"count": jsonvalue.Number(0)}))
+	n := 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.Update(func(v jsonvalue.Value) jsonvalue.Value {
+				c, _ := strconv.Atoi(v.ObjectGetElm("count").NumberGet().String())
+				v.ObjectSetElm("count", jsonvalue.Number(c+1))
+				return v
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			snapshot := s.Load()
+			_, _ = snapshot.MarshalJSON()
+			_ = snapshot.ObjectGetElm("count").NumberGet()
+		}()
+	}
+	wg.Wait()
+
+	equal(t, s.Load().ObjectGetElm("count").NumberGet().String(), strconv.Itoa(n))
+}
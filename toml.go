@@ -0,0 +1,223 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// TOMLDateTimeKind represents the kinds of date-time values of TOML.
+type TOMLDateTimeKind int
+
+const (
+	// TOMLOffsetDateTime represents a date-time with an offset such as 1979-05-27T07:32:00Z.
+	TOMLOffsetDateTime TOMLDateTimeKind = iota + 1
+	// TOMLLocalDateTime represents a date-time without an offset such as 1979-05-27T07:32:00.
+	TOMLLocalDateTime
+	// TOMLLocalDate represents a date such as 1979-05-27.
+	TOMLLocalDate
+	// TOMLLocalTime represents a time such as 07:32:00.
+	TOMLLocalTime
+)
+
+// TOMLOptions configures FromTOML.
+type TOMLOptions struct {
+	// DateTime converts a date-time value into a JSON value.
+	// The text is in the RFC 3339 form, where a date and a time are separated by 'T' and the offset Z is capitalized.
+	// If DateTime is nil, date-time values are converted into JSON strings of the text.
+	DateTime func(kind TOMLDateTimeKind, text string) (Value, error)
+}
+
+// FromTOML decodes a TOML v1.0.0 document into a JSON object.
+// Tables and inline tables are decoded into JSON objects, arrays and arrays of tables into JSON arrays,
+// integers and floats into JSON numbers, and date-time values according to TOMLOptions.DateTime.
+// Integers are not limited to 64 bits, and inf and nan are rejected because they cannot be represented in JSON.
+func FromTOML(b []byte, opts TOMLOptions) (Value, error) {
+	p, err := newTOMLParser(b, opts)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse TOML: %w`, err)
+	}
+	v, err := p.parseDocument()
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse TOML: %w`, err)
+	}
+
+	return v, nil
+}
+
+// ToTOML encodes a JSON object v into a TOML v1.0.0 document.
+// Members whose values are JSON objects are written as tables and members whose values are non-empty JSON arrays of JSON objects as arrays of tables.
+// Object members are sorted by their keys.
+// An error is returned if v is not a JSON object or contains null, which cannot be represented in TOML.
+func ToTOML(v Value) ([]byte, error) {
+	if v.Type() != TypeObject {
+		return nil, fmt.Errorf(`fail to encode TOML: JSON value must be an object but %v`, v.Type())
+	}
+
+	var b strings.Builder
+	if err := writeTOMLTable(&b, Path{}, v, "", false); err != nil {
+		return nil, fmt.Errorf(`fail to encode TOML: %w`, err)
+	}
+
+	return []byte(b.String()), nil
+}
+
+func isTOMLArrayOfTables(v Value) bool {
+	if v.Type() != TypeArray || v.ArrayLen() == 0 {
+		return false
+	}
+	for _, elm := range v.ArrayAll() {
+		if elm.Type() != TypeObject {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeTOMLTable writes a table at the Path named by a dotted key.
+// The table header is omitted for the root table and for a table having only sub-tables, and is written for an element of an array of tables if element is true.
+func writeTOMLTable(b *strings.Builder, path Path, table Value, name string, element bool) error {
+	keys := table.ObjectKeys()
+	slices.Sort(keys)
+
+	tables, arrays, pairs := []string{}, []string{}, []string{}
+	for _, key := range keys {
+		switch val := table.ObjectGetElm(key); {
+		case val.Type() == TypeObject:
+			tables = append(tables, key)
+		case isTOMLArrayOfTables(val):
+			arrays = append(arrays, key)
+		default:
+			pairs = append(pairs, key)
+		}
+	}
+
+	writeHeader := func(header string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(header + "\n")
+	}
+	switch {
+	case element:
+		writeHeader("[[" + name + "]]")
+	case name != "" && (len(pairs) > 0 || (len(tables) == 0 && len(arrays) == 0)):
+		writeHeader("[" + name + "]")
+	}
+	for _, key := range pairs {
+		b.WriteString(tomlKey(key) + " = ")
+		if err := writeTOMLValue(b, path.Append(KeyName(key)), table.ObjectGetElm(key)); err != nil {
+			return err
+		}
+		b.WriteString("\n")
+	}
+
+	prefix := ""
+	if name != "" {
+		prefix = name + "."
+	}
+	for _, key := range tables {
+		if err := writeTOMLTable(b, path.Append(KeyName(key)), table.ObjectGetElm(key), prefix+tomlKey(key), false); err != nil {
+			return err
+		}
+	}
+	for _, key := range arrays {
+		for i, elm := range table.ObjectGetElm(key).ArrayAll() {
+			if err := writeTOMLTable(b, path.Append(KeyName(key)).Append(KeyIndex(i)), elm, prefix+tomlKey(key), true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeTOMLValue writes a JSON value in the inline form.
+func writeTOMLValue(b *strings.Builder, path Path, v Value) error {
+	switch v.Type() {
+	case TypeNull:
+		return fmt.Errorf(`null at %q cannot be represented in TOML`, path.Pointer())
+	case TypeBoolean:
+		fmt.Fprint(b, v.BooleanGet())
+	case TypeNumber:
+		b.WriteString(v.NumberGet().String())
+	case TypeString:
+		b.WriteString(tomlQuote(v.StringGet()))
+	case TypeArray:
+		b.WriteString("[")
+		for i, elm := range v.ArrayAll() {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := writeTOMLValue(b, path.Append(KeyIndex(i)), elm); err != nil {
+				return err
+			}
+		}
+		b.WriteString("]")
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		b.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(" " + tomlKey(key) + " = ")
+			if err := writeTOMLValue(b, path.Append(KeyName(key)), v.ObjectGetElm(key)); err != nil {
+				return err
+			}
+		}
+		if len(keys) > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString("}")
+	}
+
+	return nil
+}
+
+var tomlBareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if tomlBareKeyPattern.MatchString(key) {
+		return key
+	}
+
+	return tomlQuote(key)
+}
+
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f || !unicode.IsPrint(r) && r <= 0xffff:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(&b, `\U%08X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
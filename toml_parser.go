@@ -0,0 +1,661 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tomlKind records how a table or a value at a Path was created in a TOML document.
+type tomlKind int
+
+const (
+	// tomlImplicit is a table created as a parent of a table header.
+	tomlImplicit tomlKind = iota + 1
+	// tomlHeader is a table defined by a table header.
+	tomlHeader
+	// tomlDotted is a table created by a dotted key.
+	tomlDotted
+	// tomlArrayOfTables is an array defined by array of tables headers.
+	tomlArrayOfTables
+	// tomlValue is a value assigned by a key/value pair, which cannot be extended.
+	tomlValue
+)
+
+// tomlParser is a recursive descent parser of TOML v1.0.0 documents.
+type tomlParser struct {
+	src  []rune
+	pos  int
+	line int
+	opts TOMLOptions
+
+	root Value
+	// current is the Path of the table into which key/value pairs are put.
+	current Path
+	kinds   map[string]tomlKind
+}
+
+func newTOMLParser(b []byte, opts TOMLOptions) (*tomlParser, error) {
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf(`input is not valid UTF-8`)
+	}
+	s := strings.ReplaceAll(string(b), "\r\n", "\n")
+
+	return &tomlParser{src: []rune(s), opts: opts, root: Object(), current: Path{}, kinds: map[string]tomlKind{}}, nil
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peekAt(offset int) rune {
+	if i := p.pos + offset; i < len(p.src) {
+		return p.src[i]
+	}
+
+	return 0
+}
+
+func (p *tomlParser) peek() rune {
+	return p.peekAt(0)
+}
+
+func (p *tomlParser) advance() {
+	if p.src[p.pos] == '\n' {
+		p.line++
+	}
+	p.pos++
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	for i, r := range []rune(s) {
+		if p.peekAt(i) != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf(`line %d: %w`, p.line+1, fmt.Errorf(format, args...))
+}
+
+func (p *tomlParser) skipBlanks() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.advance()
+	}
+}
+
+// skipLineEnd skips blanks and a comment and consumes the line break.
+func (p *tomlParser) skipLineEnd() error {
+	p.skipBlanks()
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			if r := p.peek(); r < 0x20 && r != '\t' || r == 0x7f {
+				return p.errorf(`control character %U in comment`, r)
+			}
+			p.advance()
+		}
+	}
+	switch {
+	case p.eof():
+		return nil
+	case p.peek() == '\n':
+		p.advance()
+		return nil
+	default:
+		return p.errorf(`unexpected character %q`, p.peek())
+	}
+}
+
+// skipArraySpace skips blanks, comments and line breaks in an array.
+func (p *tomlParser) skipArraySpace() error {
+	for {
+		p.skipBlanks()
+		switch p.peek() {
+		case '#', '\n':
+			if err := p.skipLineEnd(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *tomlParser) parseDocument() (Value, error) {
+	for {
+		p.skipBlanks()
+		switch {
+		case p.eof():
+			return p.root, nil
+		case p.peek() == '#' || p.peek() == '\n':
+		case p.hasPrefix("[["):
+			if err := p.parseArrayOfTablesHeader(); err != nil {
+				return nil, err
+			}
+		case p.peek() == '[':
+			if err := p.parseTableHeader(); err != nil {
+				return nil, err
+			}
+		default:
+			if err := p.parseKeyValue(p.current); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.skipLineEnd(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *tomlParser) kindOf(path Path) tomlKind {
+	return p.kinds[path.Pointer()]
+}
+
+// descend returns the Path of the child table of the table at parent with the key, following the last element of an array of tables.
+// If the child does not exist, it is created as a table of the kind.
+func (p *tomlParser) descend(parent Path, key string, kind tomlKind) (Path, error) {
+	table, _ := Find(p.root, parent)
+	path := parent.Append(KeyName(key))
+	if !table.ObjectHasElm(key) {
+		table.ObjectSetElm(key, Object())
+		p.kinds[path.Pointer()] = kind
+		return path, nil
+	}
+
+	child := table.ObjectGetElm(key)
+	switch k := p.kindOf(path); {
+	case k == tomlArrayOfTables && kind != tomlDotted:
+		return path.Append(KeyIndex(child.ArrayLen() - 1)), nil
+	case k == tomlValue || k == tomlArrayOfTables || child.Type() != TypeObject:
+		return nil, p.errorf(`key %q is already defined as a value`, path.Pointer())
+	case kind == tomlDotted && k != tomlDotted && k != tomlImplicit:
+		return nil, p.errorf(`table %q cannot be extended by a dotted key`, path.Pointer())
+	default:
+		return path, nil
+	}
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	p.advance()
+	p.skipBlanks()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != ']' {
+		return p.errorf(`']' expected after table header`)
+	}
+	p.advance()
+
+	path := Path{}
+	for _, key := range keys[:len(keys)-1] {
+		if path, err = p.descend(path, key, tomlImplicit); err != nil {
+			return err
+		}
+	}
+	key := keys[len(keys)-1]
+	table, _ := Find(p.root, path)
+	path = path.Append(KeyName(key))
+	switch p.kindOf(path) {
+	case 0:
+		table.ObjectSetElm(key, Object())
+	case tomlImplicit:
+	default:
+		return p.errorf(`table %q is already defined`, path.Pointer())
+	}
+	p.kinds[path.Pointer()] = tomlHeader
+	p.current = path
+
+	return nil
+}
+
+func (p *tomlParser) parseArrayOfTablesHeader() error {
+	p.advance()
+	p.advance()
+	p.skipBlanks()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if !p.hasPrefix("]]") {
+		return p.errorf(`']]' expected after array of tables header`)
+	}
+	p.advance()
+	p.advance()
+
+	path := Path{}
+	for _, key := range keys[:len(keys)-1] {
+		if path, err = p.descend(path, key, tomlImplicit); err != nil {
+			return err
+		}
+	}
+	key := keys[len(keys)-1]
+	table, _ := Find(p.root, path)
+	path = path.Append(KeyName(key))
+	switch p.kindOf(path) {
+	case 0:
+		table.ObjectSetElm(key, Array())
+		p.kinds[path.Pointer()] = tomlArrayOfTables
+	case tomlArrayOfTables:
+	default:
+		return p.errorf(`key %q is already defined`, path.Pointer())
+	}
+	arr := table.ObjectGetElm(key)
+	arr.ArrayAddElm(Object())
+	p.current = path.Append(KeyIndex(arr.ArrayLen() - 1))
+
+	return nil
+}
+
+// parseKeyValue parses a key/value pair and puts it into the table at the Path.
+func (p *tomlParser) parseKeyValue(table Path) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf(`'=' expected after key`)
+	}
+	p.advance()
+	p.skipBlanks()
+
+	path := table
+	for _, key := range keys[:len(keys)-1] {
+		if path, err = p.descend(path, key, tomlDotted); err != nil {
+			return err
+		}
+	}
+	key := keys[len(keys)-1]
+	parent, _ := Find(p.root, path)
+	path = path.Append(KeyName(key))
+	if parent.ObjectHasElm(key) {
+		return p.errorf(`key %q is already defined`, path.Pointer())
+	}
+
+	val, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	parent.ObjectSetElm(key, val)
+	p.kinds[path.Pointer()] = tomlValue
+
+	return nil
+}
+
+// parseKey parses a simple or dotted key followed by blanks.
+func (p *tomlParser) parseKey() ([]string, error) {
+	keys := []string{}
+	for {
+		var key string
+		var err error
+		switch r := p.peek(); {
+		case r == '"':
+			key, err = p.parseBasicString()
+		case r == '\'':
+			key, err = p.parseLiteralString()
+		case isTOMLBareKeyChar(r):
+			var b strings.Builder
+			for isTOMLBareKeyChar(p.peek()) {
+				b.WriteRune(p.peek())
+				p.advance()
+			}
+			key = b.String()
+		default:
+			err = p.errorf(`key expected but got %q`, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipBlanks()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.advance()
+		p.skipBlanks()
+	}
+}
+
+func isTOMLBareKeyChar(r rune) bool {
+	return 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '_' || r == '-'
+}
+
+func (p *tomlParser) parseValue() (Value, error) {
+	switch r := p.peek(); {
+	case p.hasPrefix(`"""`):
+		s, err := p.parseMultilineBasicString()
+		return String(s), err
+	case r == '"':
+		s, err := p.parseBasicString()
+		return String(s), err
+	case p.hasPrefix(`'''`):
+		s, err := p.parseMultilineLiteralString()
+		return String(s), err
+	case r == '\'':
+		s, err := p.parseLiteralString()
+		return String(s), err
+	case r == '[':
+		return p.parseArray()
+	case r == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true") && !isTOMLBareKeyChar(p.peekAt(4)):
+		p.pos += 4
+		return Boolean(true), nil
+	case p.hasPrefix("false") && !isTOMLBareKeyChar(p.peekAt(5)):
+		p.pos += 5
+		return Boolean(false), nil
+	case r == '+' || r == '-' || r == 'i' || r == 'n' || ('0' <= r && r <= '9'):
+		return p.parseNumberOrDateTime()
+	case p.eof() || r == '\n':
+		return nil, p.errorf(`value expected`)
+	default:
+		return nil, p.errorf(`unexpected character %q`, r)
+	}
+}
+
+func (p *tomlParser) parseArray() (Value, error) {
+	p.advance()
+	arr := Array()
+	for {
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.advance()
+			return arr, nil
+		}
+		if p.eof() {
+			return nil, p.errorf(`unterminated array`)
+		}
+
+		elm, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr.ArrayAddElm(elm)
+
+		if err := p.skipArraySpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.advance()
+		case ']':
+		default:
+			return nil, p.errorf(`',' or ']' expected in array`)
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (Value, error) {
+	p.advance()
+	p.skipBlanks()
+	table := Object()
+	if p.peek() == '}' {
+		p.advance()
+		return table, nil
+	}
+
+	// The key/value pairs are parsed in a separate document rooted at the inline table.
+	inner := &tomlParser{src: p.src, pos: p.pos, line: p.line, opts: p.opts, root: table, current: Path{}, kinds: map[string]tomlKind{}}
+	for {
+		if err := inner.parseKeyValue(Path{}); err != nil {
+			p.pos, p.line = inner.pos, inner.line
+			return nil, err
+		}
+		inner.skipBlanks()
+		switch inner.peek() {
+		case ',':
+			inner.advance()
+			inner.skipBlanks()
+		case '}':
+			inner.advance()
+			p.pos, p.line = inner.pos, inner.line
+			return table, nil
+		default:
+			return nil, inner.errorf(`',' or '}' expected in inline table`)
+		}
+	}
+}
+
+var tomlEscapes = map[rune]string{'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", '"': "\"", '\\': "\\"}
+
+func (p *tomlParser) parseEscape() (string, error) {
+	p.advance()
+	r := p.peek()
+	if s, ok := tomlEscapes[r]; ok {
+		p.advance()
+		return s, nil
+	}
+
+	digits := map[rune]int{'u': 4, 'U': 8}[r]
+	if digits == 0 {
+		return "", p.errorf(`invalid escape sequence \%c`, r)
+	}
+	p.advance()
+	var b strings.Builder
+	for i := 0; i < digits && !p.eof(); i++ {
+		b.WriteRune(p.peek())
+		p.advance()
+	}
+	code, err := strconv.ParseUint(b.String(), 16, 32)
+	if err != nil || len(b.String()) != digits || !utf8.ValidRune(rune(code)) {
+		return "", p.errorf(`invalid unicode escape \%c%s`, r, b.String())
+	}
+
+	return string(rune(code)), nil
+}
+
+func (p *tomlParser) checkStringChar(r rune) error {
+	if (r < 0x20 && r != '\t') || r == 0x7f {
+		return p.errorf(`control character %U in string`, r)
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.advance()
+	var b strings.Builder
+	for {
+		switch r := p.peek(); {
+		case p.eof() || r == '\n':
+			return "", p.errorf(`unterminated string`)
+		case r == '"':
+			p.advance()
+			return b.String(), nil
+		case r == '\\':
+			s, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		default:
+			if err := p.checkStringChar(r); err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	if p.peek() == '\n' {
+		p.advance()
+	}
+	var b strings.Builder
+	for {
+		switch r := p.peek(); {
+		case p.eof():
+			return "", p.errorf(`unterminated multi-line string`)
+		case p.hasPrefix(`"""`):
+			// Up to two quotes are allowed just before the closing delimiter.
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '"'; i++ {
+				b.WriteRune('"')
+				p.advance()
+			}
+			return b.String(), nil
+		case r == '\\':
+			// A line ending backslash trims the following whitespace and line breaks.
+			i := 1
+			for p.peekAt(i) == ' ' || p.peekAt(i) == '\t' {
+				i++
+			}
+			if p.peekAt(i) != '\n' {
+				s, err := p.parseEscape()
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(s)
+				continue
+			}
+			p.advance()
+			for r := p.peek(); r == ' ' || r == '\t' || r == '\n'; r = p.peek() {
+				p.advance()
+			}
+		case r == '\n':
+			b.WriteRune(r)
+			p.advance()
+		default:
+			if err := p.checkStringChar(r); err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.advance()
+	var b strings.Builder
+	for {
+		switch r := p.peek(); {
+		case p.eof() || r == '\n':
+			return "", p.errorf(`unterminated string`)
+		case r == '\'':
+			p.advance()
+			return b.String(), nil
+		default:
+			if err := p.checkStringChar(r); err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	if p.peek() == '\n' {
+		p.advance()
+	}
+	var b strings.Builder
+	for {
+		switch r := p.peek(); {
+		case p.eof():
+			return "", p.errorf(`unterminated multi-line string`)
+		case p.hasPrefix(`'''`):
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '\''; i++ {
+				b.WriteRune('\'')
+				p.advance()
+			}
+			return b.String(), nil
+		case r == '\n':
+			b.WriteRune(r)
+			p.advance()
+		default:
+			if err := p.checkStringChar(r); err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+var (
+	tomlDecimalPattern  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	tomlHexPattern      = regexp.MustCompile(`^0x[0-9a-fA-F](_?[0-9a-fA-F])*$`)
+	tomlOctPattern      = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	tomlBinPattern      = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	tomlFloatPattern    = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)((\.[0-9](_?[0-9])*)([eE][+-]?[0-9](_?[0-9])*)?|[eE][+-]?[0-9](_?[0-9])*)$`)
+	tomlSpecialPattern  = regexp.MustCompile(`^[+-]?(inf|nan)$`)
+	tomlDatePattern     = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}`)
+	tomlTimePattern     = regexp.MustCompile(`^[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?`)
+	tomlDateTimePattern = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2})[Tt ]([0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?)([Zz]|[+-][0-9]{2}:[0-9]{2})?$`)
+)
+
+func (p *tomlParser) parseNumberOrDateTime() (Value, error) {
+	start := p.pos
+	for r := p.peek(); !p.eof() && r != ' ' && r != '\t' && r != '\n' && r != ',' && r != ']' && r != '}' && r != '#'; r = p.peek() {
+		p.advance()
+	}
+	// A date and a time may be separated by a space.
+	if p.peek() == ' ' && tomlDatePattern.MatchString(string(p.src[start:p.pos])) && p.pos-start == 10 {
+		if end := p.pos + 1; end+2 <= len(p.src) && '0' <= p.src[end] && p.src[end] <= '9' {
+			p.advance()
+			for r := p.peek(); !p.eof() && r != ' ' && r != '\t' && r != '\n' && r != ',' && r != ']' && r != '}' && r != '#'; r = p.peek() {
+				p.advance()
+			}
+		}
+	}
+	s := string(p.src[start:p.pos])
+
+	switch {
+	case tomlDecimalPattern.MatchString(s):
+		n, _ := new(big.Int).SetString(strings.ReplaceAll(strings.TrimPrefix(s, "+"), "_", ""), 10)
+		return Number(json.Number(n.String())), nil
+	case tomlHexPattern.MatchString(s), tomlOctPattern.MatchString(s), tomlBinPattern.MatchString(s):
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[s[1]]
+		n, _ := new(big.Int).SetString(strings.ReplaceAll(s[2:], "_", ""), base)
+		return Number(json.Number(n.String())), nil
+	case tomlFloatPattern.MatchString(s):
+		return Number(json.Number(strings.ReplaceAll(strings.TrimPrefix(s, "+"), "_", ""))), nil
+	case tomlSpecialPattern.MatchString(s):
+		return nil, p.errorf(`%s cannot be represented in JSON`, s)
+	}
+
+	kind, layout := TOMLDateTimeKind(0), ""
+	switch m := tomlDateTimePattern.FindStringSubmatch(s); {
+	case m != nil && m[4] != "":
+		kind, layout = TOMLOffsetDateTime, "2006-01-02T15:04:05Z07:00"
+		s = m[1] + "T" + m[2] + strings.ToUpper(m[4])
+	case m != nil:
+		kind, layout = TOMLLocalDateTime, "2006-01-02T15:04:05"
+		s = m[1] + "T" + m[2]
+	case tomlDatePattern.MatchString(s) && len(s) == 10:
+		kind, layout = TOMLLocalDate, "2006-01-02"
+	case tomlTimePattern.FindString(s) == s:
+		kind, layout = TOMLLocalTime, "15:04:05"
+	default:
+		return nil, p.errorf(`invalid value %q`, s)
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return nil, p.errorf(`invalid date-time %q: %v`, s, err)
+	}
+
+	if p.opts.DateTime == nil {
+		return String(s), nil
+	}
+	v, err := p.opts.DateTime(kind, s)
+	if err != nil {
+		return nil, p.errorf(`fail to convert date-time %q: %w`, s, err)
+	}
+
+	return v, nil
+}
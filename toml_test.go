@@ -0,0 +1,116 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestFromTOML(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: "", want: `{}`},
+		{name: "key values", in: "a = 1 # comment\nb = \"x\"\n\"c d\" = true\n'e' = false\n", want: `{"a":1,"b":"x","c d":true,"e":false}`},
+		{name: "dotted keys", in: "a.b = 1\na . c = 2\n\"x.y\".z = 3\n", want: `{"a":{"b":1,"c":2},"x.y":{"z":3}}`},
+		{name: "integers", in: "a = +1_000\nb = -0\nc = 0xDEAD_beef\nd = 0o755\ne = 0b1101\nf = 123456789012345678901234567890\n", want: `{"a":1000,"b":0,"c":3735928559,"d":493,"e":13,"f":123456789012345678901234567890}`},
+		{name: "floats", in: "a = +1.0\nb = -3.1415\nc = 5e+22\nd = 6.626e-34\ne = 224_617.445_991\n", want: `{"a":1.0,"b":-3.1415,"c":5e+22,"d":6.626e-34,"e":224617.445991}`},
+		{name: "strings", in: `a = "tab\there \"q\" \u00e9 \U0001F600"` + "\n" + `b = 'C:\path'` + "\n", want: `{"a":"tab\there \"q\" é 😀","b":"C:\\path"}`},
+		{name: "multi-line basic string", in: "a = \"\"\"\nRoses\n  are \\\n    red\"\"\"\"\n", want: `{"a":"Roses\n  are red\""}`},
+		{name: "multi-line literal string", in: "a = '''\nline1\\n\n  line2'''\n", want: `{"a":"line1\\n\n  line2"}`},
+		{name: "arrays", in: "a = [1, \"x\", [2, 3], {b = 4},]\nc = [\n  1, # one\n  2,\n]\n", want: `{"a":[1,"x",[2,3],{"b":4}],"c":[1,2]}`},
+		{name: "inline tables", in: "a = { b = 1, c.d = \"x\" }\ne = {}\n", want: `{"a":{"b":1,"c":{"d":"x"}},"e":{}}`},
+		{name: "tables", in: "x = 0\n[a]\nb = 1\n[a.c]\nd = 2\n[e.f]\n[e]\ng = 3\n", want: `{"x":0,"a":{"b":1,"c":{"d":2}},"e":{"f":{},"g":3}}`},
+		{name: "arrays of tables", in: "[[p]]\nn = 1\n[p.q]\nr = 2\n[[p]]\nn = 3\n[[p.s]]\nt = 4\n", want: `{"p":[{"n":1,"q":{"r":2}},{"n":3,"s":[{"t":4}]}]}`},
+		{name: "sub-table after dotted keys", in: "[f]\na.b = 1\n[f.a.c]\nd = 2\n", want: `{"f":{"a":{"b":1,"c":{"d":2}}}}`},
+		{name: "datetimes", in: "a = 1979-05-27T07:32:00Z\nb = 1979-05-27 07:32:00.999-07:00\nc = 1979-05-27t07:32:00\nd = 1979-05-27\ne = 07:32:00.5\n", want: `{"a":"1979-05-27T07:32:00Z","b":"1979-05-27T07:32:00.999-07:00","c":"1979-05-27T07:32:00","d":"1979-05-27","e":"07:32:00.5"}`},
+		{name: "crlf", in: "a = 1\r\nb = 2\r\n", want: `{"a":1,"b":2}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.FromTOML([]byte(testCase.in), jsonvalue.TOMLOptions{})
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestFromTOML_DateTime(t *testing.T) {
+	opts := jsonvalue.TOMLOptions{
+		DateTime: func(kind jsonvalue.TOMLDateTimeKind, text string) (jsonvalue.Value, error) {
+			return jsonvalue.Object(jsonvalue.Props{"kind": jsonvalue.Number(int(kind)), "text": jsonvalue.String(text)}), nil
+		},
+	}
+	got, err := jsonvalue.FromTOML([]byte("a = 1979-05-27 07:32:00z\nb = [1979-05-27T07:32:00, 1979-05-27, 07:32:00]\n"), opts)
+	equal(t, err, nil)
+	want := `{"a":{"kind":1,"text":"1979-05-27T07:32:00Z"},"b":[{"kind":2,"text":"1979-05-27T07:32:00"},{"kind":3,"text":"1979-05-27"},{"kind":4,"text":"07:32:00"}]}`
+	equal(t, jsonvalue.Equal(got, mustUnmarshal(t, want)), true)
+}
+
+func TestFromTOML_Error(t *testing.T) {
+	testCases := map[string]string{
+		"duplicate key":             "a = 1\na = 2\n",
+		"duplicate table":           "[a]\n[a]\n",
+		"table redefines value":     "a = 1\n[a]\n",
+		"dotted key extends header": "[a.b]\nx = 1\n[a]\nb.y = 2\n",
+		"header extends dotted key": "[f]\na.b = 1\n[f.a]\n",
+		"extend inline table":       "a = {b = 1}\n[a.c]\n",
+		"extend static array":       "a = []\n[[a]]\n",
+		"inf":                       "a = inf\n",
+		"nan":                       "a = -nan\n",
+		"leading zero":              "a = 01\n",
+		"bad underscore":            "a = 1__0\n",
+		"invalid date":              "a = 1979-13-27\n",
+		"missing value":             "a =\n",
+		"unterminated string":       "a = \"x\n",
+		"bad escape":                `a = "\q"`,
+		"control character":         "a = \"\x01\"\n",
+		"two pairs on a line":       "a = 1 b = 2\n",
+		"multi-line inline table":   "a = {\nb = 1}\n",
+		"invalid utf8":              "a = \"\xff\"\n",
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.FromTOML([]byte(in), jsonvalue.TOMLOptions{})
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestToTOML(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: `{}`, want: ``},
+		{name: "pairs", in: `{"b":"x\n\"y\"","a":1.5,"c d":[true,[1],{"e":"f"}],"g":{}}`, want: "a = 1.5\nb = \"x\\n\\\"y\\\"\"\n\"c d\" = [true, [1], { e = \"f\" }]\n\n[g]\n"},
+		{name: "tables", in: `{"a":{"b":{"c":1},"d":2},"e":{"f":{"g":3}}}`, want: "[a]\nd = 2\n\n[a.b]\nc = 1\n\n[e.f]\ng = 3\n"},
+		{name: "arrays of tables", in: `{"p":[{"n":1,"q":{"r":2}},{}],"x":0}`, want: "x = 0\n\n[[p]]\nn = 1\n\n[p.q]\nr = 2\n\n[[p]]\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			v := mustUnmarshal(t, testCase.in)
+			got, err := jsonvalue.ToTOML(v)
+			equal(t, err, nil)
+			equal(t, string(got), testCase.want)
+
+			decoded, err := jsonvalue.FromTOML(got, jsonvalue.TOMLOptions{})
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(decoded, v), true)
+		})
+	}
+}
+
+func TestToTOML_Error(t *testing.T) {
+	for _, in := range []string{`[]`, `{"a":null}`, `{"a":[1,null]}`} {
+		t.Run(in, func(t *testing.T) {
+			_, err := jsonvalue.ToTOML(mustUnmarshal(t, in))
+			IsNotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package jsonvalue
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// Transaction calls fn with tx through which a JSON value root is modified, and reverts the modifications unless fn returns nil.
+// The modifications through tx are applied to root immediately, while only the changed parts are recorded to revert them,
+// so the cost does not depend on the size of root.
+// Transaction is not isolated: the intermediate states of root are visible to the other readers of root while fn runs,
+// and the modifications are reverted on a best-effort basis after fn fails.
+// If fn returns an error or panics, root is restored to the state before fn is called and the error or the panic is propagated.
+// If the restoration fails, the error is joined with the error returned by fn.
+// root must not be modified except through tx and tx must not be used after fn returns.
+func Transaction(root Value, fn func(tx Value) error) (err error) {
+	assert.Params(root != nil, "Value must not be nil")
+
+	h := newHistory(newObservable(root))
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if rollbackErr := h.UndoPatch().Apply(root); rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf(`fail to rollback transaction: %w`, rollbackErr))
+		}
+	}()
+
+	if err := fn(h.Value()); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package jsonvalue_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestTransaction(t *testing.T) {
+	original := `{"a":{"x":1},"b":[1,2,3],"c":"abc"}`
+	edit := func(tx jsonvalue.Value) {
+		tx.ObjectGetElm("a").ObjectSetElm("x", jsonvalue.Number(2))
+		tx.ObjectGetElm("a").ObjectSetElm("y", jsonvalue.Null())
+		tx.ObjectGetElm("b").ArraySetElm(0, jsonvalue.String("x"))
+		tx.ObjectGetElm("b").ArrayAddElm(jsonvalue.Number(4))
+		tx.ObjectDelElm("c")
+		tx.ObjectGetElm("a").ObjectGetElm("y").Assign(jsonvalue.Array())
+	}
+	t.Run("commit", func(t *testing.T) {
+		root := mustUnmarshal(t, original)
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			edit(tx)
+			return nil
+		})
+		equal(t, err, nil)
+		equal(t, mustMarshal(t, root), `{"a":{"x":2,"y":[]},"b":["x",2,3,4]}`)
+	})
+	t.Run("rollback", func(t *testing.T) {
+		root := mustUnmarshal(t, original)
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			edit(tx)
+			return fmt.Errorf("error")
+		})
+		IsNotNil(t, err)
+		equal(t, jsonvalue.Equal(root, mustUnmarshal(t, original)), true)
+	})
+	t.Run("rollback assign root", func(t *testing.T) {
+		root := mustUnmarshal(t, original)
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			tx.Assign(jsonvalue.Null())
+			return fmt.Errorf("error")
+		})
+		IsNotNil(t, err)
+		equal(t, jsonvalue.Equal(root, mustUnmarshal(t, original)), true)
+	})
+	t.Run("rollback on panic", func(t *testing.T) {
+		root := mustUnmarshal(t, original)
+		func() {
+			defer func() {
+				equal(t, recover(), any("panic"))
+			}()
+			_ = jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+				edit(tx)
+				panic("panic")
+			})
+		}()
+		equal(t, jsonvalue.Equal(root, mustUnmarshal(t, original)), true)
+	})
+	t.Run("observable root", func(t *testing.T) {
+		root := jsonvalue.Observe(mustUnmarshal(t, original))
+		count := 0
+		root.Subscribe(jsonvalue.Path{}, func(e jsonvalue.ChangeEvent) {
+			count++
+		})
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			tx.ObjectSetElm("c", jsonvalue.Null())
+			return fmt.Errorf("error")
+		})
+		IsNotNil(t, err)
+		equal(t, count, 2)
+		equal(t, root.ObjectGetElm("c").StringGet(), "abc")
+	})
+	t.Run("rollback child across shift", func(t *testing.T) {
+		original := `{"b":[{"x":1},{"x":2},{"x":3}]}`
+		root := mustUnmarshal(t, original)
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			b := tx.ObjectGetElm("b")
+			c := b.ArrayGetElm(1)
+			b.ArrayDelElm(0)
+			c.ObjectSetElm("x", jsonvalue.Number(99))
+			return fmt.Errorf("error")
+		})
+		equal(t, err.Error(), "error")
+		equal(t, jsonvalue.Equal(root, mustUnmarshal(t, original)), true)
+	})
+	t.Run("rollback failure", func(t *testing.T) {
+		root := mustUnmarshal(t, original)
+		fnErr := fmt.Errorf("error")
+		err := jsonvalue.Transaction(root, func(tx jsonvalue.Value) error {
+			tx.ObjectSetElm("d", jsonvalue.Null())
+			root.ObjectDelElm("d")
+			return fnErr
+		})
+		equal(t, errors.Is(err, fnErr), true)
+		equal(t, err != fnErr, true)
+	})
+}
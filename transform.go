@@ -0,0 +1,70 @@
+package jsonvalue
+
+import (
+	"errors"
+
+	"github.com/Jumpaku/go-assert"
+)
+
+// DeleteValue is used as a return value from the function passed to Transform to indicate that the JSON value is removed from its parent.
+// It is not returned as an error by Transform.
+var DeleteValue = errors.New("delete value")
+
+// Transform rebuilds a JSON value v bottom-up by calling fn for each the JSON values included in v.
+// fn is called for a JSON object or array after it has been called for all the elements, and receives the rebuilt JSON value whose elements are the results of fn.
+// The JSON value returned by fn replaces the JSON value at the Path, and an object member or an array element is removed if fn returns DeleteValue.
+// Members of an object can be renamed by returning a new object from fn for the object.
+// If fn returns DeleteValue for the root, Transform returns Null().
+// If a call of fn returned an error other than DeleteValue, Transform immediately returns with the error.
+// v is not modified.
+func Transform(v Value, fn func(path Path, val Value) (Value, error)) (Value, error) {
+	t, err := transformImpl(Path{}, v, fn)
+	if errors.Is(err, DeleteValue) {
+		return Null(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func transformImpl(path Path, val Value, fn func(path Path, val Value) (Value, error)) (Value, error) {
+	var rebuilt Value
+	switch val.Type() {
+	case TypeObject:
+		rebuilt = Object()
+		for _, key := range val.ObjectKeys() {
+			t, err := transformImpl(path.Append(KeyName(key)), val.ObjectGetElm(key), fn)
+			if errors.Is(err, DeleteValue) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rebuilt.ObjectSetElm(key, t)
+		}
+	case TypeArray:
+		rebuilt = Array()
+		for i := 0; i < val.ArrayLen(); i++ {
+			t, err := transformImpl(path.Append(KeyIndex(i)), val.ArrayGetElm(i), fn)
+			if errors.Is(err, DeleteValue) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rebuilt.ArrayAddElm(t)
+		}
+	default:
+		rebuilt = val.Clone()
+	}
+
+	t, err := fn(path, rebuilt)
+	if err != nil {
+		return nil, err
+	}
+	assert.State(t != nil, "transform function must not return nil Value")
+
+	return t, nil
+}
@@ -0,0 +1,100 @@
+package jsonvalue_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestTransform(t *testing.T) {
+	newExample := func() jsonvalue.Value {
+		return jsonvalue.Object(jsonvalue.Props{
+			"a": jsonvalue.Null(),
+			"b": jsonvalue.String("123"),
+			"c": jsonvalue.Array(
+				jsonvalue.Null(),
+				jsonvalue.String("456"),
+				jsonvalue.Object(jsonvalue.Props{
+					"password": jsonvalue.String("secret"),
+				}),
+			),
+		})
+	}
+	t.Run(`identity`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			return val, nil
+		})
+		equal(t, err, nil)
+		b0, _ := v.MarshalJSON()
+		b1, _ := a.MarshalJSON()
+		equal(t, string(b1), string(b0))
+	})
+	t.Run(`strip nulls`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			if val.Type() == jsonvalue.TypeNull {
+				return nil, jsonvalue.DeleteValue
+			}
+			return val, nil
+		})
+		equal(t, err, nil)
+		equal(t, a.ObjectHasElm("a"), false)
+		equal(t, a.ObjectGetElm("c").ArrayLen(), 2)
+		equal(t, a.ObjectGetElm("c").ArrayGetElm(0).StringGet(), "456")
+	})
+	t.Run(`convert numeric strings`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			if val.Type() == jsonvalue.TypeString && val.StringGet() != "secret" {
+				return jsonvalue.Number(json.Number(val.StringGet())), nil
+			}
+			return val, nil
+		})
+		equal(t, err, nil)
+		equal(t, a.ObjectGetElm("b").NumberGet().String(), "123")
+		equal(t, a.ObjectGetElm("c").ArrayGetElm(1).NumberGet().String(), "456")
+	})
+	t.Run(`redact`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			if path.Equals(jsonvalue.Path{jsonvalue.KeyName("c"), jsonvalue.KeyIndex(2), jsonvalue.KeyName("password")}) {
+				return jsonvalue.String("***"), nil
+			}
+			return val, nil
+		})
+		equal(t, err, nil)
+		equal(t, a.ObjectGetElm("c").ArrayGetElm(2).ObjectGetElm("password").StringGet(), "***")
+		equal(t, v.ObjectGetElm("c").ArrayGetElm(2).ObjectGetElm("password").StringGet(), "secret")
+	})
+	t.Run(`rename`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			if path.Len() == 0 {
+				val.ObjectSetElm("x", val.ObjectGetElm("b"))
+				val.ObjectDelElm("b")
+			}
+			return val, nil
+		})
+		equal(t, err, nil)
+		equal(t, a.ObjectHasElm("b"), false)
+		equal(t, a.ObjectGetElm("x").StringGet(), "123")
+	})
+	t.Run(`delete root`, func(t *testing.T) {
+		v := newExample()
+		a, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			return nil, jsonvalue.DeleteValue
+		})
+		equal(t, err, nil)
+		equal(t, a.Type(), jsonvalue.TypeNull)
+	})
+	t.Run(`error`, func(t *testing.T) {
+		v := newExample()
+		_, err := jsonvalue.Transform(v, func(path jsonvalue.Path, val jsonvalue.Value) (jsonvalue.Value, error) {
+			return nil, fmt.Errorf("")
+		})
+		IsNotNil(t, err)
+	})
+}
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"iter"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
 )
 
 // Value models a JSON-structured data.
@@ -47,6 +51,22 @@ type Value interface {
 	ArrayLen() int
 	// ArraySlice returns a sliced JSON array.
 	ArraySlice(begin int, endExclusive int) Value
+	// ArrayInsertElm inserts JSON values at the index, shifting the following elements.
+	ArrayInsertElm(index int, vs ...Value)
+	// ArrayDelElm deletes a JSON value at the index, shifting the following elements.
+	ArrayDelElm(index int)
+	// ArraySplice replaces the elements from begin to endExclusive with JSON values and returns the replaced elements as a JSON array.
+	ArraySplice(begin int, endExclusive int, vs ...Value) Value
+	// ArrayPrepend adds JSON values to the front.
+	ArrayPrepend(vs ...Value)
+	// ArraySort sorts the elements in the order determined by cmp, which returns a negative number if a < b, a positive number if a > b and zero otherwise.
+	ArraySort(cmp func(a, b Value) int)
+	// ArrayStableSort sorts the elements in the order determined by cmp, keeping the original order of equal elements.
+	ArrayStableSort(cmp func(a, b Value) int)
+	// ArrayAll returns an iterator over the indices and the elements in order.
+	ArrayAll() iter.Seq2[int, Value]
+	// ObjectAll returns an iterator over the keys and the associated JSON values in unspecified order without allocating a slice of the keys.
+	ObjectAll() iter.Seq2[string, Value]
 }
 
 // Props representing properties of JSON object.
@@ -222,6 +242,7 @@ func (v *value) Assign(other Value) {
 		v.numberVal = other.NumberGet()
 	case TypeString:
 		v.stringVal = other.StringGet()
+	case TypeNull:
 	}
 }
 
@@ -279,6 +300,17 @@ func (v *value) ObjectKeys() []string {
 
 	return keys
 }
+func (v *value) ObjectAll() iter.Seq2[string, Value] {
+	assert.Params(v.Type() == TypeObject, "Value must be JSON object")
+
+	return func(yield func(string, Value) bool) {
+		for key, val := range v.objectVal {
+			if !yield(key, val) {
+				return
+			}
+		}
+	}
+}
 func (v *value) ObjectHasElm(key string) bool {
 	assert.Params(v.Type() == TypeObject, "Value must be JSON object")
 
@@ -314,6 +346,17 @@ func (v *value) ArrayGetElm(index int) Value {
 
 	return v.arrayVal[index]
 }
+func (v *value) ArrayAll() iter.Seq2[int, Value] {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	return func(yield func(int, Value) bool) {
+		for i := 0; i < len(v.arrayVal); i++ {
+			if !yield(i, v.arrayVal[i]) {
+				return
+			}
+		}
+	}
+}
 func (v *value) ArraySetElm(index int, val Value) {
 	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
 	assert.Params(0 <= index && index < v.ArrayLen(), "index must be in [0, %d)", v.ArrayLen())
@@ -338,3 +381,264 @@ func (v *value) ArraySlice(begin int, endExclusive int) Value {
 
 	return Array(v.arrayVal[begin:endExclusive]...)
 }
+func (v *value) ArrayInsertElm(index int, vals ...Value) {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+	assert.Params(0 <= index && index <= v.ArrayLen(), "index must be in [0, %d]", v.ArrayLen())
+	for _, val := range vals {
+		assert.Params(val != nil, "Value must not be nil")
+	}
+
+	v.arrayVal = slices.Insert(v.arrayVal, index, vals...)
+}
+func (v *value) ArrayDelElm(index int) {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+	assert.Params(0 <= index && index < v.ArrayLen(), "index must be in [0, %d)", v.ArrayLen())
+
+	v.arrayVal = slices.Delete(v.arrayVal, index, index+1)
+}
+func (v *value) ArraySplice(begin int, endExclusive int, vals ...Value) Value {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+	assert.Params(0 <= begin && begin <= v.ArrayLen(), "begin %v must be in [0, %d]", begin, v.ArrayLen())
+	assert.Params(0 <= endExclusive && endExclusive <= v.ArrayLen(), "endExclusive %v must be in [0, %d]", endExclusive, v.ArrayLen())
+	assert.Params(begin <= endExclusive, "begin %v and endExclusive %v must be begin <= endExclusive", begin, endExclusive)
+	for _, val := range vals {
+		assert.Params(val != nil, "Value must not be nil")
+	}
+
+	removed := Array(v.arrayVal[begin:endExclusive]...)
+	v.arrayVal = slices.Replace(v.arrayVal, begin, endExclusive, vals...)
+
+	return removed
+}
+func (v *value) ArrayPrepend(vals ...Value) {
+	v.ArrayInsertElm(0, vals...)
+}
+func (v *value) ArraySort(cmp func(a, b Value) int) {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	slices.SortFunc(v.arrayVal, func(a, b Value) bool { return cmp(a, b) < 0 })
+}
+func (v *value) ArrayStableSort(cmp func(a, b Value) int) {
+	assert.Params(v.Type() == TypeArray, "Value must be JSON array")
+
+	slices.SortStableFunc(v.arrayVal, func(a, b Value) bool { return cmp(a, b) < 0 })
+}
+
+// Equal returns true if two JSON values have the same type and the same content; otherwise false.
+// JSON numbers are compared by their numerical values and JSON objects are compared regardless of the order of their keys.
+func Equal(a Value, b Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Type() {
+	case TypeNull:
+		return true
+	case TypeBoolean:
+		return a.BooleanGet() == b.BooleanGet()
+	case TypeNumber:
+		return compareNumber(a.NumberGet(), b.NumberGet()) == 0
+	case TypeString:
+		return a.StringGet() == b.StringGet()
+	case TypeArray:
+		if a.ArrayLen() != b.ArrayLen() {
+			return false
+		}
+		for i := 0; i < a.ArrayLen(); i++ {
+			if !Equal(a.ArrayGetElm(i), b.ArrayGetElm(i)) {
+				return false
+			}
+		}
+		return true
+	case TypeObject:
+		if a.ObjectLen() != b.ObjectLen() {
+			return false
+		}
+		for _, k := range a.ObjectKeys() {
+			if !b.ObjectHasElm(k) || !Equal(a.ObjectGetElm(k), b.ObjectGetElm(k)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return assert.Unexpected1[bool](`invalid JsonType: %v`, a.Type())
+	}
+}
+
+// Compare returns an integer comparing two JSON values in a total order.
+// The result will be 0 if a == b, -1 if a < b, and +1 if a > b.
+// JSON values of different types are ordered as null < boolean < number < string < array < object.
+// Booleans are ordered as false < true, numbers by their numerical values, strings lexicographically, and arrays lexicographically by their elements.
+// Numbers which are not valid JSON numbers, e.g. created by Number(json.Number("x")), are greater than the valid ones and ordered lexicographically.
+// Objects are ordered by their sorted keys first and then by the JSON values associated with the keys in the sorted order.
+func Compare(a Value, b Value) int {
+	if c := compareOrdered(typeOrder(a.Type()), typeOrder(b.Type())); c != 0 {
+		return c
+	}
+
+	switch a.Type() {
+	case TypeNull:
+		return 0
+	case TypeBoolean:
+		return compareOrdered(boolOrder(a.BooleanGet()), boolOrder(b.BooleanGet()))
+	case TypeNumber:
+		return compareNumber(a.NumberGet(), b.NumberGet())
+	case TypeString:
+		return compareOrdered(a.StringGet(), b.StringGet())
+	case TypeArray:
+		for i := 0; i < a.ArrayLen() && i < b.ArrayLen(); i++ {
+			if c := Compare(a.ArrayGetElm(i), b.ArrayGetElm(i)); c != 0 {
+				return c
+			}
+		}
+		return compareOrdered(a.ArrayLen(), b.ArrayLen())
+	case TypeObject:
+		aKeys, bKeys := a.ObjectKeys(), b.ObjectKeys()
+		slices.Sort(aKeys)
+		slices.Sort(bKeys)
+		for i := 0; i < len(aKeys) && i < len(bKeys); i++ {
+			if c := compareOrdered(aKeys[i], bKeys[i]); c != 0 {
+				return c
+			}
+		}
+		if c := compareOrdered(len(aKeys), len(bKeys)); c != 0 {
+			return c
+		}
+		for _, k := range aKeys {
+			if c := Compare(a.ObjectGetElm(k), b.ObjectGetElm(k)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		return assert.Unexpected1[int](`invalid JsonType: %v`, a.Type())
+	}
+}
+
+func typeOrder(t Type) int {
+	switch t {
+	case TypeNull:
+		return 0
+	case TypeBoolean:
+		return 1
+	case TypeNumber:
+		return 2
+	case TypeString:
+		return 3
+	case TypeArray:
+		return 4
+	case TypeObject:
+		return 5
+	default:
+		return assert.Unexpected1[int](`invalid JsonType: %v`, t)
+	}
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func compareNumber(a json.Number, b json.Number) int {
+	if a == b {
+		return 0
+	}
+
+	x, okX := parseDecimal(a.String())
+	y, okY := parseDecimal(b.String())
+	switch {
+	case okX && okY:
+		return x.compare(y)
+	case okX:
+		return -1
+	case okY:
+		return 1
+	default:
+		return compareOrdered(a.String(), b.String())
+	}
+}
+
+// decimal is a number in the normalized form sign × 0.digits × 10^exp, which is compared exactly without computing its value.
+type decimal struct {
+	// sign is -1, 0 or +1.
+	sign int
+	// digits are the significant digits without leading and trailing zeros, which are empty for zero.
+	digits string
+	exp    *big.Int
+}
+
+// parseDecimal parses a JSON number s into a decimal.
+// If s is not a JSON number, parseDecimal returns false.
+func parseDecimal(s string) (decimal, bool) {
+	scanDigits := func(i int) int {
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		return i
+	}
+
+	d := decimal{sign: 1, exp: new(big.Int)}
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		d.sign = -1
+		i++
+	}
+	begin := i
+	i = scanDigits(i)
+	integer := s[begin:i]
+	if integer == "" || len(integer) > 1 && integer[0] == '0' {
+		return decimal{}, false
+	}
+	fraction := ""
+	if i < len(s) && s[i] == '.' {
+		begin = i + 1
+		i = scanDigits(begin)
+		fraction = s[begin:i]
+		if fraction == "" {
+			return decimal{}, false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		begin = i + 1
+		if begin < len(s) && (s[begin] == '+' || s[begin] == '-') {
+			i = scanDigits(begin + 1)
+			if i == begin+1 {
+				return decimal{}, false
+			}
+		} else if i = scanDigits(begin); i == begin {
+			return decimal{}, false
+		}
+		d.exp.SetString(s[begin:i], 10)
+	}
+	if i != len(s) {
+		return decimal{}, false
+	}
+
+	digits := strings.TrimLeft(integer+fraction, "0")
+	if digits == "" {
+		return decimal{sign: 0, exp: new(big.Int)}, true
+	}
+	// integer+fraction × 10^(exp-len(fraction)) is 0.digits × 10^(exp-len(fraction)+len(digits)).
+	d.exp.Add(d.exp, big.NewInt(int64(len(digits)-len(fraction))))
+	d.digits = strings.TrimRight(digits, "0")
+
+	return d, true
+}
+
+func (d decimal) compare(other decimal) int {
+	if d.sign != other.sign {
+		return compareOrdered(d.sign, other.sign)
+	}
+	if d.sign == 0 {
+		return 0
+	}
+	c := d.exp.Cmp(other.exp)
+	if c == 0 {
+		c = compareOrdered(d.digits, other.digits)
+	}
+
+	return d.sign * c
+}
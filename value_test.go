@@ -2,6 +2,7 @@ package jsonvalue_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"testing"
 
@@ -185,14 +186,14 @@ func TestBooleanGet(t *testing.T) {
 		want := true
 		sut := jsonvalue.Boolean(true)
 		got := sut.BooleanGet()
-		equal(t, got, want)
+		equal(t, fmt.Sprint(got), fmt.Sprint(want))
 	})
 
 	t.Run(`false`, func(t *testing.T) {
 		want := false
 		sut := jsonvalue.Boolean(false)
 		got := sut.BooleanGet()
-		equal(t, got, want)
+		equal(t, fmt.Sprint(got), fmt.Sprint(want))
 	})
 }
 
@@ -535,3 +536,213 @@ func TestArraySlice(t *testing.T) {
 		equal(t, a.ArrayLen(), 2)
 	})
 }
+
+func TestEqual(t *testing.T) {
+	type testCase struct {
+		a    jsonvalue.Value
+		b    jsonvalue.Value
+		want bool
+	}
+	testCases := []testCase{
+		{a: jsonvalue.Null(), b: jsonvalue.Null(), want: true},
+		{a: jsonvalue.Null(), b: jsonvalue.Boolean(false), want: false},
+		{a: jsonvalue.Boolean(true), b: jsonvalue.Boolean(true), want: true},
+		{a: jsonvalue.Boolean(true), b: jsonvalue.Boolean(false), want: false},
+		{a: jsonvalue.Number(1), b: jsonvalue.Number(json.Number("1.0e0")), want: true},
+		{a: jsonvalue.Number(1), b: jsonvalue.Number(2), want: false},
+		{a: jsonvalue.String("a"), b: jsonvalue.String("a"), want: true},
+		{a: jsonvalue.String("a"), b: jsonvalue.String("b"), want: false},
+		{a: jsonvalue.Array(jsonvalue.Null()), b: jsonvalue.Array(jsonvalue.Null()), want: true},
+		{a: jsonvalue.Array(jsonvalue.Null()), b: jsonvalue.Array(), want: false},
+		{
+			a:    jsonvalue.Object(jsonvalue.Props{"a": jsonvalue.Null(), "b": jsonvalue.Array()}),
+			b:    jsonvalue.Object(jsonvalue.Props{"b": jsonvalue.Array(), "a": jsonvalue.Null()}),
+			want: true,
+		},
+		{
+			a:    jsonvalue.Object(jsonvalue.Props{"a": jsonvalue.Null()}),
+			b:    jsonvalue.Object(jsonvalue.Props{"b": jsonvalue.Null()}),
+			want: false,
+		},
+	}
+	for _, testCase := range testCases {
+		equal(t, jsonvalue.Equal(testCase.a, testCase.b), testCase.want)
+	}
+}
+
+func TestArrayInsertElm(t *testing.T) {
+	t.Run("front", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(2))
+		a.ArrayInsertElm(0, jsonvalue.Number(0), jsonvalue.Number(1))
+		equal(t, mustMarshal(t, a), `[0,1,2]`)
+	})
+	t.Run("middle", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(2))
+		a.ArrayInsertElm(1, jsonvalue.Number(1))
+		equal(t, mustMarshal(t, a), `[0,1,2]`)
+	})
+	t.Run("back", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(0))
+		a.ArrayInsertElm(1, jsonvalue.Number(1))
+		equal(t, mustMarshal(t, a), `[0,1]`)
+	})
+}
+
+func TestArrayDelElm(t *testing.T) {
+	a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1), jsonvalue.Number(2))
+	a.ArrayDelElm(1)
+	equal(t, mustMarshal(t, a), `[0,2]`)
+	a.ArrayDelElm(1)
+	equal(t, mustMarshal(t, a), `[0]`)
+	a.ArrayDelElm(0)
+	equal(t, mustMarshal(t, a), `[]`)
+}
+
+func TestArraySplice(t *testing.T) {
+	t.Run("replace", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1), jsonvalue.Number(2), jsonvalue.Number(3))
+		removed := a.ArraySplice(1, 3, jsonvalue.String("x"))
+		equal(t, mustMarshal(t, a), `[0,"x",3]`)
+		equal(t, mustMarshal(t, removed), `[1,2]`)
+	})
+	t.Run("insert", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1))
+		removed := a.ArraySplice(1, 1, jsonvalue.String("x"), jsonvalue.String("y"))
+		equal(t, mustMarshal(t, a), `[0,"x","y",1]`)
+		equal(t, removed.ArrayLen(), 0)
+	})
+	t.Run("remove", func(t *testing.T) {
+		a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1))
+		removed := a.ArraySplice(0, 2)
+		equal(t, a.ArrayLen(), 0)
+		equal(t, mustMarshal(t, removed), `[0,1]`)
+	})
+}
+
+func TestArrayPrepend(t *testing.T) {
+	a := jsonvalue.Array(jsonvalue.Number(2))
+	a.ArrayPrepend(jsonvalue.Number(0), jsonvalue.Number(1))
+	equal(t, mustMarshal(t, a), `[0,1,2]`)
+}
+
+func TestArraySort(t *testing.T) {
+	a := mustUnmarshal(t, `[3,"b",null,{"a":1},[1],1.5,true,"a",false,[],{}]`)
+	a.ArraySort(jsonvalue.Compare)
+	equal(t, mustMarshal(t, a), `[null,false,true,1.5,3,"a","b",[],[1],{},{"a":1}]`)
+}
+
+func TestArrayStableSort(t *testing.T) {
+	a := mustUnmarshal(t, `[{"k":2,"v":"a"},{"k":1,"v":"b"},{"k":2,"v":"c"},{"k":1,"v":"d"}]`)
+	a.ArrayStableSort(func(x, y jsonvalue.Value) int {
+		return jsonvalue.Compare(x.ObjectGetElm("k"), y.ObjectGetElm("k"))
+	})
+	equal(t, mustMarshal(t, a), `[{"k":1,"v":"b"},{"k":1,"v":"d"},{"k":2,"v":"a"},{"k":2,"v":"c"}]`)
+}
+
+func TestCompare(t *testing.T) {
+	type testCase struct {
+		a    string
+		b    string
+		want int
+	}
+	testCases := []testCase{
+		{a: `null`, b: `null`, want: 0},
+		{a: `null`, b: `false`, want: -1},
+		{a: `false`, b: `true`, want: -1},
+		{a: `true`, b: `0`, want: -1},
+		{a: `10`, b: `9`, want: 1},
+		{a: `1e1`, b: `10`, want: 0},
+		{a: `-0.5`, b: `0`, want: -1},
+		{a: `1`, b: `"0"`, want: -1},
+		{a: `"b"`, b: `"a"`, want: 1},
+		{a: `"z"`, b: `[]`, want: -1},
+		{a: `[1,2]`, b: `[1,3]`, want: -1},
+		{a: `[1,2]`, b: `[1]`, want: 1},
+		{a: `[]`, b: `{}`, want: -1},
+		{a: `{"a":2}`, b: `{"b":1}`, want: -1},
+		{a: `{"a":1,"b":1}`, b: `{"b":1}`, want: -1},
+		{a: `{"a":1,"b":2}`, b: `{"b":1,"a":1}`, want: 1},
+		{a: `{"a":1,"b":[]}`, b: `{"b":[],"a":1.0}`, want: 0},
+		{a: `-0.0`, b: `0e5`, want: 0},
+		{a: `0.00123`, b: `1.23e-3`, want: 0},
+		{a: `1e10000000`, b: `2`, want: 1},
+		{a: `1e10000000`, b: `1e9999999`, want: 1},
+		{a: `-1e10000000`, b: `-1e9999999`, want: -1},
+		{a: `1e-10000000`, b: `0`, want: 1},
+		{a: `1e-10000000`, b: `1e-9999999`, want: -1},
+		{a: `12E+1`, b: `120.0`, want: 0},
+		{a: `0.12`, b: `0.123`, want: -1},
+		{a: `-0.12`, b: `-0.123`, want: 1},
+		{a: `0.2`, b: `0.123`, want: 1},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.a+" "+testCase.b, func(t *testing.T) {
+			equal(t, jsonvalue.Compare(mustUnmarshal(t, testCase.a), mustUnmarshal(t, testCase.b)), testCase.want)
+			equal(t, jsonvalue.Compare(mustUnmarshal(t, testCase.b), mustUnmarshal(t, testCase.a)), -testCase.want)
+		})
+	}
+	t.Run("invalid numbers", func(t *testing.T) {
+		numbers := []jsonvalue.Value{
+			jsonvalue.Number(json.Number("1e10000000")),
+			jsonvalue.Number(json.Number("0x10")),
+			jsonvalue.Number(json.Number("16")),
+			jsonvalue.Number(json.Number("abc")),
+			jsonvalue.Number(json.Number("-1")),
+		}
+		want := []string{"-1", "16", "1e10000000", "0x10", "abc"}
+		slices.SortFunc(numbers, func(a, b jsonvalue.Value) bool { return jsonvalue.Compare(a, b) < 0 })
+		got := []string{}
+		for _, n := range numbers {
+			got = append(got, n.NumberGet().String())
+		}
+		equal(t, fmt.Sprint(got), fmt.Sprint(want))
+		equal(t, jsonvalue.Equal(jsonvalue.Number(json.Number("0x10")), jsonvalue.Number(json.Number("16"))), false)
+	})
+}
+
+func TestArrayAll(t *testing.T) {
+	a := jsonvalue.Array(jsonvalue.Number(0), jsonvalue.Number(1), jsonvalue.Number(2))
+	t.Run("all", func(t *testing.T) {
+		indices := []int{}
+		for i, v := range a.ArrayAll() {
+			equal(t, v.NumberGet().String(), fmt.Sprint(i))
+			indices = append(indices, i)
+		}
+		equal(t, len(indices), 3)
+	})
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		for range a.ArrayAll() {
+			count++
+			break
+		}
+		equal(t, count, 1)
+	})
+}
+
+func TestObjectAll(t *testing.T) {
+	o := jsonvalue.Object(jsonvalue.Props{
+		"a": jsonvalue.String("a"),
+		"b": jsonvalue.String("b"),
+		"c": jsonvalue.String("c"),
+	})
+	t.Run("all", func(t *testing.T) {
+		keys := []string{}
+		for k, v := range o.ObjectAll() {
+			equal(t, v.StringGet(), k)
+			keys = append(keys, k)
+		}
+		equal(t, len(keys), 3)
+		checkSliceContains(t, keys, "a")
+		checkSliceContains(t, keys, "b")
+		checkSliceContains(t, keys, "c")
+	})
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		for range o.ObjectAll() {
+			count++
+			break
+		}
+		equal(t, count, 1)
+	})
+}
@@ -0,0 +1,412 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// XMLConvention is a convention mapping XML documents to JSON values used by FromXML and ToXML.
+type XMLConvention int
+
+const (
+	// XMLConventionSimple maps an element to a JSON object of its attributes prefixed with @, its text as #text and its child elements,
+	// where an element with neither attributes nor child elements is mapped to a JSON string of its text.
+	// The document is mapped to a JSON object with the root element as the only member.
+	XMLConventionSimple XMLConvention = iota
+	// XMLConventionBadgerfish maps an element to a JSON object of its attributes prefixed with @, its text as $ and its child elements,
+	// where the namespace declarations are mapped to a JSON object @xmlns of the prefixes and $ for the default namespace.
+	// The document is mapped to a JSON object with the root element as the only member.
+	XMLConventionBadgerfish
+	// XMLConventionParker maps an element to a JSON object of its child elements or else to the JSON value inferred from its text,
+	// discarding attributes and the text of elements with child elements.
+	// Texts are inferred as numbers, true and false if they are written so, and as strings otherwise, and empty elements are mapped to null.
+	// The document is mapped to the JSON value of the root element.
+	XMLConventionParker
+)
+
+// XMLOptions configures FromXML and ToXML.
+type XMLOptions struct {
+	// Convention is the convention mapping XML documents to JSON values.
+	Convention XMLConvention
+	// Arrays is the names of elements which FromXML always maps to JSON arrays even if they appear only once in their parents.
+	Arrays []string
+	// Root is the name of the root element written by ToXML in XMLConventionParker. If Root is empty, "root" is used.
+	Root string
+	// Indent is the indentation written by ToXML for each nesting level. If Indent is empty, no line breaks are written.
+	Indent string
+}
+
+func (o XMLOptions) root() string {
+	if o.Root == "" {
+		return "root"
+	}
+
+	return o.Root
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+// FromXML decodes an XML document read from r into a JSON value following the convention.
+// Element and attribute names keep their namespace prefixes such as atom:link, and repeated child elements are mapped to JSON arrays in order.
+// The text of each element is the concatenation of its character data with the surrounding white space trimmed.
+// Comments, processing instructions and directives are discarded.
+func FromXML(r io.Reader, opts XMLOptions) (Value, error) {
+	root, err := parseXML(r)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to decode XML: %w`, err)
+	}
+
+	arrays := map[string]bool{}
+	for _, name := range opts.Arrays {
+		arrays[name] = true
+	}
+	switch opts.Convention {
+	case XMLConventionSimple:
+		return Object(Props{root.name: simpleFromXML(root, arrays)}), nil
+	case XMLConventionBadgerfish:
+		return Object(Props{root.name: badgerfishFromXML(root, arrays)}), nil
+	case XMLConventionParker:
+		return parkerFromXML(root, arrays), nil
+	default:
+		return assert.Unexpected2[Value, error](`invalid XMLConvention: %v`, opts.Convention)
+	}
+}
+
+func parseXML(r io.Reader) (*xmlNode, error) {
+	d := xml.NewDecoder(r)
+	var root *xmlNode
+	stack := []*xmlNode{}
+	texts := []*strings.Builder{}
+	for {
+		token, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, fmt.Errorf(`multiple root elements`)
+			}
+			node := &xmlNode{name: xmlName(token.Name), attrs: token.Attr}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+			texts = append(texts, &strings.Builder{})
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlName(token.Name) {
+				return nil, fmt.Errorf(`unexpected end element </%s> at offset %d`, xmlName(token.Name), d.InputOffset())
+			}
+			stack[len(stack)-1].text = strings.TrimSpace(texts[len(texts)-1].String())
+			stack, texts = stack[:len(stack)-1], texts[:len(texts)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				if strings.TrimSpace(string(token)) != "" {
+					return nil, fmt.Errorf(`character data outside the root element`)
+				}
+				continue
+			}
+			texts[len(texts)-1].Write(token)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf(`root element is missing`)
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf(`element <%s> is not closed`, stack[len(stack)-1].name)
+	}
+
+	return root, nil
+}
+
+func addXMLChildren(obj Value, node *xmlNode, arrays map[string]bool, convert func(*xmlNode) Value) {
+	counts := map[string]int{}
+	for _, child := range node.children {
+		counts[child.name]++
+	}
+	for _, child := range node.children {
+		val := convert(child)
+		switch {
+		case counts[child.name] > 1 || arrays[child.name]:
+			if !obj.ObjectHasElm(child.name) {
+				obj.ObjectSetElm(child.name, Array())
+			}
+			obj.ObjectGetElm(child.name).ArrayAddElm(val)
+		default:
+			obj.ObjectSetElm(child.name, val)
+		}
+	}
+}
+
+func simpleFromXML(node *xmlNode, arrays map[string]bool) Value {
+	if len(node.attrs) == 0 && len(node.children) == 0 {
+		return String(node.text)
+	}
+
+	obj := Object()
+	for _, attr := range node.attrs {
+		obj.ObjectSetElm("@"+xmlName(attr.Name), String(attr.Value))
+	}
+	if node.text != "" {
+		obj.ObjectSetElm("#text", String(node.text))
+	}
+	addXMLChildren(obj, node, arrays, func(child *xmlNode) Value { return simpleFromXML(child, arrays) })
+
+	return obj
+}
+
+func badgerfishFromXML(node *xmlNode, arrays map[string]bool) Value {
+	obj := Object()
+	for _, attr := range node.attrs {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			if !obj.ObjectHasElm("@xmlns") {
+				obj.ObjectSetElm("@xmlns", Object())
+			}
+			obj.ObjectGetElm("@xmlns").ObjectSetElm("$", String(attr.Value))
+		case attr.Name.Space == "xmlns":
+			if !obj.ObjectHasElm("@xmlns") {
+				obj.ObjectSetElm("@xmlns", Object())
+			}
+			obj.ObjectGetElm("@xmlns").ObjectSetElm(attr.Name.Local, String(attr.Value))
+		default:
+			obj.ObjectSetElm("@"+xmlName(attr.Name), String(attr.Value))
+		}
+	}
+	if node.text != "" {
+		obj.ObjectSetElm("$", String(node.text))
+	}
+	addXMLChildren(obj, node, arrays, func(child *xmlNode) Value { return badgerfishFromXML(child, arrays) })
+
+	return obj
+}
+
+func parkerFromXML(node *xmlNode, arrays map[string]bool) Value {
+	if len(node.children) > 0 {
+		obj := Object()
+		addXMLChildren(obj, node, arrays, func(child *xmlNode) Value { return parkerFromXML(child, arrays) })
+		return obj
+	}
+
+	switch s := node.text; {
+	case s == "":
+		return Null()
+	case s == "true":
+		return Boolean(true)
+	case s == "false":
+		return Boolean(false)
+	case (s[0] == '-' || '0' <= s[0] && s[0] <= '9') && json.Valid([]byte(s)):
+		return Number(json.Number(s))
+	default:
+		return String(s)
+	}
+}
+
+var xmlNamePattern = regexp.MustCompile(`^[\p{L}_:][\p{L}\p{N}_:.\-]*$`)
+
+// ToXML encodes a JSON value v into an XML document following the convention, which reverses FromXML.
+// JSON arrays are written as repeated elements, null is written as an empty element, and members are written in the order of their names.
+// In XMLConventionSimple and XMLConventionBadgerfish, v must be a JSON object with the root element as the only member.
+// In XMLConventionParker, v is written as the content of the root element named by XMLOptions.Root.
+// An error is returned if a member name is not a valid XML name, a JSON string contains a character not allowed in XML,
+// or a JSON value cannot be written as an element or an attribute.
+func ToXML(v Value, opts XMLOptions) ([]byte, error) {
+	w := &xmlWriter{convention: opts.Convention, indent: opts.Indent}
+	w.b.WriteString(xml.Header)
+	switch opts.Convention {
+	case XMLConventionSimple, XMLConventionBadgerfish:
+		if v.Type() != TypeObject || v.ObjectLen() != 1 {
+			return nil, fmt.Errorf(`fail to encode XML: JSON value must be an object with only one member`)
+		}
+		name := v.ObjectKeys()[0]
+		if err := w.writeElement(Path{KeyName(name)}, name, v.ObjectGetElm(name), 0); err != nil {
+			return nil, fmt.Errorf(`fail to encode XML: %w`, err)
+		}
+	case XMLConventionParker:
+		if err := w.writeElement(Path{}, opts.root(), v, 0); err != nil {
+			return nil, fmt.Errorf(`fail to encode XML: %w`, err)
+		}
+	default:
+		return assert.Unexpected2[[]byte, error](`invalid XMLConvention: %v`, opts.Convention)
+	}
+	if w.indent != "" {
+		w.b.WriteByte('\n')
+	}
+
+	return w.b.Bytes(), nil
+}
+
+type xmlWriter struct {
+	b          bytes.Buffer
+	convention XMLConvention
+	indent     string
+}
+
+func formatXMLScalar(path Path, v Value) (string, error) {
+	switch v.Type() {
+	case TypeString:
+		s := v.StringGet()
+		for i := 0; i < len(s); {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 || !isXMLChar(r) {
+				return "", fmt.Errorf(`JSON string at %q contains a character %q not allowed in XML`, path.Pointer(), r)
+			}
+			i += size
+		}
+		return s, nil
+	case TypeNumber:
+		return v.NumberGet().String(), nil
+	case TypeBoolean:
+		return strconv.FormatBool(v.BooleanGet()), nil
+	default:
+		return "", fmt.Errorf(`JSON value at %q must be a scalar but got %v`, path.Pointer(), v.Type())
+	}
+}
+
+// isXMLChar returns true if r is allowed in XML documents (the Char production of XML 1.0).
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		0x20 <= r && r <= 0xD7FF || 0xE000 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0x10FFFF
+}
+
+func (w *xmlWriter) writeElement(path Path, name string, v Value, depth int) error {
+	if !xmlNamePattern.MatchString(name) {
+		return fmt.Errorf(`name %q at %q is not a valid XML name`, name, path.Pointer())
+	}
+
+	type attr struct{ name, value string }
+	attrs := []attr{}
+	text := ""
+	children := []string{}
+	switch {
+	case v.Type() == TypeNull:
+	case v.Type() == TypeArray:
+		return fmt.Errorf(`JSON array at %q cannot be written as an element`, path.Pointer())
+	case v.Type() != TypeObject:
+		s, err := formatXMLScalar(path, v)
+		if err != nil {
+			return err
+		}
+		text = s
+	default:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		for _, key := range keys {
+			val := v.ObjectGetElm(key)
+			valPath := path.Append(KeyName(key))
+			switch {
+			case w.convention == XMLConventionParker:
+				children = append(children, key)
+			case w.convention == XMLConventionBadgerfish && key == "@xmlns":
+				if val.Type() != TypeObject {
+					return fmt.Errorf(`JSON value at %q must be an object`, valPath.Pointer())
+				}
+				prefixes := val.ObjectKeys()
+				slices.Sort(prefixes)
+				for _, prefix := range prefixes {
+					s, err := formatXMLScalar(valPath.Append(KeyName(prefix)), val.ObjectGetElm(prefix))
+					if err != nil {
+						return err
+					}
+					if prefix == "$" {
+						attrs = append(attrs, attr{name: "xmlns", value: s})
+					} else {
+						attrs = append(attrs, attr{name: "xmlns:" + prefix, value: s})
+					}
+				}
+			case strings.HasPrefix(key, "@"):
+				s, err := formatXMLScalar(valPath, val)
+				if err != nil {
+					return err
+				}
+				if !xmlNamePattern.MatchString(key[1:]) {
+					return fmt.Errorf(`name %q at %q is not a valid XML name`, key[1:], valPath.Pointer())
+				}
+				attrs = append(attrs, attr{name: key[1:], value: s})
+			case w.convention == XMLConventionSimple && key == "#text" || w.convention == XMLConventionBadgerfish && key == "$":
+				s, err := formatXMLScalar(valPath, val)
+				if err != nil {
+					return err
+				}
+				text = s
+			default:
+				children = append(children, key)
+			}
+		}
+	}
+
+	w.writeIndent(depth)
+	w.b.WriteString("<" + name)
+	for _, a := range attrs {
+		w.b.WriteString(" " + a.name + `="`)
+		_ = xml.EscapeText(&w.b, []byte(a.value))
+		w.b.WriteByte('"')
+	}
+	if text == "" && len(children) == 0 {
+		w.b.WriteString("/>")
+		return nil
+	}
+	w.b.WriteByte('>')
+	_ = xml.EscapeText(&w.b, []byte(text))
+
+	for _, key := range children {
+		val := v.ObjectGetElm(key)
+		valPath := path.Append(KeyName(key))
+		if val.Type() != TypeArray {
+			if err := w.writeElement(valPath, key, val, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		for i, elm := range val.ArrayAll() {
+			if err := w.writeElement(valPath.Append(KeyIndex(i)), key, elm, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(children) > 0 && text == "" {
+		w.writeIndent(depth)
+	}
+	w.b.WriteString("</" + name + ">")
+
+	return nil
+}
+
+func (w *xmlWriter) writeIndent(depth int) {
+	if w.indent == "" || w.b.Len() == len(xml.Header) {
+		return
+	}
+	w.b.WriteString("\n" + strings.Repeat(w.indent, depth))
+}
@@ -0,0 +1,197 @@
+package jsonvalue_test
+
+import (
+	"strings"
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+const xmlFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- feed -->
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/" version="2">
+  <title>Example &amp; Co</title>
+  <entry id="1">
+    <title>First</title>
+    <media:thumbnail url="a.png"/>
+    <count>10</count>
+  </entry>
+  <entry id="2">
+    <title><![CDATA[<Second>]]></title>
+    <count>2.5</count>
+    <draft>true</draft>
+    <empty/>
+  </entry>
+</feed>`
+
+func TestFromXML(t *testing.T) {
+	type testCase struct {
+		name string
+		opts jsonvalue.XMLOptions
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{
+			name: "simple",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionSimple},
+			in:   xmlFeed,
+			want: `{"feed":{"@xmlns":"http://www.w3.org/2005/Atom","@xmlns:media":"http://search.yahoo.com/mrss/","@version":"2","title":"Example & Co","entry":[
+				{"@id":"1","title":"First","media:thumbnail":{"@url":"a.png"},"count":"10"},
+				{"@id":"2","title":"<Second>","count":"2.5","draft":"true","empty":""}]}}`,
+		},
+		{
+			name: "badgerfish",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionBadgerfish},
+			in:   xmlFeed,
+			want: `{"feed":{"@xmlns":{"$":"http://www.w3.org/2005/Atom","media":"http://search.yahoo.com/mrss/"},"@version":"2","title":{"$":"Example & Co"},"entry":[
+				{"@id":"1","title":{"$":"First"},"media:thumbnail":{"@url":"a.png"},"count":{"$":"10"}},
+				{"@id":"2","title":{"$":"<Second>"},"count":{"$":"2.5"},"draft":{"$":"true"},"empty":{}}]}}`,
+		},
+		{
+			name: "parker",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionParker},
+			in:   xmlFeed,
+			want: `{"title":"Example & Co","entry":[
+				{"title":"First","media:thumbnail":null,"count":10},
+				{"title":"<Second>","count":2.5,"draft":true,"empty":null}]}`,
+		},
+		{
+			name: "arrays",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionParker, Arrays: []string{"item"}},
+			in:   `<list><item>a</item></list>`,
+			want: `{"item":["a"]}`,
+		},
+		{
+			name: "mixed content",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionSimple},
+			in:   `<p> Hello <b>world</b> ! </p>`,
+			want: `{"p":{"#text":"Hello  !","b":"world"}}`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.FromXML(strings.NewReader(testCase.in), testCase.opts)
+			equal(t, err, nil)
+			equal(t, string(mustMarshal(t, got)), string(mustMarshal(t, mustUnmarshal(t, testCase.want))))
+		})
+	}
+}
+
+func TestFromXML_Error(t *testing.T) {
+	testCases := map[string]string{
+		"empty":          ``,
+		"unclosed":       `<a><b></b>`,
+		"mismatched":     `<a><b></a></b>`,
+		"multiple roots": `<a/><b/>`,
+		"text outside":   `<a/>text`,
+		"syntax":         `<a x=1/>`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.FromXML(strings.NewReader(in), jsonvalue.XMLOptions{})
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestToXML(t *testing.T) {
+	type testCase struct {
+		name string
+		opts jsonvalue.XMLOptions
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{
+			name: "simple",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionSimple},
+			in:   `{"feed":{"@version":2,"title":"A & B","entry":[{"@id":"1","#text":"x"},{"n":null}],"ok":true}}`,
+			want: `<feed version="2"><entry id="1">x</entry><entry><n/></entry><ok>true</ok><title>A &amp; B</title></feed>`,
+		},
+		{
+			name: "badgerfish",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionBadgerfish},
+			in:   `{"feed":{"@xmlns":{"$":"urn:a","m":"urn:m"},"m:x":{"@q":"\"<"},"title":{"$":"T"}}}`,
+			want: `<feed xmlns="urn:a" xmlns:m="urn:m"><m:x q="&#34;&lt;"/><title>T</title></feed>`,
+		},
+		{
+			name: "parker",
+			opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionParker, Root: "list"},
+			in:   `{"item":[1,"a"],"x":null}`,
+			want: `<list><item>1</item><item>a</item><x/></list>`,
+		},
+		{
+			name: "indent",
+			opts: jsonvalue.XMLOptions{Indent: "  "},
+			in:   `{"a":{"b":{"c":"x"},"d":""}}`,
+			want: "<a>\n  <b>\n    <c>x</c>\n  </b>\n  <d/>\n</a>\n",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.ToXML(mustUnmarshal(t, testCase.in), testCase.opts)
+			equal(t, err, nil)
+			equal(t, string(got), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+testCase.want)
+		})
+	}
+}
+
+func TestToXML_RoundTrip(t *testing.T) {
+	testCases := map[string]jsonvalue.XMLConvention{
+		"simple":     jsonvalue.XMLConventionSimple,
+		"badgerfish": jsonvalue.XMLConventionBadgerfish,
+		"parker":     jsonvalue.XMLConventionParker,
+	}
+	for name, convention := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := jsonvalue.XMLOptions{Convention: convention, Root: "feed", Indent: "\t"}
+			v, err := jsonvalue.FromXML(strings.NewReader(xmlFeed), opts)
+			equal(t, err, nil)
+			b, err := jsonvalue.ToXML(v, opts)
+			equal(t, err, nil)
+			got, err := jsonvalue.FromXML(strings.NewReader(string(b)), opts)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, v), true)
+		})
+	}
+}
+
+func TestToXML_Error(t *testing.T) {
+	type testCase struct {
+		name string
+		opts jsonvalue.XMLOptions
+		in   string
+	}
+	testCases := []testCase{
+		{name: "not an object", opts: jsonvalue.XMLOptions{}, in: `"a"`},
+		{name: "multiple roots", opts: jsonvalue.XMLOptions{}, in: `{"a":"","b":""}`},
+		{name: "root array", opts: jsonvalue.XMLOptions{}, in: `{"a":[]}`},
+		{name: "nested array", opts: jsonvalue.XMLOptions{}, in: `{"a":{"b":[[1]]}}`},
+		{name: "invalid name", opts: jsonvalue.XMLOptions{}, in: `{"a":{"b c":1}}`},
+		{name: "object attribute", opts: jsonvalue.XMLOptions{}, in: `{"a":{"@b":{}}}`},
+		{name: "parker attribute name", opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionParker}, in: `{"@b":1}`},
+		{name: "control character in text", opts: jsonvalue.XMLOptions{}, in: `{"a":"x\u0001y"}`},
+		{name: "control character in attribute", opts: jsonvalue.XMLOptions{}, in: `{"a":{"@b":"\u0000"}}`},
+		{name: "noncharacter in badgerfish text", opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionBadgerfish}, in: `{"a":{"$":"\ufffe"}}`},
+		{name: "control character in parker text", opts: jsonvalue.XMLOptions{Convention: jsonvalue.XMLConventionParker}, in: `{"a":"\u001f"}`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := jsonvalue.ToXML(mustUnmarshal(t, testCase.in), testCase.opts)
+			IsNotNil(t, err)
+		})
+	}
+	t.Run("allowed characters", func(t *testing.T) {
+		in := mustUnmarshal(t, `{"a":"x\ty\r\nz\ud7ff\ue000\ufffd\ud83d\ude00"}`)
+		b, err := jsonvalue.ToXML(in, jsonvalue.XMLOptions{})
+		equal(t, err, nil)
+		got, err := jsonvalue.FromXML(strings.NewReader(string(b)), jsonvalue.XMLOptions{})
+		equal(t, err, nil)
+		equal(t, jsonvalue.Equal(got, in), true)
+	})
+	t.Run("invalid utf8", func(t *testing.T) {
+		_, err := jsonvalue.ToXML(jsonvalue.Object(jsonvalue.Props{"a": jsonvalue.String("\xff")}), jsonvalue.XMLOptions{})
+		IsNotNil(t, err)
+	})
+}
@@ -0,0 +1,209 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Jumpaku/go-assert"
+	"golang.org/x/exp/slices"
+)
+
+// FromYAML decodes a YAML 1.2 stream into a JSON value.
+// Block and flow collections, plain, quoted and block scalars, anchors, aliases and the tags of the core schema are supported.
+// Plain scalars are resolved by the YAML 1.2 core schema, aliases are expanded into copies of the anchored nodes,
+// and a stream of multiple documents is decoded into a JSON array of the documents.
+// An empty stream is decoded into null.
+// Local tags such as !custom are ignored, and plain scalars with them are read as strings.
+// Mapping keys must be strings, and complex keys, non-string keys, duplicate keys, .inf and .nan are rejected with errors.
+func FromYAML(b []byte) (Value, error) {
+	p, err := newYAMLParser(b)
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse YAML: %w`, err)
+	}
+	docs, err := p.parseStream()
+	if err != nil {
+		return nil, fmt.Errorf(`fail to parse YAML: %w`, err)
+	}
+
+	switch len(docs) {
+	case 0:
+		return Null(), nil
+	case 1:
+		return docs[0], nil
+	default:
+		return Array(docs...), nil
+	}
+}
+
+// ToYAML encodes a JSON value v into a YAML 1.2 document in the block style.
+// Object members are sorted by their keys, and strings are quoted if they would otherwise be read as other types.
+func ToYAML(v Value) ([]byte, error) {
+	var b strings.Builder
+	switch {
+	case v.Type() == TypeObject && v.ObjectLen() > 0, v.Type() == TypeArray && v.ArrayLen() > 0:
+		writeYAMLBlock(&b, v, 0, false)
+	default:
+		writeYAMLScalar(&b, v, 2)
+	}
+
+	return []byte(b.String()), nil
+}
+
+// writeYAMLBlock writes a non-empty JSON object or array in the block style at the indentation indent.
+// If inline is true, the first line is written without indentation following a sequence indicator.
+func writeYAMLBlock(b *strings.Builder, v Value, indent int, inline bool) {
+	writeIndent := func(i int) {
+		if i > 0 || !inline {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+	}
+	writeChild := func(child Value, nested bool) {
+		switch {
+		case child.Type() == TypeObject && child.ObjectLen() > 0, child.Type() == TypeArray && child.ArrayLen() > 0:
+			if nested {
+				b.WriteString("\n")
+				writeYAMLBlock(b, child, indent+2, false)
+			} else {
+				b.WriteString(" ")
+				writeYAMLBlock(b, child, indent+2, true)
+			}
+		default:
+			b.WriteString(" ")
+			writeYAMLScalar(b, child, indent+2)
+		}
+	}
+
+	switch v.Type() {
+	case TypeObject:
+		keys := v.ObjectKeys()
+		slices.Sort(keys)
+		for i, key := range keys {
+			writeIndent(i)
+			b.WriteString(yamlQuote(key))
+			b.WriteString(":")
+			writeChild(v.ObjectGetElm(key), true)
+		}
+	case TypeArray:
+		for i, elm := range v.ArrayAll() {
+			writeIndent(i)
+			b.WriteString("-")
+			writeChild(elm, false)
+		}
+	default:
+		assert.Unexpected(`JSON value must be an object or an array: %v`, v.Type())
+	}
+}
+
+// writeYAMLScalar writes a scalar or an empty collection followed by a line break.
+// The lines of a block scalar are written at the indentation indent.
+func writeYAMLScalar(b *strings.Builder, v Value, indent int) {
+	switch v.Type() {
+	case TypeNull:
+		b.WriteString("null")
+	case TypeBoolean:
+		fmt.Fprint(b, v.BooleanGet())
+	case TypeNumber:
+		b.WriteString(v.NumberGet().String())
+	case TypeString:
+		s := v.StringGet()
+		if !yamlLiteralSafe(s) {
+			b.WriteString(yamlQuote(s))
+			break
+		}
+		body := strings.TrimRight(s, "\n")
+		switch len(s) - len(body) {
+		case 0:
+			b.WriteString("|-")
+		case 1:
+			b.WriteString("|")
+		default:
+			b.WriteString("|+")
+		}
+		for _, line := range strings.Split(body, "\n") {
+			b.WriteString("\n")
+			if line != "" {
+				b.WriteString(strings.Repeat(" ", indent))
+				b.WriteString(line)
+			}
+		}
+		b.WriteString(strings.Repeat("\n", max(len(s)-len(body)-1, 0)))
+	case TypeArray:
+		b.WriteString("[]")
+	case TypeObject:
+		b.WriteString("{}")
+	}
+	b.WriteString("\n")
+}
+
+// yamlLiteralSafe returns true if a string s can be written as a literal block scalar.
+func yamlLiteralSafe(s string) bool {
+	body := strings.TrimRight(s, "\n")
+	if !strings.Contains(s, "\n") || body == "" || body[0] == ' ' || body[0] == '\t' || body[0] == '\n' {
+		return false
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" && strings.TrimLeft(line, " \t") == "" {
+			return false
+		}
+	}
+	for _, r := range s {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// yamlQuote returns a string s as a plain scalar if it is read as the same string, or as a double-quoted scalar otherwise.
+func yamlQuote(s string) string {
+	if yamlPlainSafe(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case unicode.IsPrint(r):
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r <= 0xffff:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func yamlPlainSafe(s string) bool {
+	if s == "" || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") || strings.HasPrefix(s, "...") {
+		return false
+	}
+	if strings.HasSuffix(s, " ") || strings.HasSuffix(s, ":") || strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	v, err := resolveYAMLPlain(s)
+
+	return err == nil && v.Type() == TypeString
+}
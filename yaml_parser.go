@@ -0,0 +1,1067 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlEOF is returned by yamlParser.peekAt at the end of the input.
+const yamlEOF rune = -1
+
+// yamlParser is a recursive descent parser of YAML 1.2 streams.
+type yamlParser struct {
+	src       []rune
+	pos       int
+	line      int
+	lineStart int
+	anchors   map[string]Value
+	// aliasNodes is the number of the JSON values copied by expanding aliases.
+	aliasNodes int
+}
+
+// maxYAMLAliasNodes limits the number of the JSON values copied by expanding aliases to reject exponentially expanding documents.
+const maxYAMLAliasNodes = 1 << 20
+
+// yamlMark is a saved position of yamlParser.
+type yamlMark struct {
+	pos       int
+	line      int
+	lineStart int
+}
+
+func newYAMLParser(b []byte) (*yamlParser, error) {
+	if !utf8.Valid(b) {
+		return nil, fmt.Errorf(`input is not valid UTF-8`)
+	}
+	s := strings.TrimPrefix(string(b), "\ufeff")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	return &yamlParser{src: []rune(s)}, nil
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *yamlParser) peekAt(offset int) rune {
+	if i := p.pos + offset; i < len(p.src) {
+		return p.src[i]
+	}
+
+	return yamlEOF
+}
+
+func (p *yamlParser) peek() rune {
+	return p.peekAt(0)
+}
+
+func (p *yamlParser) col() int {
+	return p.pos - p.lineStart
+}
+
+func (p *yamlParser) advance() {
+	if p.src[p.pos] == '\n' {
+		p.line++
+		p.lineStart = p.pos + 1
+	}
+	p.pos++
+}
+
+func (p *yamlParser) mark() yamlMark {
+	return yamlMark{pos: p.pos, line: p.line, lineStart: p.lineStart}
+}
+
+func (p *yamlParser) reset(m yamlMark) {
+	p.pos, p.line, p.lineStart = m.pos, m.line, m.lineStart
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	return p.errorAt(p.mark(), format, args...)
+}
+
+func (p *yamlParser) errorAt(m yamlMark, format string, args ...any) error {
+	return fmt.Errorf(`line %d, column %d: %s`, m.line+1, m.pos-m.lineStart+1, fmt.Sprintf(format, args...))
+}
+
+func isYAMLBlank(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isYAMLFlowIndicator(r rune) bool {
+	return r == ',' || r == '[' || r == ']' || r == '{' || r == '}'
+}
+
+// wsAt returns true if the character at the offset is a blank, a line break or the end of the input.
+func (p *yamlParser) wsAt(offset int) bool {
+	r := p.peekAt(offset)
+	return isYAMLBlank(r) || r == '\n' || r == yamlEOF
+}
+
+func (p *yamlParser) skipBlanks() {
+	for isYAMLBlank(p.peek()) {
+		p.advance()
+	}
+}
+
+func (p *yamlParser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.advance()
+	}
+}
+
+// skipToContent skips blanks, comments and line breaks, and returns true if a line break is skipped.
+func (p *yamlParser) skipToContent() bool {
+	crossed := false
+	for {
+		p.skipBlanks()
+		switch p.peek() {
+		case '#':
+			p.skipLine()
+		case '\n':
+			p.advance()
+			crossed = true
+		default:
+			return crossed
+		}
+	}
+}
+
+// expectLineEnd reports an error if anything other than a comment follows on the current line.
+func (p *yamlParser) expectLineEnd() error {
+	p.skipBlanks()
+	if r := p.peek(); r != '\n' && r != '#' && r != yamlEOF {
+		return p.errorf(`unexpected character %q`, r)
+	}
+
+	return nil
+}
+
+func (p *yamlParser) atMarker(marker string) bool {
+	if p.col() != 0 {
+		return false
+	}
+	for i, r := range marker {
+		if p.peekAt(i) != r {
+			return false
+		}
+	}
+
+	return p.wsAt(len(marker))
+}
+
+func (p *yamlParser) atDocumentMarker() bool {
+	return p.atMarker("---") || p.atMarker("...")
+}
+
+func (p *yamlParser) parseStream() ([]Value, error) {
+	docs := []Value{}
+	for {
+		p.skipToContent()
+		for p.col() == 0 && p.peek() == '%' {
+			p.skipLine()
+			p.skipToContent()
+		}
+		if p.eof() {
+			return docs, nil
+		}
+		if p.atMarker("...") {
+			p.pos += 3
+			continue
+		}
+		if p.atMarker("---") {
+			p.pos += 3
+		}
+
+		p.anchors = map[string]Value{}
+		doc, err := p.parseBlockNode(-1, false)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+
+		p.skipToContent()
+		if !p.eof() && !p.atDocumentMarker() {
+			return nil, p.errorf(`unexpected content %q`, p.peek())
+		}
+	}
+}
+
+// parseBlockNode parses a node in the block context whose parent node is at the indentation indent.
+// If mappingValue is true, the node is a mapping value, which may be a block sequence at the same indentation as the parent
+// and must not be a block collection starting on the same line as the key.
+func (p *yamlParser) parseBlockNode(indent int, mappingValue bool) (Value, error) {
+	fits := func() bool {
+		return p.col() > indent || (mappingValue && p.col() == indent && p.peek() == '-' && p.wsAt(1))
+	}
+	empty := func(crossed bool) bool {
+		return p.eof() || p.atDocumentMarker() || (crossed && !fits())
+	}
+
+	crossed := p.skipToContent()
+	if empty(crossed) {
+		return Null(), nil
+	}
+	newLine := crossed
+
+	anchor, tag := "", ""
+	for p.peek() == '&' || p.peek() == '!' {
+		if p.peek() == '&' {
+			anchor = p.scanName()
+		} else {
+			tag = p.scanTag()
+		}
+		crossed := p.skipToContent()
+		newLine = newLine || crossed
+		if empty(crossed) {
+			v, err := yamlScalar("", true, tag, p.mark())
+			if err != nil {
+				return nil, err
+			}
+			p.setAnchor(anchor, v)
+			return v, nil
+		}
+	}
+
+	v, err := p.parseBlockContent(indent, tag, mappingValue && !newLine)
+	if err != nil {
+		return nil, err
+	}
+	p.setAnchor(anchor, v)
+
+	return v, nil
+}
+
+func (p *yamlParser) setAnchor(anchor string, v Value) {
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+}
+
+// parseBlockContent parses the content of a node, which must not be a block collection if afterKey is true.
+func (p *yamlParser) parseBlockContent(indent int, tag string, afterKey bool) (Value, error) {
+	start := p.mark()
+	switch r := p.peek(); {
+	case r == '*':
+		v, err := p.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		return v, p.expectLineEnd()
+	case r == '-' && p.wsAt(1):
+		if afterKey {
+			return nil, p.errorf(`block sequence must not start on the same line as the mapping key`)
+		}
+		v, err := p.parseBlockSequence(p.col())
+		if err != nil {
+			return nil, err
+		}
+		return v, yamlCollectionTag(tag, v, start)
+	case r == '|' || r == '>':
+		s, err := p.parseBlockScalar(indent)
+		if err != nil {
+			return nil, err
+		}
+		return yamlScalar(s, false, tag, start)
+	case r == '[' || r == '{':
+		v, err := p.parseFlowNode()
+		if err != nil {
+			return nil, err
+		}
+		p.skipBlanks()
+		if p.peek() == ':' {
+			return nil, p.errorf(`collections as mapping keys are not supported`)
+		}
+		if err := yamlCollectionTag(tag, v, start); err != nil {
+			return nil, err
+		}
+		return v, p.expectLineEnd()
+	case r == '?' && p.wsAt(1):
+		return nil, p.errorf(`complex mapping keys are not supported`)
+	}
+
+	// A scalar followed by ':' on the same line is the first key of a block mapping.
+	col := p.col()
+	quoted := p.peek() == '"' || p.peek() == '\''
+	if quoted {
+		_, err := p.scanQuoted()
+		if err != nil {
+			return nil, err
+		}
+	} else if _, err := p.scanPlain(indent, false, false); err != nil {
+		return nil, err
+	}
+	p.skipBlanks()
+	isKey := p.peek() == ':' && p.wsAt(1) && p.line == start.line
+	p.reset(start)
+	if isKey {
+		if afterKey {
+			return nil, p.errorf(`block mapping must not start on the same line as the mapping key`)
+		}
+		v, err := p.parseBlockMapping(col)
+		if err != nil {
+			return nil, err
+		}
+		return v, yamlCollectionTag(tag, v, start)
+	}
+
+	var s string
+	var err error
+	if quoted {
+		s, err = p.scanQuoted()
+	} else {
+		s, err = p.scanPlain(indent, false, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectLineEnd(); err != nil {
+		return nil, err
+	}
+
+	return yamlScalar(s, !quoted, tag, start)
+}
+
+func (p *yamlParser) parseAlias() (Value, error) {
+	start := p.mark()
+	name := p.scanName()
+	v, ok := p.anchors[name]
+	if !ok {
+		return nil, p.errorAt(start, `alias *%s refers to an undefined anchor`, name)
+	}
+
+	if !p.countAliasNodes(v) {
+		return nil, p.errorAt(start, `aliases are expanded into more than %d nodes`, maxYAMLAliasNodes)
+	}
+
+	return v.Clone(), nil
+}
+
+// countAliasNodes adds the number of the JSON values included in v to aliasNodes and returns false if it exceeds maxYAMLAliasNodes.
+func (p *yamlParser) countAliasNodes(v Value) bool {
+	p.aliasNodes++
+	if p.aliasNodes > maxYAMLAliasNodes {
+		return false
+	}
+	switch v.Type() {
+	case TypeArray:
+		for _, elm := range v.ArrayAll() {
+			if !p.countAliasNodes(elm) {
+				return false
+			}
+		}
+	case TypeObject:
+		for _, val := range v.ObjectAll() {
+			if !p.countAliasNodes(val) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// scanName scans an anchor or alias name following '&' or '*'.
+func (p *yamlParser) scanName() string {
+	p.advance()
+	var b strings.Builder
+	for !p.wsAt(0) && !isYAMLFlowIndicator(p.peek()) {
+		b.WriteRune(p.peek())
+		p.advance()
+	}
+
+	return b.String()
+}
+
+// scanTag scans a tag and returns it in the shorthand form such as !!str.
+func (p *yamlParser) scanTag() string {
+	var b strings.Builder
+	for !p.wsAt(0) && !isYAMLFlowIndicator(p.peek()) {
+		b.WriteRune(p.peek())
+		p.advance()
+	}
+
+	tag := b.String()
+	if strings.HasPrefix(tag, "!<tag:yaml.org,2002:") && strings.HasSuffix(tag, ">") {
+		return "!!" + strings.TrimSuffix(strings.TrimPrefix(tag, "!<tag:yaml.org,2002:"), ">")
+	}
+
+	return tag
+}
+
+func (p *yamlParser) parseBlockSequence(indent int) (Value, error) {
+	arr := Array()
+	for {
+		p.advance()
+		elm, err := p.parseBlockNode(indent, false)
+		if err != nil {
+			return nil, err
+		}
+		arr.ArrayAddElm(elm)
+
+		p.skipToContent()
+		if p.eof() || p.atDocumentMarker() || p.col() < indent {
+			return arr, nil
+		}
+		if p.col() > indent {
+			return nil, p.errorf(`unexpected indentation`)
+		}
+		if p.peek() != '-' || !p.wsAt(1) {
+			return arr, nil
+		}
+	}
+}
+
+func (p *yamlParser) parseBlockMapping(indent int) (Value, error) {
+	obj := Object()
+	for {
+		start := p.mark()
+		key, err := p.scanBlockKey()
+		if err != nil {
+			return nil, err
+		}
+		p.skipBlanks()
+		if p.peek() != ':' || !p.wsAt(1) {
+			return nil, p.errorf(`':' expected after mapping key %q`, key)
+		}
+		p.advance()
+		if obj.ObjectHasElm(key) {
+			return nil, p.errorAt(start, `duplicate mapping key %q`, key)
+		}
+
+		val, err := p.parseBlockNode(indent, true)
+		if err != nil {
+			return nil, err
+		}
+		obj.ObjectSetElm(key, val)
+
+		p.skipToContent()
+		if p.eof() || p.atDocumentMarker() || p.col() < indent {
+			return obj, nil
+		}
+		if p.col() > indent {
+			return nil, p.errorf(`unexpected indentation`)
+		}
+	}
+}
+
+func (p *yamlParser) scanBlockKey() (string, error) {
+	start := p.mark()
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.scanQuoted()
+	case r == '?' && p.wsAt(1):
+		return "", p.errorf(`complex mapping keys are not supported`)
+	case r == '[' || r == '{':
+		return "", p.errorf(`collections as mapping keys are not supported`)
+	case r == '*' || r == '&' || r == '!':
+		return "", p.errorf(`anchors, aliases and tags on mapping keys are not supported`)
+	}
+
+	s, err := p.scanPlain(-1, false, false)
+	if err != nil {
+		return "", err
+	}
+	key, err := yamlScalar(s, true, "", start)
+	if err != nil {
+		return "", err
+	}
+	if err := p.checkKey(key, start); err != nil {
+		return "", err
+	}
+
+	return key.StringGet(), nil
+}
+
+func (p *yamlParser) checkKey(key Value, m yamlMark) error {
+	if key.Type() != TypeString {
+		b, _ := json.Marshal(key)
+		return p.errorAt(m, `mapping key %s is not a string but %v`, b, key.Type())
+	}
+
+	return nil
+}
+
+// canStartPlain returns true if a plain scalar can start at the current position.
+func (p *yamlParser) canStartPlain(flow bool) bool {
+	switch r := p.peek(); r {
+	case '-', '?', ':':
+		return !p.wsAt(1) && !(flow && isYAMLFlowIndicator(p.peekAt(1)))
+	case ',', '[', ']', '{', '}', '#', '&', '*', '!', '|', '>', '\'', '"', '%', '@', '`', ' ', '\t', '\n', yamlEOF:
+		return false
+	default:
+		return true
+	}
+}
+
+// scanPlain scans a plain scalar.
+// If multiline is true, the following lines indented more than indent are folded into the scalar.
+func (p *yamlParser) scanPlain(indent int, flow bool, multiline bool) (string, error) {
+	if !p.canStartPlain(flow) {
+		if p.eof() {
+			return "", p.errorf(`unexpected end of input`)
+		}
+		return "", p.errorf(`unexpected character %q`, p.peek())
+	}
+
+	var b strings.Builder
+	for {
+		b.WriteString(p.scanPlainLine(flow))
+		if !multiline {
+			return b.String(), nil
+		}
+
+		m := p.mark()
+		p.skipBlanks()
+		if p.peek() != '\n' {
+			p.reset(m)
+			return b.String(), nil
+		}
+		breaks := 0
+		for p.peek() == '\n' {
+			p.advance()
+			breaks++
+			p.skipBlanks()
+		}
+		if p.eof() || p.peek() == '#' || p.atDocumentMarker() || (!flow && p.col() <= indent) || (flow && isYAMLFlowIndicator(p.peek())) || !p.canContinuePlain(flow) {
+			p.reset(m)
+			return b.String(), nil
+		}
+		if breaks == 1 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(strings.Repeat("\n", breaks-1))
+		}
+	}
+}
+
+func (p *yamlParser) canContinuePlain(flow bool) bool {
+	return !(p.peek() == ':' && (p.wsAt(1) || (flow && isYAMLFlowIndicator(p.peekAt(1)))))
+}
+
+// scanPlainLine scans a plain scalar until the end of the line or an indicator terminating it, excluding the trailing blanks.
+func (p *yamlParser) scanPlainLine(flow bool) string {
+	start, end := p.pos, p.pos
+	for !p.eof() {
+		r := p.peek()
+		if r == '\n' {
+			break
+		}
+		if r == ':' && (p.wsAt(1) || (flow && isYAMLFlowIndicator(p.peekAt(1)))) {
+			break
+		}
+		if r == '#' && p.pos > start && isYAMLBlank(p.src[p.pos-1]) {
+			break
+		}
+		if flow && isYAMLFlowIndicator(r) {
+			break
+		}
+		p.advance()
+		if !isYAMLBlank(r) {
+			end = p.pos
+		}
+	}
+	p.pos = end
+
+	return string(p.src[start:end])
+}
+
+// scanQuoted scans a single-quoted or double-quoted scalar.
+func (p *yamlParser) scanQuoted() (string, error) {
+	start := p.mark()
+	quote := p.peek()
+	p.advance()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorAt(start, `unterminated quoted scalar`)
+		}
+		r := p.peek()
+		switch {
+		case r == '\'' && quote == '\'' && p.peekAt(1) == '\'':
+			b.WriteRune('\'')
+			p.advance()
+			p.advance()
+		case r == quote:
+			p.advance()
+			return b.String(), nil
+		case r == '\\' && quote == '"' && p.peekAt(1) == '\n':
+			p.advance()
+			p.advance()
+			p.skipBlanks()
+		case r == '\\' && quote == '"':
+			s, err := p.scanEscape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		case isYAMLBlank(r) || r == '\n':
+			blanks := p.pos
+			p.skipBlanks()
+			if p.peek() != '\n' {
+				b.WriteString(string(p.src[blanks:p.pos]))
+				continue
+			}
+			breaks := 0
+			for p.peek() == '\n' {
+				p.advance()
+				breaks++
+				p.skipBlanks()
+			}
+			if p.atDocumentMarker() {
+				return "", p.errorAt(start, `unterminated quoted scalar`)
+			}
+			if breaks == 1 {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(strings.Repeat("\n", breaks-1))
+			}
+		default:
+			b.WriteRune(r)
+			p.advance()
+		}
+	}
+}
+
+var yamlEscapes = map[rune]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b",
+	' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+func (p *yamlParser) scanEscape() (string, error) {
+	start := p.mark()
+	p.advance()
+	r := p.peek()
+	if s, ok := yamlEscapes[r]; ok {
+		p.advance()
+		return s, nil
+	}
+
+	digits := map[rune]int{'x': 2, 'u': 4, 'U': 8}[r]
+	if digits == 0 {
+		return "", p.errorAt(start, `invalid escape sequence \%c`, r)
+	}
+	p.advance()
+	code, err := p.scanHex(digits)
+	if err != nil {
+		return "", p.errorAt(start, `invalid escape sequence: %v`, err)
+	}
+	if r == 'u' && 0xd800 <= code && code < 0xdc00 && p.peek() == '\\' && p.peekAt(1) == 'u' {
+		m := p.mark()
+		p.advance()
+		p.advance()
+		if low, err := p.scanHex(4); err == nil && 0xdc00 <= low && low < 0xe000 {
+			return string(rune(0x10000 + (code-0xd800)<<10 + (low - 0xdc00))), nil
+		}
+		p.reset(m)
+	}
+	if !utf8.ValidRune(rune(code)) {
+		return "", p.errorAt(start, `invalid code point U+%X`, code)
+	}
+
+	return string(rune(code)), nil
+}
+
+func (p *yamlParser) scanHex(digits int) (int, error) {
+	var b strings.Builder
+	for i := 0; i < digits; i++ {
+		b.WriteRune(p.peek())
+		if !p.eof() {
+			p.advance()
+		}
+	}
+	code, err := strconv.ParseUint(b.String(), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf(`%q is not a hexadecimal number of %d digits`, b.String(), digits)
+	}
+
+	return int(code), nil
+}
+
+// parseBlockScalar parses a literal or folded block scalar whose parent node is at the indentation indent.
+func (p *yamlParser) parseBlockScalar(indent int) (string, error) {
+	literal := p.peek() == '|'
+	p.advance()
+	chomping, explicit := ' ', 0
+	for i := 0; i < 2; i++ {
+		switch r := p.peek(); {
+		case (r == '-' || r == '+') && chomping == ' ':
+			chomping = r
+			p.advance()
+		case '1' <= r && r <= '9' && explicit == 0:
+			explicit = int(r - '0')
+			p.advance()
+		}
+	}
+	if err := p.expectLineEnd(); err != nil {
+		return "", err
+	}
+	p.skipLine()
+	if !p.eof() {
+		p.advance()
+	}
+
+	contentIndent := -1
+	if explicit > 0 {
+		contentIndent = max(indent, 0) + explicit
+	}
+	lines := []string{}
+	for !p.eof() && !p.atDocumentMarker() {
+		n := 0
+		for p.peekAt(n) == ' ' {
+			n++
+		}
+		blank := n
+		for isYAMLBlank(p.peekAt(blank)) {
+			blank++
+		}
+		if r := p.peekAt(blank); r == '\n' || r == yamlEOF {
+			if contentIndent >= 0 && n > contentIndent {
+				lines = append(lines, string(p.src[p.pos+contentIndent:p.pos+blank]))
+			} else {
+				lines = append(lines, "")
+			}
+			p.skipLine()
+			if !p.eof() {
+				p.advance()
+			}
+			continue
+		}
+		if contentIndent < 0 {
+			if n <= indent {
+				break
+			}
+			contentIndent = n
+		}
+		if n < contentIndent {
+			break
+		}
+		p.pos += contentIndent
+		start := p.pos
+		p.skipLine()
+		lines = append(lines, string(p.src[start:p.pos]))
+		if !p.eof() {
+			p.advance()
+		}
+	}
+
+	last := len(lines) - 1
+	for last >= 0 && lines[last] == "" {
+		last--
+	}
+	body, trailing := lines[:last+1], len(lines)-last-1
+
+	var text string
+	if literal {
+		text = strings.Join(body, "\n")
+	} else {
+		text = foldYAMLLines(body)
+	}
+	switch {
+	case chomping == '-':
+		return text, nil
+	case chomping == '+' && len(body) == 0:
+		return strings.Repeat("\n", trailing), nil
+	case chomping == '+':
+		return text + "\n" + strings.Repeat("\n", trailing), nil
+	case len(body) == 0:
+		return "", nil
+	default:
+		return text + "\n", nil
+	}
+}
+
+// foldYAMLLines joins the lines of a folded block scalar.
+// A line break between two lines is folded into a space unless the lines are separated by empty lines or either of them is more indented.
+func foldYAMLLines(lines []string) string {
+	moreIndented := func(line string) bool {
+		return isYAMLBlank(rune(line[0]))
+	}
+
+	var b strings.Builder
+	prev := -1
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		switch empties := i - prev - 1; {
+		case prev < 0:
+			b.WriteString(strings.Repeat("\n", i))
+		case moreIndented(line) || moreIndented(lines[prev]):
+			b.WriteString(strings.Repeat("\n", empties+1))
+		case empties == 0:
+			b.WriteByte(' ')
+		default:
+			b.WriteString(strings.Repeat("\n", empties))
+		}
+		b.WriteString(line)
+		prev = i
+	}
+
+	return b.String()
+}
+
+// parseFlowNode parses a node in the flow context.
+func (p *yamlParser) parseFlowNode() (Value, error) {
+	anchor, tag := "", ""
+	for p.peek() == '&' || p.peek() == '!' {
+		if p.peek() == '&' {
+			anchor = p.scanName()
+		} else {
+			tag = p.scanTag()
+		}
+		p.skipToContent()
+	}
+
+	start := p.mark()
+	var v Value
+	var err error
+	switch r := p.peek(); {
+	case r == '*':
+		v, err = p.parseAlias()
+	case r == '[':
+		v, err = p.parseFlowSequence()
+		if err == nil {
+			err = yamlCollectionTag(tag, v, start)
+		}
+	case r == '{':
+		v, err = p.parseFlowMapping()
+		if err == nil {
+			err = yamlCollectionTag(tag, v, start)
+		}
+	case r == '"' || r == '\'':
+		var s string
+		if s, err = p.scanQuoted(); err == nil {
+			v, err = yamlScalar(s, false, tag, start)
+		}
+	case r == ',' || r == ']' || r == '}' || (r == ':' && (p.wsAt(1) || isYAMLFlowIndicator(p.peekAt(1)))):
+		v, err = yamlScalar("", true, tag, start)
+	default:
+		var s string
+		if s, err = p.scanPlain(-1, true, true); err == nil {
+			v, err = yamlScalar(s, true, tag, start)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.setAnchor(anchor, v)
+
+	return v, nil
+}
+
+func (p *yamlParser) parseFlowSequence() (Value, error) {
+	start := p.mark()
+	p.advance()
+	arr := Array()
+	for {
+		p.skipToContent()
+		switch {
+		case p.eof() || p.atDocumentMarker():
+			return nil, p.errorAt(start, `unterminated flow sequence`)
+		case p.peek() == ']':
+			p.advance()
+			return arr, nil
+		case p.peek() == '?' && p.wsAt(1):
+			return nil, p.errorf(`complex mapping keys are not supported`)
+		}
+
+		keyStart := p.mark()
+		elm, err := p.parseFlowNode()
+		if err != nil {
+			return nil, err
+		}
+		p.skipToContent()
+		if p.peek() == ':' {
+			if err := p.checkKey(elm, keyStart); err != nil {
+				return nil, err
+			}
+			p.advance()
+			p.skipToContent()
+			val := Null()
+			if r := p.peek(); r != ',' && r != ']' {
+				if val, err = p.parseFlowNode(); err != nil {
+					return nil, err
+				}
+				p.skipToContent()
+			}
+			elm = Object(Props{elm.StringGet(): val})
+		}
+		arr.ArrayAddElm(elm)
+
+		switch p.peek() {
+		case ',':
+			p.advance()
+		case ']':
+		default:
+			return nil, p.errorf(`',' or ']' expected in flow sequence`)
+		}
+	}
+}
+
+func (p *yamlParser) parseFlowMapping() (Value, error) {
+	start := p.mark()
+	p.advance()
+	obj := Object()
+	for {
+		p.skipToContent()
+		switch {
+		case p.eof() || p.atDocumentMarker():
+			return nil, p.errorAt(start, `unterminated flow mapping`)
+		case p.peek() == '}':
+			p.advance()
+			return obj, nil
+		case p.peek() == '?' && p.wsAt(1):
+			return nil, p.errorf(`complex mapping keys are not supported`)
+		}
+
+		keyStart := p.mark()
+		key, err := p.parseFlowNode()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkKey(key, keyStart); err != nil {
+			return nil, err
+		}
+		if obj.ObjectHasElm(key.StringGet()) {
+			return nil, p.errorAt(keyStart, `duplicate mapping key %q`, key.StringGet())
+		}
+		p.skipToContent()
+		val := Null()
+		if p.peek() == ':' {
+			p.advance()
+			p.skipToContent()
+			if r := p.peek(); r != ',' && r != '}' {
+				if val, err = p.parseFlowNode(); err != nil {
+					return nil, err
+				}
+				p.skipToContent()
+			}
+		}
+		obj.ObjectSetElm(key.StringGet(), val)
+
+		switch p.peek() {
+		case ',':
+			p.advance()
+		case '}':
+		default:
+			return nil, p.errorf(`',' or '}' expected in flow mapping`)
+		}
+	}
+}
+
+var (
+	yamlIntPattern        = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlOctPattern        = regexp.MustCompile(`^0o[0-7]+$`)
+	yamlHexPattern        = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	yamlFloatPattern      = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlInfNaNPattern     = regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+	yamlJSONNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+)
+
+// resolveYAMLPlain resolves a plain scalar to a JSON value by the YAML 1.2 core schema.
+func resolveYAMLPlain(s string) (Value, error) {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return Null(), nil
+	case "true", "True", "TRUE":
+		return Boolean(true), nil
+	case "false", "False", "FALSE":
+		return Boolean(false), nil
+	}
+
+	switch {
+	case yamlIntPattern.MatchString(s):
+		n, _ := new(big.Int).SetString(strings.TrimPrefix(s, "+"), 10)
+		return Number(json.Number(n.String())), nil
+	case yamlOctPattern.MatchString(s):
+		n, _ := new(big.Int).SetString(s[2:], 8)
+		return Number(json.Number(n.String())), nil
+	case yamlHexPattern.MatchString(s):
+		n, _ := new(big.Int).SetString(s[2:], 16)
+		return Number(json.Number(n.String())), nil
+	case yamlFloatPattern.MatchString(s):
+		if t := strings.TrimPrefix(s, "+"); yamlJSONNumberPattern.MatchString(t) {
+			return Number(json.Number(t)), nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`number %s is out of range`, s)
+		}
+		return Number(json.Number(strconv.FormatFloat(f, 'g', -1, 64))), nil
+	case yamlInfNaNPattern.MatchString(s):
+		return nil, fmt.Errorf(`%s cannot be represented in JSON`, s)
+	default:
+		return String(s), nil
+	}
+}
+
+// yamlScalar converts a scalar to a JSON value according to the tag.
+// A plain scalar without a tag is resolved by the YAML 1.2 core schema and the other scalars are strings.
+func yamlScalar(s string, plain bool, tag string, m yamlMark) (Value, error) {
+	expect := func(typ Type) (Value, error) {
+		v, err := resolveYAMLPlain(s)
+		if err != nil {
+			return nil, fmt.Errorf(`line %d: %w`, m.line+1, err)
+		}
+		if v.Type() != typ {
+			return nil, fmt.Errorf(`line %d: %q is not valid for tag %s`, m.line+1, s, tag)
+		}
+		return v, nil
+	}
+
+	switch {
+	case tag == "":
+		if !plain {
+			return String(s), nil
+		}
+		v, err := resolveYAMLPlain(s)
+		if err != nil {
+			return nil, fmt.Errorf(`line %d: %w`, m.line+1, err)
+		}
+		return v, nil
+	case tag == "!" || tag == "!!str":
+		return String(s), nil
+	case tag == "!!null":
+		return expect(TypeNull)
+	case tag == "!!bool":
+		return expect(TypeBoolean)
+	case tag == "!!int":
+		v, err := expect(TypeNumber)
+		if err == nil && strings.ContainsAny(v.NumberGet().String(), ".eE") {
+			return nil, fmt.Errorf(`line %d: %q is not valid for tag %s`, m.line+1, s, tag)
+		}
+		return v, err
+	case tag == "!!float":
+		return expect(TypeNumber)
+	case tag == "!!map" || tag == "!!seq":
+		return nil, fmt.Errorf(`line %d: scalar is not valid for tag %s`, m.line+1, tag)
+	case yamlLocalTag(tag):
+		return String(s), nil
+	default:
+		return nil, fmt.Errorf(`line %d: unsupported tag %s`, m.line+1, tag)
+	}
+}
+
+// yamlLocalTag returns true if a tag is a local tag such as !custom, which is ignored.
+func yamlLocalTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!") && !strings.HasPrefix(tag, "!<")
+}
+
+func yamlCollectionTag(tag string, v Value, m yamlMark) error {
+	switch {
+	case tag == "" || tag == "!" || yamlLocalTag(tag):
+		return nil
+	case tag == "!!map" && v.Type() == TypeObject, tag == "!!seq" && v.Type() == TypeArray:
+		return nil
+	case tag == "!!map" || tag == "!!seq" || tag == "!!str" || tag == "!!null" || tag == "!!bool" || tag == "!!int" || tag == "!!float":
+		return fmt.Errorf(`line %d: %v is not valid for tag %s`, m.line+1, v.Type(), tag)
+	default:
+		return fmt.Errorf(`line %d: unsupported tag %s`, m.line+1, tag)
+	}
+}
@@ -0,0 +1,123 @@
+package jsonvalue_test
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Jumpaku/go-json-value"
+)
+
+func TestFromYAML(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "empty", in: "", want: `null`},
+		{name: "comment only", in: "# comment\n", want: `null`},
+		{name: "scalars", in: "[null, ~, true, False, 1, +2, -3.5, 1e3, 0o17, 0xFF, .5, text]", want: `[null,null,true,false,1,2,-3.5,1e3,15,255,0.5,"text"]`},
+		{name: "block mapping", in: "a: 1\nb:\n  c: x # comment\n  d: \"y\"\ne:\n", want: `{"a":1,"b":{"c":"x","d":"y"},"e":null}`},
+		{name: "block sequence", in: "- a\n- - b\n  - c\n- d: 1\n  e: 2\n-\n", want: `["a",["b","c"],{"d":1,"e":2},null]`},
+		{name: "sequence in mapping", in: "a:\n- 1\n- 2\nb: 3\n", want: `{"a":[1,2],"b":3}`},
+		{name: "flow", in: "{a: [1, {b: c}], 'd': e, f, g: [h: i]}", want: `{"a":[1,{"b":"c"}],"d":"e","f":null,"g":[{"h":"i"}]}`},
+		{name: "multi-line flow", in: "a: [\n  1,\n  2, # two\n]\n", want: `{"a":[1,2]}`},
+		{name: "plain multi-line", in: "a: b\n  c\n\n  d\ne: f\n", want: `{"a":"b c\nd","e":"f"}`},
+		{name: "plain with indicators", in: "a: b:c #d\nurl: http://example.com/#x\n", want: `{"a":"b:c","url":"http://example.com/#x"}`},
+		{name: "single quoted", in: "'it''s\n  folded\n\n  twice'", want: `"it's folded\ntwice"`},
+		{name: "double quoted", in: `"a\tb\u00e9\x41\U0001F600\ud83d\ude00\/\\\" \` + "\n  c\"", want: `"a\tbéA😀😀/\\\" c"`},
+		{name: "literal", in: "a: |\n  x\n   y\n\n  z\n\nb: 1\n", want: `{"a":"x\n y\n\nz\n","b":1}`},
+		{name: "literal strip", in: "a: |-\n  x\n\n", want: `{"a":"x"}`},
+		{name: "literal keep", in: "a: |+\n  x\n\n", want: `{"a":"x\n\n"}`},
+		{name: "literal explicit indentation", in: "a: |2\n   x\n  y\n", want: `{"a":" x\ny\n"}`},
+		{name: "folded", in: "a: >\n  x\n  y\n\n  z\n    w\n  v\n", want: `{"a":"x y\nz\n  w\nv\n"}`},
+		{name: "anchors and aliases", in: "a: &x\n  b: [1]\nc: *x\nd: &y 2\ne: [*y, *x]\n", want: `{"a":{"b":[1]},"c":{"b":[1]},"d":2,"e":[2,{"b":[1]}]}`},
+		{name: "collections on lines after anchors and tags", in: "a: &x\n- 1\nb: !!map\n  c: *x\n", want: `{"a":[1],"b":{"c":[1]}}`},
+		{name: "tags", in: "- !!str 1\n- !!int '2'\n- !!float 3\n- !!null ''\n- !!bool true\n- !!map {}\n- !custom x\n- ! 4\n", want: `["1",2,3,null,true,{},"x","4"]`},
+		{name: "quoted keys", in: "'1': a\n\"true\": b\n", want: `{"1":"a","true":"b"}`},
+		{name: "explicit document", in: "%YAML 1.2\n---\na: 1\n...\n", want: `{"a":1}`},
+		{name: "document with content on marker", in: "--- |\n  text\n", want: `"text\n"`},
+		{name: "multiple documents", in: "---\na: 1\n---\n- 2\n--- 3\n---\n", want: `[{"a":1},[2],3,null]`},
+		{name: "crlf", in: "a: 1\r\nb: 2\r\n", want: `{"a":1,"b":2}`},
+		{name: "big integer", in: "123456789012345678901234567890", want: `123456789012345678901234567890`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := jsonvalue.FromYAML([]byte(testCase.in))
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(got, mustUnmarshal(t, testCase.want)), true)
+		})
+	}
+}
+
+func TestFromYAML_Error(t *testing.T) {
+	testCases := map[string]string{
+		"integer key":               "1: a\n",
+		"boolean key":               "true: a\n",
+		"null key":                  ": a\n",
+		"flow integer key":          "{1: a}",
+		"complex key":               "? a\n: b\n",
+		"collection key":            "[a]: b\n",
+		"duplicate key":             "a: 1\na: 2\n",
+		"undefined alias":           "a: *x\n",
+		"bad indentation":           "a:\n    b: 1\n  c: 2\n",
+		"unterminated":              "a: 'b\n",
+		"unterminated flow":         "[a, b",
+		"inf":                       "a: .inf\n",
+		"nan":                       "a: .NaN\n",
+		"bad escape":                `"\q"`,
+		"bad tag":                   "!!int x\n",
+		"unsupported tag":           "!!set {}\n",
+		"trailing content":          "a: [1] x\n",
+		"invalid utf8":              "\xff",
+		"mapping after key":         "a: b: c\n",
+		"sequence after key":        "a: - 1\n",
+		"empty sequence after key":  "a: -\n",
+		"tagged sequence after key": "a: &x - 1\n",
+		"billion laughs": `a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+h: &h [*g,*g,*g,*g,*g,*g,*g,*g,*g]
+i: &i [*h,*h,*h,*h,*h,*h,*h,*h,*h]
+`,
+	}
+	for name, in := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jsonvalue.FromYAML([]byte(in))
+			IsNotNil(t, err)
+		})
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	type testCase struct {
+		name string
+		in   string
+		want string
+	}
+	testCases := []testCase{
+		{name: "null", in: `null`, want: "null\n"},
+		{name: "number", in: `1.50`, want: "1.50\n"},
+		{name: "empty object", in: `{}`, want: "{}\n"},
+		{name: "object", in: `{"b":[1,{"c":true}],"a":{"x":null,"y":[]}}`, want: "a:\n  x: null\n  y: []\nb:\n  - 1\n  - c: true\n"},
+		{name: "nested arrays", in: `[[1,2],[],{"a":[3]}]`, want: "- - 1\n  - 2\n- []\n- a:\n    - 3\n"},
+		{name: "quoted strings", in: `["true","1","","- a","a: b","a #b"," a","null","x\ty"]`, want: "- \"true\"\n- \"1\"\n- \"\"\n- \"- a\"\n- \"a: b\"\n- \"a #b\"\n- \" a\"\n- \"null\"\n- \"x\\ty\"\n"},
+		{name: "plain strings", in: `{"key with space":"value","url":"http://example.com"}`, want: "key with space: value\nurl: http://example.com\n"},
+		{name: "literal", in: `{"a":"x\n y\n","b":"x\ny","c":"x\n\n"}`, want: "a: |\n  x\n   y\nb: |-\n  x\n  y\nc: |+\n  x\n\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			v := mustUnmarshal(t, testCase.in)
+			got, err := jsonvalue.ToYAML(v)
+			equal(t, err, nil)
+			equal(t, string(got), testCase.want)
+
+			decoded, err := jsonvalue.FromYAML(got)
+			equal(t, err, nil)
+			equal(t, jsonvalue.Equal(decoded, v), true)
+		})
+	}
+}